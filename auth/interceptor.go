@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Methods reachable without a token, e.g. the Consul health check
+const publicMethodPrefix = "/grpc.health.v1.Health/"
+
+type userIdKey struct{}
+
+func NewContext(ctx context.Context, userId gocql.UUID) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+// UserIdFromContext returns the authenticated caller set by the interceptors
+func UserIdFromContext(ctx context.Context) (gocql.UUID, bool) {
+	userId, ok := ctx.Value(userIdKey{}).(gocql.UUID)
+	return userId, ok
+}
+
+func (v *Verifier) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	userId, err := v.Verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return NewContext(ctx, userId), nil
+}
+
+func (v *Verifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, publicMethodPrefix) {
+			return handler(ctx, req)
+		}
+
+		authCtx, err := v.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(authCtx, req)
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (v *Verifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, publicMethodPrefix) {
+			return handler(srv, stream)
+		}
+
+		authCtx, err := v.authenticate(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: authCtx})
+	}
+}
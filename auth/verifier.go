@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/gocql/gocql"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+var signingMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// Verifier validates access tokens against locally configured keys, no network call is made
+type Verifier struct {
+	keys       map[string]crypto.PublicKey // keyed by kid, "" holds the single configured public key
+	parserOpts []jwt.ParserOption
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// NewVerifierFromConfig loads the key from auth.jwt.public-key-file (PEM) or auth.jwt.jwks-file (JWKS)
+func NewVerifierFromConfig() (*Verifier, error) {
+	verifier := &Verifier{
+		keys:       map[string]crypto.PublicKey{},
+		parserOpts: []jwt.ParserOption{jwt.WithValidMethods(signingMethods), jwt.WithExpirationRequired()},
+	}
+
+	if issuer, err := helper.ReadConfig[string]("auth.jwt.issuer"); err == nil && issuer != "" {
+		verifier.parserOpts = append(verifier.parserOpts, jwt.WithIssuer(issuer))
+	}
+	if audience, err := helper.ReadConfig[string]("auth.jwt.audience"); err == nil && audience != "" {
+		verifier.parserOpts = append(verifier.parserOpts, jwt.WithAudience(audience))
+	}
+
+	if publicKeyFile, err := helper.ReadConfig[string]("auth.jwt.public-key-file"); err == nil && publicKeyFile != "" {
+		key, err := readPublicKeyFile(publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		verifier.keys[""] = key
+	}
+
+	if jwksFile, err := helper.ReadConfig[string]("auth.jwt.jwks-file"); err == nil && jwksFile != "" {
+		if err := verifier.loadJwks(jwksFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(verifier.keys) == 0 {
+		return nil, errors.New("missing auth.jwt.public-key-file or auth.jwt.jwks-file config")
+	}
+
+	return verifier, nil
+}
+
+// Verify validates the token and returns the user id carried in its subject
+func (v *Verifier) Verify(tokenString string) (gocql.UUID, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, v.keyFunc, v.parserOpts...)
+	if err != nil {
+		return gocql.UUID{}, err
+	}
+
+	subject, err := token.Claims.GetSubject()
+	if err != nil {
+		return gocql.UUID{}, err
+	}
+
+	return gocql.ParseUUID(subject)
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if key, ok := v.keys[""]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *Verifier) loadJwks(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var keySet jsonWebKeySet
+	if err := json.Unmarshal(raw, &keySet); err != nil {
+		return fmt.Errorf("invalid jwks file: %w", err)
+	}
+
+	for _, jwk := range keySet.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("invalid jwk %q: %w", jwk.Kid, err)
+		}
+		v.keys[jwk.Kid] = key
+	}
+
+	return nil
+}
+
+func readPublicKeyFile(path string) (crypto.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("public key file is not PEM encoded")
+	}
+
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -3,4 +3,22 @@ package consts
 const KeySpace = "ks_chat"
 const ConversationTableName = "conversations"
 const ChatMessageTableName = "messages"
+const ChatMessageHistoryTableName = "message_histories"
+const HiddenChatMessageTableName = "hidden_messages"
+const MessageReactionTableName = "message_reactions"
 const ParticipantTableName = "conversation_participants"
+const ReadCursorTableName = "read_cursors"
+const MessageStatusTableName = "message_statuses"
+const IdempotencyKeyTableName = "message_idempotency_keys"
+const ConversationSequenceTableName = "conversation_sequences"
+const OutboxTableName = "outbox"
+const OutboxLeaseTableName = "outbox_leases"
+const MessageByConversationTableName = "messages_by_conversation"
+const MessageBucketTableName = "message_buckets"
+const SchemaMigrationTableName = "schema_migrations"
+const SchemaMigrationLockTableName = "schema_migration_locks"
+const UnreadCountTableName = "unread_counts"
+const MessageSequenceTableName = "message_sequences"
+const OutboxEntryTableName = "outbox_entries"
+const OutboxCursorTableName = "outbox_cursors"
+const OutboxDeadEntryTableName = "outbox_dead_entries"
@@ -0,0 +1,12 @@
+// Package testenv is imported for its side effect by the test packages. go-common-utils/common creates its
+// Elasticsearch client when it is initialized and exits without a host, this package sorts and so initializes first.
+// The client is never used by the tests, the host only has to be set.
+package testenv
+
+import "os"
+
+func init() {
+	if os.Getenv("DATA_DATABASE_ELASTICSEARCH_HOST") == "" {
+		_ = os.Setenv("DATA_DATABASE_ELASTICSEARCH_HOST", "localhost")
+	}
+}
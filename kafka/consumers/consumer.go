@@ -0,0 +1,15 @@
+package consumers
+
+import (
+	"github.com/TripConnect/chat-service/realtime"
+	"github.com/TripConnect/chat-service/store"
+)
+
+// Consumer handles the chat topics, the handlers reach Cassandra and Kafka only through its dependencies
+type Consumer struct {
+	Brokers []string
+	Store   store.Storage
+	Search  store.Search
+	Events  store.Events
+	Hub     *realtime.Hub
+}
@@ -0,0 +1,215 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+// Headers set on dead lettered records
+const (
+	headerErrorReason    = "x-error-reason"
+	headerAttempts       = "x-attempts"
+	headerOriginalTopic  = "x-original-topic"
+	headerOriginalOffset = "x-original-offset"
+	headerFailedAt       = "x-failed-at"
+	headerReplayedAt     = "x-replayed-at"
+)
+
+const (
+	defaultMaxAttempts     = 5
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultMaxRetryBackoff = 10 * time.Second
+	replayIdleTimeout      = 5 * time.Second
+)
+
+// permanentError marks a failure that retrying cannot fix, e.g. a record that cannot be decoded
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanentErr permanentError
+	return errors.As(err, &permanentErr)
+}
+
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+// newRetryPolicy reads <prefix>.max-attempts, <prefix>.retry-backoff-ms and <prefix>.max-retry-backoff-ms
+func newRetryPolicy(prefix string) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultRetryBackoff,
+		maxBackoff:  defaultMaxRetryBackoff,
+	}
+	if maxAttempts, err := helper.ReadConfig[int](prefix + ".max-attempts"); err == nil && maxAttempts > 0 {
+		policy.maxAttempts = maxAttempts
+	}
+	if backoff, err := helper.ReadConfig[int](prefix + ".retry-backoff-ms"); err == nil && backoff > 0 {
+		policy.backoff = time.Duration(backoff) * time.Millisecond
+	}
+	if maxBackoff, err := helper.ReadConfig[int](prefix + ".max-retry-backoff-ms"); err == nil && maxBackoff > 0 {
+		policy.maxBackoff = time.Duration(maxBackoff) * time.Millisecond
+	}
+	return policy
+}
+
+// run calls fn until it succeeds, fails permanently or runs out of attempts, the backoff doubles between attempts
+func (p retryPolicy) run(ctx context.Context, fn func() error) (int, error) {
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return attempt, nil
+		}
+
+		if isPermanent(err) || attempt >= p.maxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, p.maxBackoff)
+	}
+}
+
+// retryUntilDone calls fn until it succeeds, failures are logged and retried with the same doubling backoff.
+// It only gives up when the context is cancelled.
+func (p retryPolicy) retryUntilDone(ctx context.Context, fn func() error) error {
+	backoff := p.backoff
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		log.Printf("Retrying in %s after %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, p.maxBackoff)
+	}
+}
+
+func (c *Consumer) publishDeadLetter(ctx context.Context, dlqTopic string, m kafka.Message, cause error, attempts int) error {
+	if dlqTopic == "" {
+		return errors.New("missing dead letter topic config")
+	}
+
+	headers := append(withoutDeadLetterHeaders(m.Headers),
+		kafka.Header{Key: headerErrorReason, Value: []byte(cause.Error())},
+		kafka.Header{Key: headerAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: headerOriginalTopic, Value: []byte(m.Topic)},
+		kafka.Header{Key: headerOriginalOffset, Value: []byte(fmt.Sprintf("%d/%d", m.Partition, m.Offset))},
+		kafka.Header{Key: headerFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	return c.Events.PublishRecord(ctx, kafka.Message{
+		Topic:   dlqTopic,
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+	})
+}
+
+func withoutDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	kept := []kafka.Header{}
+	for _, header := range headers {
+		if !strings.HasPrefix(header.Key, "x-") {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+func headerValue(headers []kafka.Header, key string) string {
+	for _, header := range headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// ReplayPendingDeadLetters moves up to limit records (0 for all) from the pending dead letter topic
+// back to the topic they failed on, it stops once the dead letter topic stays idle
+func (c *Consumer) ReplayPendingDeadLetters(ctx context.Context, limit int) (int, error) {
+	dlqTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-sys-internal-pending-dlq")
+	pendingTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-sys-internal-pending-queue")
+	if dlqTopic == "" {
+		return 0, errors.New("missing dead letter topic config")
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  c.Brokers,
+		GroupID:  "chat-service-dlq-replay",
+		Topic:    dlqTopic,
+		MaxBytes: 10e6, // 10MB
+	})
+	defer reader.Close()
+
+	replayed := 0
+	for limit == 0 || replayed < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, replayIdleTimeout)
+		m, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return replayed, nil
+		}
+		if err != nil {
+			return replayed, err
+		}
+
+		targetTopic := headerValue(m.Headers, headerOriginalTopic)
+		if targetTopic == "" {
+			targetTopic = pendingTopic
+		}
+
+		err = c.Events.PublishRecord(ctx, kafka.Message{
+			Topic: targetTopic,
+			Key:   m.Key,
+			Value: m.Value,
+			Headers: append(withoutDeadLetterHeaders(m.Headers),
+				kafka.Header{Key: headerReplayedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+			),
+		})
+		if err != nil {
+			return replayed, err
+		}
+
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
@@ -0,0 +1,55 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+// ListenMembershipQueue stops the realtime subscriptions of the members who left or were removed, like the sent
+// messages every instance reads every event in a group of its own
+func (c *Consumer) ListenMembershipQueue(ctx context.Context) {
+	membershipTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-membership-changed")
+	if membershipTopic == "" {
+		fmt.Printf("missing membership topic, subscriptions only stop for members removed on this instance")
+		return
+	}
+
+	var listener = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     c.Brokers,
+		GroupID:     "chat-service-realtime-membership-" + realtimeInstanceId(),
+		Topic:       membershipTopic,
+		StartOffset: kafka.LastOffset,
+		MaxBytes:    10e6, // 10MB
+	})
+	defer listener.Close()
+
+	for {
+		m, err := listener.ReadMessage(ctx)
+		if err != nil {
+			fmt.Printf("error while consume membership event %v", err)
+			break
+		}
+
+		c.handleMembershipEvent(m)
+	}
+}
+
+func (c *Consumer) handleMembershipEvent(m kafka.Message) {
+	var event models.KafkaMembershipEvent
+	if err := json.Unmarshal(m.Value, &event); err != nil {
+		fmt.Printf("error while consume membership event %v", err)
+		return
+	}
+
+	if event.Action != models.MembershipRemoved && event.Action != models.MembershipLeft {
+		return
+	}
+	for _, userId := range event.UserIds {
+		c.Hub.RemoveMember(event.ConversationId, userId)
+	}
+}
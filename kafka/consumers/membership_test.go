@@ -0,0 +1,65 @@
+package consumers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestHandleMembershipEvent(t *testing.T) {
+	otherId := gocql.MustRandomUUID()
+
+	tests := []struct {
+		name        string
+		action      models.MembershipAction
+		wantRevoked bool
+	}{
+		{
+			name:        "removed member stops streaming",
+			action:      models.MembershipRemoved,
+			wantRevoked: true,
+		},
+		{
+			name:        "member who left stops streaming",
+			action:      models.MembershipLeft,
+			wantRevoked: true,
+		},
+		{
+			name:   "role change keeps streaming",
+			action: models.MembershipRoleChanged,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			member := f.consumer.Hub.Subscribe(memberId, []gocql.UUID{conversationId})
+			other := f.consumer.Hub.Subscribe(otherId, []gocql.UUID{conversationId})
+			value, err := json.Marshal(models.KafkaMembershipEvent{ConversationId: conversationId, UserIds: []gocql.UUID{memberId}, Action: tc.action})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f.consumer.handleMembershipEvent(kafka.Message{Value: value})
+
+			if revoked := isClosed(member.Revoked()); revoked != tc.wantRevoked {
+				t.Fatalf("member revoked = %v, want %v", revoked, tc.wantRevoked)
+			}
+			if isClosed(other.Revoked()) || !f.consumer.Hub.Follows(other, conversationId) {
+				t.Fatal("other member stopped streaming")
+			}
+		})
+	}
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,139 @@
+package consumers
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const workerQueueSize = 64
+
+// committer is the part of kafka.Reader the tracker commits with
+type committer interface {
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// offsetTracker commits a partition offset only once every record before it was handled,
+// records finish out of order because they are spread over workers
+type offsetTracker struct {
+	mu        sync.Mutex
+	reader    committer
+	fetched   map[int]int64          // last fetched offset
+	inFlight  map[int][]int64        // fetched offsets not committed yet, in fetch order
+	handled   map[int]map[int64]bool // offsets handled but waiting for an earlier one
+	committed map[int]int64          // last committed offset
+	records   map[int]map[int64]kafka.Message
+}
+
+func newOffsetTracker(reader committer) *offsetTracker {
+	return &offsetTracker{
+		reader:    reader,
+		fetched:   map[int]int64{},
+		inFlight:  map[int][]int64{},
+		handled:   map[int]map[int64]bool{},
+		committed: map[int]int64{},
+		records:   map[int]map[int64]kafka.Message{},
+	}
+}
+
+// track registers the fetched record. A record at or before the last fetched offset of its partition means fetching
+// restarted there, after a rebalance or a reconnect, so the state of the partition is dropped: its pending offsets
+// would otherwise block the commits or be committed although the new fetches were not handled yet.
+func (t *offsetTracker) track(m kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.fetched[m.Partition]; ok && m.Offset <= last {
+		t.reset(m.Partition)
+	}
+	t.fetched[m.Partition] = m.Offset
+
+	t.inFlight[m.Partition] = append(t.inFlight[m.Partition], m.Offset)
+	if t.records[m.Partition] == nil {
+		t.records[m.Partition] = map[int64]kafka.Message{}
+		t.handled[m.Partition] = map[int64]bool{}
+	}
+	t.records[m.Partition][m.Offset] = m
+}
+
+func (t *offsetTracker) reset(partition int) {
+	delete(t.fetched, partition)
+	delete(t.inFlight, partition)
+	delete(t.handled, partition)
+	delete(t.committed, partition)
+	delete(t.records, partition)
+}
+
+// done marks the record handled and commits the longest handled prefix of its partition.
+// Records fetched before a reset are not tracked anymore and are skipped.
+func (t *offsetTracker) done(ctx context.Context, m kafka.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.records[m.Partition][m.Offset]; !ok {
+		return nil
+	}
+	t.handled[m.Partition][m.Offset] = true
+
+	var commit *kafka.Message
+	offsets := t.inFlight[m.Partition]
+	for len(offsets) > 0 && t.handled[m.Partition][offsets[0]] {
+		record := t.records[m.Partition][offsets[0]]
+		commit = &record
+		delete(t.handled[m.Partition], offsets[0])
+		delete(t.records[m.Partition], offsets[0])
+		offsets = offsets[1:]
+	}
+	t.inFlight[m.Partition] = offsets
+
+	if commit == nil {
+		return nil
+	}
+	if last, ok := t.committed[m.Partition]; ok && last >= commit.Offset {
+		return nil
+	}
+	if err := t.reader.CommitMessages(ctx, *commit); err != nil {
+		return err
+	}
+	t.committed[m.Partition] = commit.Offset
+	return nil
+}
+
+// orderedWorkers handles records on a fixed number of goroutines, records sharing an ordering key
+// always land on the same worker so they are handled in the order they were fetched
+type orderedWorkers struct {
+	queues []chan kafka.Message
+	wg     sync.WaitGroup
+}
+
+func newOrderedWorkers(concurrency int, handle func(kafka.Message)) *orderedWorkers {
+	workers := &orderedWorkers{queues: make([]chan kafka.Message, concurrency)}
+	workers.wg.Add(concurrency)
+	for i := range workers.queues {
+		queue := make(chan kafka.Message, workerQueueSize)
+		workers.queues[i] = queue
+		go func() {
+			defer workers.wg.Done()
+			for m := range queue {
+				handle(m)
+			}
+		}()
+	}
+	return workers
+}
+
+func (w *orderedWorkers) dispatch(orderingKey string, m kafka.Message) {
+	hash := fnv.New32a()
+	hash.Write([]byte(orderingKey))
+	w.queues[hash.Sum32()%uint32(len(w.queues))] <- m
+}
+
+// stop waits for the queued records to be handled
+func (w *orderedWorkers) stop() {
+	for _, queue := range w.queues {
+		close(queue)
+	}
+	w.wg.Wait()
+}
@@ -0,0 +1,82 @@
+package consumers
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeCommitter struct {
+	offsets []int64
+}
+
+func (c *fakeCommitter) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		c.offsets = append(c.offsets, m.Offset)
+	}
+	return nil
+}
+
+func TestOffsetTracker(t *testing.T) {
+	record := func(partition int, offset int64) kafka.Message {
+		return kafka.Message{Partition: partition, Offset: offset}
+	}
+
+	tests := []struct {
+		name        string
+		run         func(ctx context.Context, tracker *offsetTracker)
+		wantCommits []int64
+	}{
+		{
+			name: "out of order records commit once the earlier one is handled",
+			run: func(ctx context.Context, tracker *offsetTracker) {
+				for _, offset := range []int64{5, 6, 7} {
+					tracker.track(record(0, offset))
+				}
+				_ = tracker.done(ctx, record(0, 6))
+				_ = tracker.done(ctx, record(0, 5))
+				_ = tracker.done(ctx, record(0, 7))
+			},
+			wantCommits: []int64{6, 7},
+		},
+		{
+			name: "records before a restarted fetch are dropped",
+			run: func(ctx context.Context, tracker *offsetTracker) {
+				for _, offset := range []int64{5, 6, 7} {
+					tracker.track(record(0, offset))
+				}
+				_ = tracker.done(ctx, record(0, 7))
+				// Fetching restarts at the committed offset, 7 was handled before the reset and must not be committed
+				tracker.track(record(0, 5))
+				tracker.track(record(0, 6))
+				_ = tracker.done(ctx, record(0, 6))
+				_ = tracker.done(ctx, record(0, 5))
+			},
+			wantCommits: []int64{6},
+		},
+		{
+			name: "a restart keeps the other partitions",
+			run: func(ctx context.Context, tracker *offsetTracker) {
+				tracker.track(record(0, 5))
+				tracker.track(record(1, 9))
+				tracker.track(record(1, 10))
+				_ = tracker.done(ctx, record(1, 10))
+				tracker.track(record(0, 5))
+				_ = tracker.done(ctx, record(1, 9))
+			},
+			wantCommits: []int64{10},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := &fakeCommitter{}
+			tc.run(context.Background(), newOffsetTracker(reader))
+			if !slices.Equal(reader.offsets, tc.wantCommits) {
+				t.Fatalf("commits = %v, want %v", reader.offsets, tc.wantCommits)
+			}
+		})
+	}
+}
@@ -5,65 +5,265 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
-	"github.com/TripConnect/chat-service/consts"
 	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
 	"github.com/segmentio/kafka-go"
-	"github.com/tripconnect/go-common-utils/common"
 	"github.com/tripconnect/go-common-utils/helper"
 )
 
-func ListenPendingMessageQueue(ctx context.Context) {
+const defaultPendingConcurrency = 4
+
+// ListenPendingMessageQueue commits a record only after it was persisted, indexed and acknowledged or dead lettered,
+// so a crash redelivers it. Records are handled by kafka.consumer.pending.concurrency workers, the messages of one
+// conversation always go to the same worker to keep their order.
+func (c *Consumer) ListenPendingMessageQueue(ctx context.Context) {
 	pendingTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-sys-internal-pending-queue")
+	policy := newRetryPolicy("kafka.consumer.pending")
+
+	concurrency, err := helper.ReadConfig[int]("kafka.consumer.pending.concurrency")
+	if err != nil || concurrency <= 0 {
+		concurrency = defaultPendingConcurrency
+	}
 
 	var listener = kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  []string{common.KafkaConnection},
+		Brokers:  c.Brokers,
 		GroupID:  "chat-service-internal",
 		Topic:    pendingTopic,
 		MaxBytes: 10e6, // 10MB
 	})
+	defer listener.Close()
+
+	tracker := newOffsetTracker(listener)
+	workers := newOrderedWorkers(concurrency, func(m kafka.Message) {
+		if !c.processPendingMessage(ctx, policy, m) {
+			// Shutting down, the record is redelivered on the next start
+			return
+		}
+		if err := tracker.done(ctx, m); err != nil {
+			fmt.Printf("error while commit pending queue %v", err)
+		}
+	})
+	defer workers.stop()
 
 	for {
-		m, err := listener.ReadMessage(ctx)
+		m, err := listener.FetchMessage(ctx)
 		if err != nil {
 			fmt.Printf("error while consume message %v", err)
 			break
 		}
 
-		var kafkaPendingMessage models.KafkaPendingMessage
-		if err := json.Unmarshal(m.Value, &kafkaPendingMessage); err != nil {
-			fmt.Printf("error while comsume pending queue %v", err)
-			return
+		tracker.track(m)
+		workers.dispatch(pendingOrderingKey(m), m)
+	}
+}
+
+// processPendingMessage handles the record or dead letters it and reports whether its offset can be committed.
+// The dead letter is retried until it is published, committing past a record that is nowhere would lose it
+// and leaving its offset untracked would stall the commits of its partition, so only a shutdown gives up.
+func (c *Consumer) processPendingMessage(ctx context.Context, policy retryPolicy, m kafka.Message) bool {
+	attempts, err := policy.run(ctx, func() error { return c.handlePendingMessage(ctx, m) })
+	if ctx.Err() != nil {
+		return false
+	}
+	if err == nil {
+		return true
+	}
+
+	fmt.Printf("error while comsume pending queue %v", err)
+	// A bad record goes to the dead letter topic, the loop keeps consuming
+	if dlqErr := policy.retryUntilDone(ctx, func() error { return c.deadLetterPendingMessage(ctx, m, err, attempts) }); dlqErr != nil {
+		log.Printf("Dead letter pending message failed %s", dlqErr.Error())
+		return false
+	}
+	return true
+}
+
+// pendingOrderingKey is the conversation of the record, undecodable records are ordered by partition
+func pendingOrderingKey(m kafka.Message) string {
+	if len(m.Key) > 0 {
+		return string(m.Key)
+	}
+
+	var kafkaPendingMessage models.KafkaPendingMessage
+	if err := json.Unmarshal(m.Value, &kafkaPendingMessage); err == nil {
+		return kafkaPendingMessage.ConversationId.String()
+	}
+	return fmt.Sprintf("partition-%d", m.Partition)
+}
+
+// handlePendingMessage persists and indexes the message then acknowledges it on the sent topic,
+// every step is idempotent so that a retry or a replay finishes what a failed attempt started
+func (c *Consumer) handlePendingMessage(ctx context.Context, m kafka.Message) error {
+	var kafkaPendingMessage models.KafkaPendingMessage
+	if err := json.Unmarshal(m.Value, &kafkaPendingMessage); err != nil {
+		return permanent(fmt.Errorf("malformed pending message: %w", err))
+	}
+
+	// Saving related
+	// A retry that slipped past the handler check holds a different message id for the same key
+	if kafkaPendingMessage.IdempotencyKey != "" {
+		ownerId, err := c.Store.ClaimIdempotencyKey(kafkaPendingMessage.ConversationId, kafkaPendingMessage.FromUserId, kafkaPendingMessage.IdempotencyKey, kafkaPendingMessage.MessageId)
+		if err != nil {
+			return fmt.Errorf("failed to check idempotency key: %w", err)
+		}
+		if ownerId != kafkaPendingMessage.MessageId {
+			fmt.Printf("skip duplicate message %s of %s", kafkaPendingMessage.MessageId, ownerId)
+			return nil
 		}
+	}
 
-		// Saving related
-		entity := models.NewChatMessageEntity(kafkaPendingMessage)
-		if insertError := models.ChatMessageRepository.Insert(entity); insertError != nil {
-			fmt.Printf("failed to create chat message %v", insertError)
-			return
+	entity := models.NewChatMessageEntity(kafkaPendingMessage)
+	applied := false
+	if existing, err := c.Store.GetChatMessage(entity.Id); err == nil {
+		// Redelivered message, finish the remaining steps with the stored row and its sequence number
+		entity = *existing
+	} else if err != gocql.ErrNotFound {
+		return fmt.Errorf("failed to get chat message: %w", err)
+	} else {
+		sequence, err := c.Store.NextConversationSequence(entity.ConversationId, entity.Id)
+		if err != nil {
+			return fmt.Errorf("failed to get sequence number: %w", err)
+		}
+		entity.Sequence = sequence
+
+		var insertError error
+		applied, insertError = c.Store.InsertChatMessageIfNotExists(entity)
+		if insertError != nil {
+			return fmt.Errorf("failed to create chat message: %w", insertError)
 		}
-		chatMessageDoc := models.NewChatMessageDoc(entity)
-		_, saveEsErr := common.ElasticsearchClient.
-			Index(consts.ChatMessageIndex).
-			Id(chatMessageDoc.Id.String()).
-			Request(&chatMessageDoc).
-			Do(ctx)
-		if saveEsErr != nil {
-			fmt.Printf("Failed to save es: %v", saveEsErr)
+		if !applied {
+			existing, err := c.Store.GetChatMessage(entity.Id)
+			if err != nil {
+				return fmt.Errorf("failed to get chat message: %w", err)
+			}
+			entity = *existing
 		}
+	}
+	// Only the attempt that inserted the message counts it, a failed count is corrected when the members read
+	if applied {
+		if err := c.countUnread(ctx, entity); err != nil {
+			fmt.Printf("failed to count message %s as unread %v", entity.Id, err)
+		}
+	}
+	if applied || !c.isMessageStatusPast(entity.Id, models.MessagePersisted) {
+		if err := c.Store.SaveMessageStatus(entity.Id, entity.ConversationId, entity.FromUserId, models.MessagePersisted, ""); err != nil {
+			fmt.Printf("failed to save status of message %s %v", entity.Id, err)
+		}
+	}
+	// The projections are relayed from the outbox, storing them is enough to commit the record
+	sentChatMessageTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-sent-message")
+	ack := &models.KafkaSentMessage{
+		Id:               entity.Id,
+		ConversationId:   entity.ConversationId,
+		FromUserId:       entity.FromUserId,
+		Content:          entity.Content,
+		SentTime:         entity.SentTime,
+		CreatedAt:        entity.CreatedAt,
+		ReplyToMessageId: entity.ReplyToMessageId,
+		ThreadRootId:     entity.ThreadRootId,
+		Sequence:         entity.Sequence,
+	}
+	// The document is synced from the row when relayed, a redelivery after an edit keeps the edit
+	docEntry, err := models.NewMessageSyncEntry(entity)
+	if err != nil {
+		return permanent(err)
+	}
+	sentEntry, err := models.NewPublishEntry(entity.ConversationId, sentChatMessageTopic, ack)
+	if err != nil {
+		return permanent(err)
+	}
 
-		// Saga related
-		sentChatMessageTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-sent-message")
-		ack := &models.KafkaSentMessage{
-			Id:             entity.Id,
-			ConversationId: entity.ConversationId,
-			FromUserId:     entity.FromUserId,
-			Content:        entity.Content,
-			SentTime:       entity.SentTime,
-			CreatedAt:      entity.CreatedAt,
+	// The history copy is rewritten on redelivery too, its key is derived from the message
+	rows := models.NewChatMessageRows(entity)
+	entries := []models.OutboxEntity{docEntry}
+	// Only the activity columns are written, a later message keeps its place
+	touched, err := c.Store.TouchConversation(entity)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation activity: %w", err)
+	}
+	if touched {
+		activityEntry, err := models.NewConversationSyncEntry(entity.ConversationId)
+		if err != nil {
+			return permanent(err)
 		}
-		if err := common.Publish(ctx, sentChatMessageTopic, ack); err != nil {
-			log.Printf("Saga chat message failed %s", err.Error())
+		entries = append(entries, activityEntry)
+	}
+	entries = append(entries, sentEntry)
+
+	if err := c.Store.SaveWithOutbox(rows, entries...); err != nil {
+		return fmt.Errorf("failed to save outbox: %w", err)
+	}
+
+	// Subscribers wait for the sent record, it is published as soon as the batch is stored instead of after the relay
+	// delay. The outbox entry is the fallback, a relay reading it before it is marked publishes the record twice.
+	if err := c.Events.PublishKeyed(ctx, sentChatMessageTopic, entity.ConversationId.String(), ack); err != nil {
+		fmt.Printf("failed to publish sent message %s, left to the outbox %v", entity.Id, err)
+	} else if err := c.Store.MarkOutboxRelayed(sentEntry); err != nil {
+		fmt.Printf("failed to mark sent message %s relayed %v", entity.Id, err)
+	}
+
+	return nil
+}
+
+// deadLetterPendingMessage parks the record and tells the sender, when the record can be decoded, that it failed
+func (c *Consumer) deadLetterPendingMessage(ctx context.Context, m kafka.Message, cause error, attempts int) error {
+	dlqTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-sys-internal-pending-dlq")
+	if err := c.publishDeadLetter(ctx, dlqTopic, m, cause, attempts); err != nil {
+		return err
+	}
+
+	var kafkaPendingMessage models.KafkaPendingMessage
+	if err := json.Unmarshal(m.Value, &kafkaPendingMessage); err == nil {
+		c.reportFailedMessage(ctx, kafkaPendingMessage, cause)
+	}
+	return nil
+}
+
+// isMessageStatusPast reports whether the message already reached the given stage, e.g. delivered before a redelivery
+func (c *Consumer) isMessageStatusPast(messageId gocql.UUID, status models.DeliveryStatus) bool {
+	current, err := c.Store.GetMessageStatus(messageId)
+	if err != nil {
+		return false
+	}
+	currentStatus := models.DeliveryStatus(current.Status)
+	return currentStatus != models.MessageFailed && currentStatus >= status
+}
+
+// reportFailedMessage lets the sender know the message was dropped so the client can retry, the sender only gets
+// a reason code and the cause stays in the log and the dead letter headers
+func (c *Consumer) reportFailedMessage(ctx context.Context, message models.KafkaPendingMessage, cause error) {
+	reason := models.FailureRetriesExhausted
+	if isPermanent(cause) {
+		reason = models.FailureRejected
+	}
+	fmt.Printf("message %s failed with %s %v", message.MessageId, reason, cause)
+
+	if err := c.Store.SaveMessageStatus(message.MessageId, message.ConversationId, message.FromUserId, models.MessageFailed, reason); err != nil {
+		fmt.Printf("failed to save status of message %s %v", message.MessageId, err)
+	}
+	// A retry with the same key is sent again instead of getting the failed message back
+	if message.IdempotencyKey != "" {
+		if err := c.Store.ReleaseIdempotencyKey(message.ConversationId, message.FromUserId, message.IdempotencyKey, message.MessageId); err != nil {
+			fmt.Printf("failed to release idempotency key of message %s %v", message.MessageId, err)
 		}
 	}
+
+	failedChatMessageTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-failed-message")
+	event := &models.KafkaFailedMessage{
+		MessageId:      message.MessageId,
+		ConversationId: message.ConversationId,
+		FromUserId:     message.FromUserId,
+		Reason:         reason,
+		FailedAt:       time.Now(),
+	}
+	if sequence, err := c.Store.GetMessageSequence(message.MessageId); err == nil {
+		event.Sequence = sequence
+	} else if err != gocql.ErrNotFound {
+		fmt.Printf("failed to get sequence of message %s %v", message.MessageId, err)
+	}
+	if err := c.Events.Publish(ctx, failedChatMessageTopic, event); err != nil {
+		log.Printf("Publish failed message event failed %s", err.Error())
+	}
 }
@@ -0,0 +1,438 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	_ "github.com/TripConnect/chat-service/internal/testenv"
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/realtime"
+	"github.com/TripConnect/chat-service/store/memory"
+	"github.com/gocql/gocql"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	conversationId = gocql.MustRandomUUID()
+	senderId       = gocql.MustRandomUUID()
+	memberId       = gocql.MustRandomUUID()
+	now            = time.Now().Truncate(time.Millisecond)
+)
+
+type fixture struct {
+	consumer *Consumer
+	storage  *memory.Storage
+	events   *memory.Events
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	storage := memory.NewStorage()
+	events := memory.NewEvents()
+	_ = storage.UpdateConversation(models.ConversationEntity{
+		Id:            conversationId,
+		Type:          int(pb.ConversationType_GROUP),
+		CreatedAt:     now.Add(-time.Hour),
+		LastMessageAt: now.Add(-time.Minute),
+	})
+	return &fixture{
+		consumer: &Consumer{Store: storage, Search: memory.NewSearch(storage), Events: events, Hub: realtime.NewHub()},
+		storage:  storage,
+		events:   events,
+	}
+}
+
+// setConfig overrides a remote config entry through the environment the way viper resolves it
+func setConfig(t *testing.T, path string, value string) {
+	t.Helper()
+	t.Setenv(strings.ToUpper(strings.ReplaceAll("data."+path, ".", "_")), value)
+}
+
+func newPendingRecord(t *testing.T, message models.KafkaPendingMessage) kafka.Message {
+	t.Helper()
+	value, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kafka.Message{Topic: "pending", Partition: 1, Offset: 42, Key: []byte(message.ConversationId.String()), Value: value}
+}
+
+func newPendingMessage(sentTime time.Time) models.KafkaPendingMessage {
+	return models.KafkaPendingMessage{
+		ConversationId: conversationId,
+		MessageId:      gocql.MustRandomUUID(),
+		FromUserId:     senderId,
+		Content:        "Where do we meet?",
+		SentTime:       sentTime,
+	}
+}
+
+func outboxKinds(f *fixture) []string {
+	kinds := []string{}
+	for _, entry := range f.storage.Outbox() {
+		kinds = append(kinds, entry.Kind+":"+entry.Target)
+	}
+	return kinds
+}
+
+func TestHandlePendingMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(f *fixture, message *models.KafkaPendingMessage)
+		message   models.KafkaPendingMessage
+		value     []byte
+		permanent bool
+		wantErr   bool
+		check     func(t *testing.T, f *fixture, message models.KafkaPendingMessage)
+	}{
+		{
+			name:    "new message is persisted with its projections",
+			message: newPendingMessage(now),
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				entity, err := f.storage.GetChatMessage(message.MessageId)
+				if err != nil || entity.Content != message.Content || entity.Sequence != 1 {
+					t.Fatalf("message = %v, %v", entity, err)
+				}
+				messageStatus, _ := f.storage.GetMessageStatus(message.MessageId)
+				if messageStatus.Status != int(models.MessagePersisted) {
+					t.Fatalf("status = %d, want persisted", messageStatus.Status)
+				}
+				conversation, _ := f.storage.GetConversation(conversationId)
+				if conversation.LastMessageId != message.MessageId || conversation.LastMessagePreview != message.Content {
+					t.Fatalf("conversation = %v", conversation)
+				}
+				assertStrings(t, outboxKinds(f),
+					string(models.OutboxSyncDocument)+":"+consts.ChatMessageIndex,
+					string(models.OutboxSyncDocument)+":"+consts.ConversationIndex,
+					string(models.OutboxPublishRecord)+":"+"sent")
+			},
+		},
+		{
+			name:    "sent record is published right after it is stored",
+			message: newPendingMessage(now),
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				published := f.events.Published()
+				if len(published) != 1 || published[0].Topic != "sent" || published[0].Key != conversationId.String() {
+					t.Fatalf("published = %v, want the sent record", published)
+				}
+				for _, entry := range f.storage.Outbox() {
+					if relayed := entry.Kind == string(models.OutboxPublishRecord); entry.Relayed != relayed {
+						t.Fatalf("%s:%s relayed = %v, want %v", entry.Kind, entry.Target, entry.Relayed, relayed)
+					}
+				}
+			},
+		},
+		{
+			name:    "sent record is left to the relay when publishing fails",
+			message: newPendingMessage(now),
+			setup: func(f *fixture, message *models.KafkaPendingMessage) {
+				f.events.SetErr(errors.New("broker down"))
+			},
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				for _, entry := range f.storage.Outbox() {
+					if entry.Relayed {
+						t.Fatalf("%s:%s marked relayed", entry.Kind, entry.Target)
+					}
+				}
+			},
+		},
+		{
+			name:    "new message is unread for the other members",
+			message: newPendingMessage(now),
+			setup: func(f *fixture, message *models.KafkaPendingMessage) {
+				for _, userId := range []gocql.UUID{senderId, memberId} {
+					_ = f.storage.InsertParticipant(models.ParticipantEntity{ConversationId: conversationId, UserId: userId, Status: int(models.Joined)})
+				}
+			},
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				if unread, err := f.storage.GetUnreadCount(conversationId, memberId); err != nil || unread != 1 {
+					t.Fatalf("member unread = %d, %v, want 1", unread, err)
+				}
+				if _, err := f.storage.GetUnreadCount(conversationId, senderId); err != gocql.ErrNotFound {
+					t.Fatalf("sender unread counted: %v", err)
+				}
+			},
+		},
+		{
+			name:    "older message keeps the conversation activity",
+			message: newPendingMessage(now.Add(-time.Hour)),
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				conversation, _ := f.storage.GetConversation(conversationId)
+				if conversation.LastMessageId == message.MessageId {
+					t.Fatalf("last message moved back to %s", message.MessageId)
+				}
+				assertStrings(t, outboxKinds(f),
+					string(models.OutboxSyncDocument)+":"+consts.ChatMessageIndex,
+					string(models.OutboxPublishRecord)+":"+"sent")
+			},
+		},
+		{
+			name:    "redelivery keeps the stored row and its status",
+			message: newPendingMessage(now),
+			setup: func(f *fixture, message *models.KafkaPendingMessage) {
+				entity := models.NewChatMessageEntity(*message)
+				entity.Sequence = 7
+				_, _ = f.storage.InsertChatMessageIfNotExists(entity)
+				_ = f.storage.SaveMessageStatus(message.MessageId, conversationId, senderId, models.MessageDelivered, "")
+				message.Content = "Changed in flight"
+				_ = f.storage.InsertParticipant(models.ParticipantEntity{ConversationId: conversationId, UserId: memberId, Status: int(models.Joined)})
+			},
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				if _, err := f.storage.GetUnreadCount(conversationId, memberId); err != gocql.ErrNotFound {
+					t.Fatalf("redelivered message counted again: %v", err)
+				}
+				entity, _ := f.storage.GetChatMessage(message.MessageId)
+				if entity.Sequence != 7 || entity.Content != "Where do we meet?" {
+					t.Fatalf("message = %v", entity)
+				}
+				messageStatus, _ := f.storage.GetMessageStatus(message.MessageId)
+				if messageStatus.Status != int(models.MessageDelivered) {
+					t.Fatalf("status = %d, want delivered", messageStatus.Status)
+				}
+			},
+		},
+		{
+			name:    "retried message keeps the number it took before later messages",
+			message: newPendingMessage(now),
+			setup: func(f *fixture, message *models.KafkaPendingMessage) {
+				_, _ = f.storage.NextConversationSequence(conversationId, message.MessageId)
+				_, _ = f.storage.NextConversationSequence(conversationId, gocql.MustRandomUUID())
+			},
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				if entity, _ := f.storage.GetChatMessage(message.MessageId); entity.Sequence != 1 {
+					t.Fatalf("sequence = %d, want 1", entity.Sequence)
+				}
+			},
+		},
+		{
+			name:    "duplicate idempotency key is skipped",
+			message: newPendingMessage(now),
+			setup: func(f *fixture, message *models.KafkaPendingMessage) {
+				message.IdempotencyKey = "retry-1"
+				_, _ = f.storage.ClaimIdempotencyKey(conversationId, senderId, "retry-1", gocql.MustRandomUUID())
+			},
+			check: func(t *testing.T, f *fixture, message models.KafkaPendingMessage) {
+				if _, err := f.storage.GetChatMessage(message.MessageId); err != gocql.ErrNotFound {
+					t.Fatalf("duplicate stored: %v", err)
+				}
+				assertStrings(t, outboxKinds(f))
+			},
+		},
+		{
+			name:      "malformed record fails permanently",
+			value:     []byte("{"),
+			wantErr:   true,
+			permanent: true,
+		},
+		{
+			name: "unknown conversation is retried",
+			message: models.KafkaPendingMessage{
+				ConversationId: gocql.MustRandomUUID(),
+				MessageId:      gocql.MustRandomUUID(),
+				FromUserId:     senderId,
+				Content:        "Hello",
+				SentTime:       now,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfig(t, "kafka.topic.chatting-fct-sent-message", "sent")
+			f := newFixture(t)
+			message := tc.message
+			if tc.setup != nil {
+				tc.setup(f, &message)
+			}
+			record := newPendingRecord(t, message)
+			if tc.value != nil {
+				record.Value = tc.value
+			}
+
+			err := f.consumer.handlePendingMessage(context.Background(), record)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tc.wantErr)
+			}
+			var permanentErr permanentError
+			if isPermanent := errors.As(err, &permanentErr); isPermanent != tc.permanent {
+				t.Fatalf("permanent = %v, want %v (%v)", isPermanent, tc.permanent, err)
+			}
+			if tc.check != nil {
+				tc.check(t, f, message)
+			}
+		})
+	}
+}
+
+func TestDeadLetterPendingMessage(t *testing.T) {
+	message := newPendingMessage(now)
+
+	tests := []struct {
+		name       string
+		value      []byte
+		wantFailed bool
+	}{
+		{
+			name:       "sender is told the message failed",
+			value:      newPendingRecord(t, message).Value,
+			wantFailed: true,
+		},
+		{
+			name:  "undecodable record is only parked",
+			value: []byte("{"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfig(t, "kafka.topic.chatting-sys-internal-pending-dlq", "pending-dlq")
+			f := newFixture(t)
+			record := newPendingRecord(t, message)
+			record.Value = tc.value
+			record.Headers = []kafka.Header{{Key: "trace-id", Value: []byte("abc")}, {Key: headerAttempts, Value: []byte("9")}}
+
+			if err := f.consumer.deadLetterPendingMessage(context.Background(), record, errors.New("boom"), 3); err != nil {
+				t.Fatal(err)
+			}
+
+			records := f.events.Records()
+			if len(records) != 1 || records[0].Topic != "pending-dlq" || string(records[0].Value) != string(tc.value) {
+				t.Fatalf("dead letters = %v", records)
+			}
+			headers := records[0].Headers
+			if headerValue(headers, "trace-id") != "abc" || headerValue(headers, headerAttempts) != "3" ||
+				headerValue(headers, headerErrorReason) != "boom" || headerValue(headers, headerOriginalOffset) != "1/42" {
+				t.Fatalf("headers = %v", headers)
+			}
+
+			messageStatus, err := f.storage.GetMessageStatus(message.MessageId)
+			if tc.wantFailed && (err != nil || messageStatus.Status != int(models.MessageFailed) || messageStatus.FailureReason != string(models.FailureRetriesExhausted)) {
+				t.Fatalf("status = %v, %v, want failed", messageStatus, err)
+			}
+			if !tc.wantFailed && err != gocql.ErrNotFound {
+				t.Fatalf("status = %v, %v, want none", messageStatus, err)
+			}
+		})
+	}
+
+	t.Run("failed event carries the skipped number", func(t *testing.T) {
+		setConfig(t, "kafka.topic.chatting-sys-internal-pending-dlq", "pending-dlq")
+		f := newFixture(t)
+		_, _ = f.storage.NextConversationSequence(conversationId, message.MessageId)
+		if err := f.consumer.deadLetterPendingMessage(context.Background(), newPendingRecord(t, message), errors.New("boom"), 1); err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range f.events.Published() {
+			if failed, ok := event.Data.(*models.KafkaFailedMessage); ok && failed.Sequence == 1 {
+				return
+			}
+		}
+		t.Fatalf("published = %v, want a failed event with sequence 1", f.events.Published())
+	})
+
+	t.Run("sender gets a reason code instead of the error", func(t *testing.T) {
+		setConfig(t, "kafka.topic.chatting-sys-internal-pending-dlq", "pending-dlq")
+		f := newFixture(t)
+		if err := f.consumer.deadLetterPendingMessage(context.Background(), newPendingRecord(t, message), permanent(errors.New("cassandra: timeout")), 1); err != nil {
+			t.Fatal(err)
+		}
+		messageStatus, err := f.storage.GetMessageStatus(message.MessageId)
+		if err != nil || messageStatus.FailureReason != string(models.FailureRejected) {
+			t.Fatalf("status = %v, %v, want %s", messageStatus, err, models.FailureRejected)
+		}
+		for _, event := range f.events.Published() {
+			if failed, ok := event.Data.(*models.KafkaFailedMessage); ok && failed.Reason != models.FailureRejected {
+				t.Fatalf("failed event reason = %q", failed.Reason)
+			}
+		}
+	})
+
+	t.Run("idempotency key is released for the retry", func(t *testing.T) {
+		setConfig(t, "kafka.topic.chatting-sys-internal-pending-dlq", "pending-dlq")
+		f := newFixture(t)
+		keyed := message
+		keyed.IdempotencyKey = "retry-1"
+		_, _ = f.storage.ClaimIdempotencyKey(conversationId, senderId, "retry-1", keyed.MessageId)
+		if err := f.consumer.deadLetterPendingMessage(context.Background(), newPendingRecord(t, keyed), errors.New("boom"), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		retryId := gocql.MustRandomUUID()
+		if ownerId, _ := f.storage.ClaimIdempotencyKey(conversationId, senderId, "retry-1", retryId); ownerId != retryId {
+			t.Fatalf("key owned by %s, want the retry %s", ownerId, retryId)
+		}
+	})
+
+	t.Run("missing dead letter topic", func(t *testing.T) {
+		f := newFixture(t)
+		if err := f.consumer.deadLetterPendingMessage(context.Background(), newPendingRecord(t, message), errors.New("boom"), 1); err == nil {
+			t.Fatal("dead lettered without a topic")
+		}
+	})
+}
+
+func TestProcessPendingMessage(t *testing.T) {
+	setConfig(t, "kafka.topic.chatting-sys-internal-pending-dlq", "pending-dlq")
+	setConfig(t, "kafka.consumer.pending.retry-backoff-ms", "1")
+	setConfig(t, "kafka.consumer.pending.max-retry-backoff-ms", "5")
+	policy := newRetryPolicy("kafka.consumer.pending")
+	poison := newPendingRecord(t, newPendingMessage(now))
+	poison.Value = []byte("{")
+
+	t.Run("handled record is committed", func(t *testing.T) {
+		f := newFixture(t)
+		if !f.consumer.processPendingMessage(context.Background(), policy, newPendingRecord(t, newPendingMessage(now))) {
+			t.Fatal("record not committed")
+		}
+	})
+
+	t.Run("dead letter is retried until it is published", func(t *testing.T) {
+		f := newFixture(t)
+		f.events.SetErr(errors.New("broker down"))
+		committed := make(chan bool)
+		go func() { committed <- f.consumer.processPendingMessage(context.Background(), policy, poison) }()
+
+		select {
+		case <-committed:
+			t.Fatal("gave up on the record while the dead letter topic was unavailable")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		f.events.SetErr(nil)
+		select {
+		case ok := <-committed:
+			if !ok {
+				t.Fatal("record not committed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("dead letter not published after the topic recovered")
+		}
+		if records := f.events.Records(); len(records) != 1 || records[0].Topic != "pending-dlq" {
+			t.Fatalf("dead letters = %v", records)
+		}
+	})
+
+	t.Run("shutdown leaves the record to be redelivered", func(t *testing.T) {
+		f := newFixture(t)
+		f.events.SetErr(errors.New("broker down"))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if f.consumer.processPendingMessage(ctx, policy, poison) {
+			t.Fatal("committed a record that was not dead lettered")
+		}
+	})
+}
+
+func assertStrings(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+// ListenSentMessageQueue feeds the realtime hub, every instance uses its own consumer group so that all subscribers
+// receive every persisted message. The group is named after kafka.consumer.realtime.instance-id, or the host name,
+// so a restarted instance reuses its group instead of leaving one behind on the brokers.
+func (c *Consumer) ListenSentMessageQueue(ctx context.Context) {
+	sentChatMessageTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-sent-message")
+
+	var listener = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     c.Brokers,
+		GroupID:     "chat-service-realtime-" + realtimeInstanceId(),
+		Topic:       sentChatMessageTopic,
+		StartOffset: kafka.LastOffset,
+		MaxBytes:    10e6, // 10MB
+	})
+	defer listener.Close()
+
+	for {
+		m, err := listener.ReadMessage(ctx)
+		if err != nil {
+			fmt.Printf("error while consume sent message %v", err)
+			break
+		}
+
+		c.handleSentMessage(m)
+	}
+}
+
+func realtimeInstanceId() string {
+	if instanceId, err := helper.ReadConfig[string]("kafka.consumer.realtime.instance-id"); err == nil && instanceId != "" {
+		return instanceId
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("Missing kafka.consumer.realtime.instance-id and no host name: %v", err)
+	}
+	return hostname
+}
+
+// handleSentMessage fans the message out to the subscribers of this instance, it is delivered once
+// a subscriber other than its sender received it
+func (c *Consumer) handleSentMessage(m kafka.Message) {
+	var kafkaSentMessage models.KafkaSentMessage
+	if err := json.Unmarshal(m.Value, &kafkaSentMessage); err != nil {
+		fmt.Printf("error while comsume sent message %v", err)
+		return
+	}
+
+	if delivered := c.Hub.Publish(models.NewSentChatMessageEntity(kafkaSentMessage)); delivered > 0 {
+		c.markDelivered(kafkaSentMessage)
+	}
+}
+
+// markDelivered moves a persisted message to delivered, every instance may report it so the write is idempotent
+func (c *Consumer) markDelivered(message models.KafkaSentMessage) {
+	current, err := c.Store.GetMessageStatus(message.Id)
+	if err != nil || current.Status != int(models.MessagePersisted) {
+		return
+	}
+
+	if err := c.Store.SaveMessageStatus(message.Id, message.ConversationId, message.FromUserId, models.MessageDelivered, ""); err != nil {
+		fmt.Printf("failed to mark message %s delivered %v", message.Id, err)
+	}
+}
@@ -0,0 +1,111 @@
+package consumers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestMarkDelivered(t *testing.T) {
+	tests := []struct {
+		name    string
+		current *models.DeliveryStatus
+		want    *models.DeliveryStatus
+	}{
+		{
+			name:    "persisted becomes delivered",
+			current: ptr(models.MessagePersisted),
+			want:    ptr(models.MessageDelivered),
+		},
+		{
+			name:    "failed stays failed",
+			current: ptr(models.MessageFailed),
+			want:    ptr(models.MessageFailed),
+		},
+		{
+			name:    "pending is not skipped ahead",
+			current: ptr(models.MessagePending),
+			want:    ptr(models.MessagePending),
+		},
+		{
+			name: "unknown message gets no status",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			message := models.KafkaSentMessage{Id: gocql.MustRandomUUID(), ConversationId: conversationId, FromUserId: senderId}
+			if tc.current != nil {
+				_ = f.storage.SaveMessageStatus(message.Id, conversationId, senderId, *tc.current, "")
+			}
+
+			f.consumer.markDelivered(message)
+
+			messageStatus, err := f.storage.GetMessageStatus(message.Id)
+			if tc.want == nil {
+				if err != gocql.ErrNotFound {
+					t.Fatalf("status = %v, %v, want none", messageStatus, err)
+				}
+				return
+			}
+			if err != nil || messageStatus.Status != int(*tc.want) {
+				t.Fatalf("status = %v, %v, want %d", messageStatus, err, *tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleSentMessage(t *testing.T) {
+	memberId := gocql.MustRandomUUID()
+
+	tests := []struct {
+		name        string
+		subscribers []gocql.UUID
+		want        models.DeliveryStatus
+	}{
+		{
+			name:        "received by another member",
+			subscribers: []gocql.UUID{senderId, memberId},
+			want:        models.MessageDelivered,
+		},
+		{
+			name:        "received by its sender only",
+			subscribers: []gocql.UUID{senderId},
+			want:        models.MessagePersisted,
+		},
+		{
+			name: "nobody subscribed",
+			want: models.MessagePersisted,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			for _, userId := range tc.subscribers {
+				f.consumer.Hub.Subscribe(userId, []gocql.UUID{conversationId})
+			}
+			message := models.KafkaSentMessage{Id: gocql.MustRandomUUID(), ConversationId: conversationId, FromUserId: senderId}
+			_ = f.storage.SaveMessageStatus(message.Id, conversationId, senderId, models.MessagePersisted, "")
+			value, err := json.Marshal(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f.consumer.handleSentMessage(kafka.Message{Value: value})
+
+			messageStatus, err := f.storage.GetMessageStatus(message.Id)
+			if err != nil || messageStatus.Status != int(tc.want) {
+				t.Fatalf("status = %v, %v, want %d", messageStatus, err, tc.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
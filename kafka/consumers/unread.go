@@ -0,0 +1,40 @@
+package consumers
+
+import (
+	"context"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/gocql/gocql"
+)
+
+const unreadMembersPageSize = 500
+
+// countUnread adds the message to the unread counters of the joined members other than the sender
+func (c *Consumer) countUnread(ctx context.Context, entity models.ChatMessageEntity) error {
+	for pageNumber := 0; ; pageNumber++ {
+		docs, err := c.Search.SearchParticipants(ctx, store.ParticipantQuery{
+			ConversationId: entity.ConversationId,
+			Status:         models.Joined,
+			PageNumber:     pageNumber,
+			PageSize:       unreadMembersPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		userIds := []gocql.UUID{}
+		for _, doc := range docs {
+			if doc.UserId != entity.FromUserId {
+				userIds = append(userIds, doc.UserId)
+			}
+		}
+		if err := c.Store.IncrementUnreadCounts(entity.ConversationId, userIds); err != nil {
+			return err
+		}
+
+		if len(docs) < unreadMembersPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package producers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/tripconnect/go-common-utils/common"
+)
+
+// KeyedPublisher sends records with the same key to the same partition, which keeps their order, records without
+// a key are spread over the partitions. Writes wait for every in-sync replica and batches are flushed after
+// publisherBatchTimeout instead of the one second default.
+var KeyedPublisher = &kafka.Writer{
+	Addr:                   kafka.TCP(common.KafkaConnection),
+	Balancer:               &kafka.Hash{},
+	RequiredAcks:           kafka.RequireAll,
+	BatchTimeout:           publisherBatchTimeout,
+	AllowAutoTopicCreation: true,
+}
+
+const publisherBatchTimeout = 10 * time.Millisecond
+
+// Publish publishes data as JSON without a key, data should be passed as pointer
+func Publish(ctx context.Context, topic string, data interface{}) error {
+	valueBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Publish kafka message failed %v", err)
+	}
+
+	return KeyedPublisher.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Value: valueBytes,
+	})
+}
+
+// PublishKeyed publishes data as JSON under the key, data should be passed as pointer
+func PublishKeyed(ctx context.Context, topic string, key string, data interface{}) error {
+	valueBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Publish kafka message failed %v", err)
+	}
+
+	return KeyedPublisher.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: valueBytes,
+	})
+}
+
+// Events publishes on the shared Kafka writers
+type Events struct{}
+
+func (Events) Publish(ctx context.Context, topic string, data interface{}) error {
+	return Publish(ctx, topic, data)
+}
+
+func (Events) PublishKeyed(ctx context.Context, topic string, key string, data interface{}) error {
+	return PublishKeyed(ctx, topic, key, data)
+}
+
+func (Events) PublishRecord(ctx context.Context, record kafka.Message) error {
+	return KeyedPublisher.WriteMessages(ctx, record)
+}
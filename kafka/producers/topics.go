@@ -0,0 +1,40 @@
+package producers
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/tripconnect/go-common-utils/common"
+)
+
+// EnsureTopics creates the missing topics with the given partition count, existing topics are left as they are
+func EnsureTopics(partitions int, replicationFactor int, topics ...string) error {
+	conn, err := kafka.Dial("tcp", common.KafkaConnection)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return err
+	}
+
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return err
+	}
+	defer controllerConn.Close()
+
+	configs := make([]kafka.TopicConfig, len(topics))
+	for i, topic := range topics {
+		configs[i] = kafka.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     partitions,
+			ReplicationFactor: replicationFactor,
+		}
+	}
+
+	return controllerConn.CreateTopics(configs...)
+}
@@ -2,80 +2,289 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/TripConnect/chat-service/auth"
 	"github.com/TripConnect/chat-service/consts"
 	"github.com/TripConnect/chat-service/kafka/consumers"
+	"github.com/TripConnect/chat-service/kafka/producers"
+	"github.com/TripConnect/chat-service/migrations"
 	"github.com/TripConnect/chat-service/models"
+	"github.com/TripConnect/chat-service/outbox"
+	"github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/realtime"
+	"github.com/TripConnect/chat-service/reconcile"
 	"github.com/TripConnect/chat-service/rpc"
+	"github.com/TripConnect/chat-service/store/cassandra"
+	"github.com/TripConnect/chat-service/store/elastic"
 	"github.com/gocql/gocql"
 	"github.com/google/uuid"
 	"github.com/hashicorp/consul/api"
-	"github.com/kristoiv/gocqltable"
 	"github.com/tripconnect/go-common-utils/common"
 	"github.com/tripconnect/go-common-utils/helper"
-	"github.com/tripconnect/go-proto-lib/protos"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func initCassandra() {
-	host, _ := helper.ReadConfig[string]("database.cassandra.host")
+// ================= CASSANDRA =================
+
+const (
+	defaultCassandraConnectTimeout = 5 * time.Second
+	defaultCassandraTimeout        = 10 * time.Second
+)
+
+// readStringList reads a list config, a comma separated string is accepted too since env variables cannot hold lists
+func readStringList(path string) []string {
+	raw, err := helper.ReadConfig[any](path)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	switch raw := raw.(type) {
+	case []any:
+		for _, value := range raw {
+			values = append(values, fmt.Sprint(value))
+		}
+	case string:
+		values = strings.Split(raw, ",")
+	}
+
+	trimmed := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
+
+func readConsistency(path string, fallback gocql.Consistency) gocql.Consistency {
+	raw, err := helper.ReadConfig[string](path)
+	if err != nil || raw == "" {
+		return fallback
+	}
+
+	consistency, err := gocql.ParseConsistencyWrapper(raw)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", path, err)
+	}
+	return consistency
+}
+
+func readSerialConsistency(path string) gocql.SerialConsistency {
+	raw, _ := helper.ReadConfig[string](path)
+	switch strings.ToUpper(raw) {
+	case "", "SERIAL":
+		return gocql.Serial
+	case "LOCAL_SERIAL":
+		return gocql.LocalSerial
+	}
+	log.Fatalf("Invalid %s: %q", path, raw)
+	return gocql.Serial
+}
+
+func readDuration(path string, fallback time.Duration) time.Duration {
+	millis, err := helper.ReadConfig[int](path)
+	if err != nil || millis <= 0 {
+		return fallback
+	}
+	return time.Duration(millis) * time.Millisecond
+}
+
+// newCassandraCluster builds the cluster from database.cassandra.*, hosts lists the contact points
+// and the legacy host key is still read when it is missing
+func newCassandraCluster(consistency gocql.Consistency) *gocql.ClusterConfig {
+	hosts := readStringList("database.cassandra.hosts")
+	if len(hosts) == 0 {
+		hosts = readStringList("database.cassandra.host")
+	}
+	if len(hosts) == 0 {
+		log.Fatalf("Missing database.cassandra.hosts config")
+	}
+
 	username, _ := helper.ReadConfig[string]("database.cassandra.username")
 	password, _ := helper.ReadConfig[string]("database.cassandra.password")
 
-	cluster := gocql.NewCluster(host)
+	cluster := gocql.NewCluster(hosts...)
 	cluster.Authenticator = gocql.PasswordAuthenticator{
 		Username: username,
 		Password: password,
 	}
+	if port, err := helper.ReadConfig[int]("database.cassandra.port"); err == nil && port > 0 {
+		cluster.Port = port
+	}
+	cluster.ConnectTimeout = readDuration("database.cassandra.connect-timeout-ms", defaultCassandraConnectTimeout)
+	cluster.Timeout = readDuration("database.cassandra.timeout-ms", defaultCassandraTimeout)
+	cluster.Consistency = consistency
+	cluster.SerialConsistency = readSerialConsistency("database.cassandra.consistency.serial")
+
+	// Queries stay in the local datacenter, replicas owning the partition are tried first
+	if localDC, _ := helper.ReadConfig[string]("database.cassandra.local-datacenter"); localDC != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(localDC))
+	}
 
-	session, err := cluster.CreateSession()
+	if tlsEnabled, _ := helper.ReadConfig[bool]("database.cassandra.tls.enabled"); tlsEnabled {
+		caPath, _ := helper.ReadConfig[string]("database.cassandra.tls.ca-path")
+		certPath, _ := helper.ReadConfig[string]("database.cassandra.tls.cert-path")
+		keyPath, _ := helper.ReadConfig[string]("database.cassandra.tls.key-path")
+		skipVerify, _ := helper.ReadConfig[bool]("database.cassandra.tls.insecure-skip-verify")
+		cluster.SslOpts = &gocql.SslOptions{
+			CaPath:                 caPath,
+			CertPath:               certPath,
+			KeyPath:                keyPath,
+			EnableHostVerification: !skipVerify,
+		}
+	}
+
+	return cluster
+}
+
+// newKeyspaceReplication uses NetworkTopologyStrategy when database.cassandra.replication maps datacenters
+// to replication factors, SimpleStrategy with database.cassandra.replication-factor otherwise
+func newKeyspaceReplication() map[string]any {
+	if datacenters, err := helper.ReadConfig[map[string]any]("database.cassandra.replication"); err == nil && len(datacenters) > 0 {
+		replication := map[string]any{"class": "NetworkTopologyStrategy"}
+		for datacenter, factor := range datacenters {
+			replication[datacenter] = fmt.Sprint(factor)
+		}
+		return replication
+	}
+
+	replicationFactor, err := helper.ReadConfig[int]("database.cassandra.replication-factor")
+	if err != nil || replicationFactor <= 0 {
+		replicationFactor = 1
+	}
+	return map[string]any{"class": "SimpleStrategy", "replication_factor": strconv.Itoa(replicationFactor)}
+}
+
+// createKeyspace creates the keyspace, an existing keyspace is never altered since changing its replication needs a repair
+func createKeyspace(session *gocql.Session) {
+	replication := newKeyspaceReplication()
+
+	var existing map[string]string
+	err := session.Query(`SELECT replication FROM system_schema.keyspaces WHERE keyspace_name = ?`, consts.KeySpace).Scan(&existing)
+	if err == nil {
+		if !sameReplication(existing, replication) {
+			log.Printf("Keyspace %s replication %v differs from the configured %v, alter it and run a repair", consts.KeySpace, existing, replication)
+		}
+		return
+	}
+	if err != gocql.ErrNotFound {
+		log.Fatalf("Failed to read keyspace %s: %v", consts.KeySpace, err)
+	}
+
+	options := []string{}
+	for key, value := range replication {
+		options = append(options, fmt.Sprintf("'%s': '%s'", key, value))
+	}
+	slices.Sort(options)
+	statement := fmt.Sprintf(`CREATE KEYSPACE IF NOT EXISTS %q WITH replication = {%s} AND durable_writes = true`,
+		consts.KeySpace, strings.Join(options, ", "))
+	if err := session.Query(statement).Exec(); err != nil {
+		log.Fatalf("Failed to create keyspace %s: %v", consts.KeySpace, err)
+	}
+}
+
+// sameReplication compares the replication read from system_schema with the configured one,
+// Cassandra stores the strategy class with its package name
+func sameReplication(existing map[string]string, replication map[string]any) bool {
+	if len(existing) != len(replication) {
+		return false
+	}
+	for key, value := range replication {
+		if key == "class" {
+			if !strings.HasSuffix(existing[key], "."+fmt.Sprint(value)) && existing[key] != value {
+				return false
+			}
+		} else if existing[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// initCassandra connects one session, reads and writes run at the levels of database.cassandra.consistency.*
+func initCassandra() {
+	readLevel := readConsistency("database.cassandra.consistency.read", gocql.Quorum)
+	writeLevel := readConsistency("database.cassandra.consistency.write", gocql.Quorum)
+
+	session, err := newCassandraCluster(writeLevel).CreateSession()
 	if err != nil {
 		log.Fatalf("Failed to connect to Cassandra: %v", err)
 	}
-	gocqltable.SetDefaultSession(session)
+	models.SetSession(session, readLevel, writeLevel)
 
-	keyspace := gocqltable.NewKeyspace(consts.KeySpace)
-	_ = keyspace.Create(map[string]interface{}{
-		"class":              "SimpleStrategy",
-		"replication_factor": 1,
-	}, true)
+	createKeyspace(session)
+}
 
-	models.ConversationRepository.TableInterface.Create()
-	models.ChatMessageRepository.TableInterface.Create()
-	models.ParticipantRepository.TableInterface.Create()
+// initSchema applies the pending migrations but the manual ones, replicas starting together take turns on the migration lock
+func initSchema(ctx context.Context) {
+	applied, err := migrations.Up(ctx, false, false)
+	for _, migration := range applied {
+		log.Printf("Applied migration %d %s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate schema: %v", err)
+	}
 }
 
-func initElasticsearch() {
-	ctx := context.Background()
+func initKafka(ctx context.Context) {
+	// A pending message that cannot be handled has nowhere to go without its dead letter topic
+	topics := []string{}
+	for _, path := range []string{
+		"kafka.topic.chatting-sys-internal-pending-queue",
+		"kafka.topic.chatting-fct-sent-message",
+		"kafka.topic.chatting-sys-internal-pending-dlq",
+	} {
+		topic, err := helper.ReadConfig[string](path)
+		if err != nil || topic == "" {
+			log.Fatalf("Missing kafka topic config %s", path)
+		}
+		topics = append(topics, topic)
+	}
 
-	common.ElasticsearchClient.Indices.
-		Create(consts.ConversationIndex).
-		Mappings(models.ConversationDocumentMappings).
-		Do(ctx)
+	// Messages are keyed by conversation, the partition count bounds how many consumers share the pending queue
+	if partitions, err := helper.ReadConfig[int]("kafka.topic.partitions"); err == nil && partitions > 0 {
+		replicationFactor, err := helper.ReadConfig[int]("kafka.topic.replication-factor")
+		if err != nil || replicationFactor <= 0 {
+			replicationFactor = 1
+		}
+		if err := producers.EnsureTopics(partitions, replicationFactor, topics...); err != nil {
+			log.Printf("Failed to create kafka topics: %v", err)
+		}
+	}
 
-	common.ElasticsearchClient.Indices.
-		Create(consts.ChatMessageIndex).
-		Mappings(models.ChatMessageDocumentMappings).
-		Do(ctx)
+	consumer := newConsumer()
+	go consumer.ListenPendingMessageQueue(ctx)
+	go consumer.ListenSentMessageQueue(ctx)
+	go consumer.ListenMembershipQueue(ctx)
 
-	common.ElasticsearchClient.Indices.
-		Create(consts.ParticipantIndex).
-		Mappings(models.ParticipantDocumentMappings).
-		Do(ctx)
+	// Projects the rows written with an outbox entry to Elasticsearch and Kafka
+	go outbox.RunRelay(ctx)
 }
 
-func initKafka(ctx context.Context) {
-	go consumers.ListenPendingMessageQueue(ctx)
+// newConsumer wires the consumers to Cassandra, Kafka and the realtime hub of this instance
+func newConsumer() *consumers.Consumer {
+	return &consumers.Consumer{
+		Brokers: []string{common.KafkaConnection},
+		Store:   cassandra.Storage{},
+		Search:  elastic.Search{},
+		Events:  producers.Events{},
+		Hub:     realtime.ChatMessageHub,
+	}
 }
 
 // ================= CONSUL =================
@@ -136,6 +345,98 @@ func deregisterConsul(client *api.Client, serviceID string) {
 	}
 }
 
+// ================= COMMANDS =================
+
+// runCommand runs a one-shot maintenance command instead of the server
+func runCommand(ctx context.Context, name string, args []string) {
+	switch name {
+	case "replay-dlq":
+		flags := flag.NewFlagSet(name, flag.ExitOnError)
+		limit := flags.Int("limit", 0, "maximum number of records to replay, 0 replays all")
+		flags.Parse(args)
+
+		replayed, err := newConsumer().ReplayPendingDeadLetters(ctx, *limit)
+		log.Printf("Replayed %d dead lettered pending messages", replayed)
+		if err != nil {
+			log.Fatalf("Replay failed: %v", err)
+		}
+	case "reconcile":
+		flags := flag.NewFlagSet(name, flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "only report the drift, do not repair documents")
+		flags.Parse(args)
+
+		initCassandra()
+		report, err := reconcile.Run(ctx, *dryRun)
+		report.Log()
+		if err != nil {
+			log.Fatalf("Reconciliation failed: %v", err)
+		}
+	case "backfill-history":
+		initCassandra()
+		copied, err := reconcile.BackfillHistory(ctx)
+		log.Printf("Copied %d messages into the conversation history", copied)
+		if err != nil {
+			log.Fatalf("Backfill failed: %v", err)
+		}
+	case "backfill-unread":
+		initCassandra()
+		counted, err := reconcile.BackfillUnreadCounts(ctx)
+		log.Printf("Counted the unread messages of %d participants", counted)
+		if err != nil {
+			log.Fatalf("Backfill failed: %v", err)
+		}
+	case "migrate":
+		runMigrateCommand(ctx, args)
+	default:
+		log.Fatalf("unknown command %q", name)
+	}
+}
+
+// runMigrateCommand runs "migrate up [-dry-run]" or "migrate status"
+func runMigrateCommand(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		log.Fatalf("usage: migrate up [-dry-run] | migrate status")
+	}
+
+	initCassandra()
+	switch args[0] {
+	case "up":
+		flags := flag.NewFlagSet("migrate up", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "only print the pending migrations")
+		flags.Parse(args[1:])
+
+		migrated, err := migrations.Up(ctx, *dryRun, true)
+		if !*dryRun {
+			for _, migration := range migrated {
+				log.Printf("Applied migration %d %s", migration.Version, migration.Name)
+			}
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(migrated) == 0 {
+			log.Println("Schema is up to date")
+		}
+	case "status":
+		statuses, err := migrations.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migrations: %v", err)
+		}
+		for _, migration := range statuses {
+			appliedAt := "pending"
+			if migration.Manual {
+				appliedAt = "pending, apply with migrate up"
+			}
+			if !migration.AppliedAt.IsZero() {
+				appliedAt = migration.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-50s %s\n", migration.Version, migration.Name, appliedAt)
+		}
+	default:
+		log.Fatalf("unknown migrate command %q", args[0])
+	}
+}
+
 // ================= MAIN =================
 
 func main() {
@@ -143,11 +444,30 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if len(os.Args) > 1 {
+		runCommand(ctx, os.Args[1], os.Args[2:])
+		return
+	}
+
 	// init infra
 	initCassandra()
-	initElasticsearch()
+	initSchema(ctx)
 	initKafka(ctx)
 
+	// Enable on a single replica, every run scans the whole keyspace
+	if interval, err := helper.ReadConfig[int]("reconciler.interval-seconds"); err == nil && interval > 0 {
+		go reconcile.RunPeriodically(ctx, time.Duration(interval)*time.Second)
+	}
+
+	// Serves the expvar counters, the reconciler drift among them, on /debug/vars
+	if metricsPort, err := helper.ReadConfig[int]("metrics.port"); err == nil && metricsPort > 0 {
+		go func() {
+			if err := http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil); err != nil {
+				log.Printf("Metrics server stopped: %v", err)
+			}
+		}()
+	}
+
 	port, err := helper.ReadConfig[int]("server.port")
 	if err != nil {
 		log.Fatalf("failed to load port config %v", err)
@@ -162,8 +482,21 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	server := grpc.NewServer()
-	protos.RegisterChatServiceServer(server, &rpc.Server{})
+	verifier, err := auth.NewVerifierFromConfig()
+	if err != nil {
+		log.Fatalf("failed to load auth config %v", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(verifier.StreamServerInterceptor()),
+	)
+	protos.RegisterChatServiceServer(server, &rpc.Server{
+		Store:  cassandra.Storage{},
+		Search: elastic.Search{},
+		Events: producers.Events{},
+		Hub:    realtime.ChatMessageHub,
+	})
 
 	healthServer := health.NewServer()
 	healthpb.RegisterHealthServer(server, healthServer)
@@ -0,0 +1,298 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+)
+
+const (
+	lockName          = "schema"
+	lockTTL           = 5 * time.Minute
+	lockRetryInterval = 2 * time.Second
+	// Renewed several times per TTL so one slow renewal does not let the lock expire
+	lockRenewInterval = lockTTL / 3
+)
+
+var errLockLost = errors.New("migration lock was lost")
+
+// Identifies this instance in the migration lock
+var runnerId = uuid.NewString()
+
+type Migration struct {
+	Version int
+	Name    string
+	// Manual migrations take too long for the startup path, only the migrate command applies them.
+	// Later migrations must not depend on them since startup skips them.
+	Manual bool
+	// QuickWhen lets startup apply a manual migration when it reports true, e.g. when there is nothing to copy
+	QuickWhen func(ctx context.Context) (bool, error)
+	Steps     []Step
+}
+
+// MigrationStatus is a known migration and when it was applied, AppliedAt is zero while it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Status lists every known migration with the time it was applied
+func Status() ([]MigrationStatus, error) {
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(All))
+	for i, migration := range All {
+		statuses[i] = MigrationStatus{Migration: migration, AppliedAt: applied[migration.Version].AppliedAt}
+	}
+	return statuses, nil
+}
+
+// Up applies the pending migrations in version order, a dry run only logs their steps.
+// Manual migrations are left pending unless withManual is set.
+// Replicas starting together wait for the lock, then find nothing left to apply.
+func Up(ctx context.Context, dryRun bool, withManual bool) ([]Migration, error) {
+	if dryRun {
+		pending, err := pendingMigrations(ctx, withManual)
+		if err != nil {
+			return nil, err
+		}
+		for _, migration := range pending {
+			log.Printf("Would apply migration %d %s", migration.Version, migration.Name)
+			for _, step := range migration.Steps {
+				log.Printf("  %s", step.Describe())
+			}
+		}
+		return pending, nil
+	}
+
+	if err := createBookkeepingTables(ctx); err != nil {
+		return nil, err
+	}
+	if err := acquireLock(ctx); err != nil {
+		return nil, err
+	}
+	defer releaseLock()
+	lockCtx, heartbeat := holdLock(ctx)
+	defer heartbeat.stop()
+
+	// Read after locking, another replica may have applied them while this one waited
+	pending, err := pendingMigrations(lockCtx, withManual)
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.Session()
+	applied := []Migration{}
+	for _, migration := range pending {
+		log.Printf("Applying migration %d %s", migration.Version, migration.Name)
+		for _, step := range migration.Steps {
+			if err := step.Apply(lockCtx, session); err != nil {
+				if lockErr := heartbeat.err(); lockErr != nil {
+					err = lockErr
+				}
+				return applied, fmt.Errorf("migration %d failed at %q: %w", migration.Version, step.Describe(), err)
+			}
+		}
+		if err := heartbeat.err(); err != nil {
+			return applied, err
+		}
+
+		record := models.SchemaMigrationEntity{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+		if err := models.SchemaMigrationRepository.Insert(record); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// createBookkeepingTables creates the tables the runner itself needs, they are outside of the versioned migrations
+func createBookkeepingTables(ctx context.Context) error {
+	session := models.Session()
+	for _, step := range []Step{
+		CreateTable(models.SchemaMigrationRepository.TableInterface),
+		CreateTable(models.SchemaMigrationLockRepository.TableInterface),
+	} {
+		if err := step.Apply(ctx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appliedMigrations reads the applied versions, none are applied while the table does not exist
+func appliedMigrations() (map[int]models.SchemaMigrationEntity, error) {
+	table := models.SchemaMigrationRepository.TableInterface
+	applied := map[int]models.SchemaMigrationEntity{}
+
+	var name string
+	session := models.Session()
+	err := session.Query(`SELECT table_name FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?`,
+		table.Keyspace().Name(), table.Name()).Scan(&name)
+	if err == gocql.ErrNotFound {
+		return applied, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	iter := models.Select(table, fmt.Sprintf(`SELECT * FROM %q.%q`, table.Keyspace().Name(), table.Name()))
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		record := *row.(*models.SchemaMigrationEntity)
+		applied[record.Version] = record
+	}
+	return applied, iter.Close()
+}
+
+func pendingMigrations(ctx context.Context, withManual bool) ([]Migration, error) {
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, migration := range All {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if migration.Manual && !withManual {
+			quick := false
+			if migration.QuickWhen != nil {
+				if quick, err = migration.QuickWhen(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if !quick {
+				log.Printf("WARNING: manual migration %d %s is pending, apply it with migrate up", migration.Version, migration.Name)
+				continue
+			}
+			log.Printf("Manual migration %d %s is quick to apply, applying it at startup", migration.Version, migration.Name)
+		}
+		pending = append(pending, migration)
+	}
+	return pending, nil
+}
+
+// acquireLock waits until no other instance holds the lock, the lock expires if its holder dies
+func acquireLock(ctx context.Context) error {
+	table := models.SchemaMigrationLockRepository.TableInterface
+	insert := table.Query(fmt.Sprintf(`INSERT INTO %q.%q (name, owner) VALUES (?, ?) IF NOT EXISTS USING TTL ?`,
+		table.Keyspace().Name(), table.Name()), lockName, runnerId, int(lockTTL.Seconds()))
+
+	for {
+		holder := map[string]interface{}{}
+		applied, err := insert.Session.Query(insert.Statement, insert.Values...).WithContext(ctx).MapScanCAS(holder)
+		if err != nil {
+			return err
+		}
+		if applied {
+			return nil
+		}
+
+		log.Printf("Waiting for migration lock held by %v", holder["owner"])
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// lockHeartbeat renews the lock while the migrations run
+type lockHeartbeat struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	lost   error
+}
+
+// holdLock renews the lock in the background until stopped, the returned context is cancelled when a renewal fails
+// so a step never runs on once another instance may have taken the lock
+func holdLock(ctx context.Context) (context.Context, *lockHeartbeat) {
+	lockCtx, cancel := context.WithCancel(ctx)
+	heartbeat := &lockHeartbeat{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(heartbeat.done)
+		ticker := time.NewTicker(lockRenewInterval)
+		defer ticker.Stop()
+		renewedAt := time.Now()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := renewLock(lockCtx)
+			if err == nil {
+				renewedAt = time.Now()
+				continue
+			}
+			if lockCtx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to renew migration lock: %v", err)
+			// A failed request is retried on the next tick as long as that tick comes before the lock expires
+			if err != errLockLost && time.Since(renewedAt)+lockRenewInterval < lockTTL {
+				continue
+			}
+			heartbeat.mu.Lock()
+			heartbeat.lost = fmt.Errorf("migration lock could not be renewed: %w", err)
+			heartbeat.mu.Unlock()
+			cancel()
+			return
+		}
+	}()
+
+	return lockCtx, heartbeat
+}
+
+// err is set once a renewal failed
+func (h *lockHeartbeat) err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lost
+}
+
+func (h *lockHeartbeat) stop() {
+	h.cancel()
+	<-h.done
+}
+
+// renewLock extends the lock and fails if it expired and was taken by another instance
+func renewLock(ctx context.Context) error {
+	table := models.SchemaMigrationLockRepository.TableInterface
+	renew := table.Query(fmt.Sprintf(`UPDATE %q.%q USING TTL ? SET owner = ? WHERE name = ? IF owner = ?`,
+		table.Keyspace().Name(), table.Name()), int(lockTTL.Seconds()), runnerId, lockName, runnerId)
+
+	applied, err := renew.Session.Query(renew.Statement, renew.Values...).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return errLockLost
+	}
+	return nil
+}
+
+func releaseLock() {
+	table := models.SchemaMigrationLockRepository.TableInterface
+	release := table.Query(fmt.Sprintf(`DELETE FROM %q.%q WHERE name = ? IF owner = ?`,
+		table.Keyspace().Name(), table.Name()), lockName, runnerId)
+
+	if _, err := release.Session.Query(release.Statement, release.Values...).MapScanCAS(map[string]interface{}{}); err != nil {
+		log.Printf("Failed to release migration lock: %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/typedapi/core/reindex"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/conflicts"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/versiontype"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+	r "github.com/kristoiv/gocqltable/reflect"
+	"github.com/tripconnect/go-common-utils/common"
+)
+
+// Step is one change of a migration, a dry run prints its description instead of applying it.
+// Steps are idempotent so a migration interrupted halfway can be applied again.
+type Step interface {
+	Describe() string
+	Apply(ctx context.Context, session *gocql.Session) error
+}
+
+type cqlStep struct {
+	statement string
+}
+
+func (s cqlStep) Describe() string {
+	return s.statement
+}
+
+func (s cqlStep) Apply(ctx context.Context, session *gocql.Session) error {
+	return session.Query(s.statement).WithContext(ctx).Exec()
+}
+
+// CQL runs the statement as is, it has to be safe to run twice (e.g. CREATE ... IF NOT EXISTS)
+func CQL(statement string) Step {
+	return cqlStep{statement: statement}
+}
+
+// CreateTable creates the table of a repository from its row struct unless it exists
+func CreateTable(table gocqltable.TableInterface, properties ...string) Step {
+	fields, values, ok := r.FieldsAndValues(table.Row())
+	if !ok {
+		panic(fmt.Sprintf("table %s has no row struct", table.Name()))
+	}
+
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = fmt.Sprintf("%q %s", strings.ToLower(field), cqlType(values[i]))
+	}
+
+	primaryKey := "(" + strings.Join(table.RowKeys(), ", ") + ")"
+	if len(table.RangeKeys()) > 0 {
+		primaryKey += ", " + strings.Join(table.RangeKeys(), ", ")
+	}
+	columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", primaryKey))
+
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q (%s)`,
+		table.Keyspace().Name(), table.Name(), strings.Join(columns, ", "))
+	if len(properties) > 0 {
+		statement += " WITH " + strings.Join(properties, " AND ")
+	}
+	return cqlStep{statement: statement}
+}
+
+func cqlType(value interface{}) string {
+	switch value.(type) {
+	case time.Time:
+		return "timestamp"
+	case gocql.UUID:
+		return "uuid"
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Int, reflect.Int32:
+		return "int"
+	case reflect.Int64:
+		return "bigint"
+	case reflect.String:
+		return "text"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Float64:
+		return "double"
+	}
+	panic(fmt.Sprintf("no cql type for %T", value))
+}
+
+type addColumnStep struct {
+	table   gocqltable.TableInterface
+	column  string
+	cqlType string
+}
+
+// AddColumn adds the column unless the table already has it, Cassandra has no ADD IF NOT EXISTS
+func AddColumn(table gocqltable.TableInterface, column string, cqlType string) Step {
+	return addColumnStep{table: table, column: column, cqlType: cqlType}
+}
+
+func (s addColumnStep) statement() string {
+	return fmt.Sprintf(`ALTER TABLE %q.%q ADD %s %s`, s.table.Keyspace().Name(), s.table.Name(), s.column, s.cqlType)
+}
+
+func (s addColumnStep) Describe() string {
+	return s.statement() + " (unless it exists)"
+}
+
+func (s addColumnStep) Apply(ctx context.Context, session *gocql.Session) error {
+	var existing string
+	err := session.Query(`SELECT column_name FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ? AND column_name = ?`,
+		s.table.Keyspace().Name(), s.table.Name(), s.column).WithContext(ctx).Scan(&existing)
+	if err == nil {
+		return nil
+	}
+	if err != gocql.ErrNotFound {
+		return err
+	}
+	return session.Query(s.statement()).WithContext(ctx).Exec()
+}
+
+type indexStep struct {
+	index    string
+	mappings types.TypeMappingVariant
+}
+
+// CreateIndex creates the index with the mappings, an existing index gets the new fields of the mappings
+func CreateIndex(index string, mappings types.TypeMappingVariant) Step {
+	return indexStep{index: index, mappings: mappings}
+}
+
+func (s indexStep) Describe() string {
+	fields := []string{}
+	for field := range s.mappings.TypeMappingCaster().Properties {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	return fmt.Sprintf("create index %s or put mapping of fields %s", s.index, strings.Join(fields, ", "))
+}
+
+func (s indexStep) Apply(ctx context.Context, _ *gocql.Session) error {
+	exists, err := common.ElasticsearchClient.Indices.Exists(s.index).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		_, err = common.ElasticsearchClient.Indices.
+			Create(s.index).
+			Mappings(s.mappings).
+			Do(ctx)
+		return err
+	}
+
+	_, err = common.ElasticsearchClient.Indices.
+		PutMapping(s.index).
+		Properties(s.mappings.TypeMappingCaster().Properties).
+		Do(ctx)
+	return err
+}
+
+// How often a running reindex task is checked
+const reindexPollInterval = 5 * time.Second
+
+type reindexStep struct {
+	alias    string
+	index    string
+	settings types.IndexSettingsVariant
+	mappings types.TypeMappingVariant
+}
+
+// IndicesEmpty reports whether none of the indices or aliases holds a document, a missing one is empty
+func IndicesEmpty(names ...string) func(ctx context.Context) (bool, error) {
+	return func(ctx context.Context) (bool, error) {
+		for _, name := range names {
+			exists, err := common.ElasticsearchClient.Indices.Exists(name).Do(ctx)
+			if err != nil {
+				return false, err
+			}
+			if !exists {
+				continue
+			}
+
+			res, err := common.ElasticsearchClient.Count().Index(name).Do(ctx)
+			if err != nil {
+				return false, err
+			}
+			if res.Count > 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// ReindexIntoAlias creates the index with the settings and mappings, copies the documents of the alias into it and
+// atomically replaces the indices behind the alias, which may still be a concrete index, with the new one.
+// The copy keeps the document versions, a second pass copies the documents written during the first one.
+// Only writes landing between the second pass and the alias swap are lost, the reconciler restores them.
+func ReindexIntoAlias(alias string, index string, settings types.IndexSettingsVariant, mappings types.TypeMappingVariant) Step {
+	return reindexStep{alias: alias, index: index, settings: settings, mappings: mappings}
+}
+
+func (s reindexStep) Describe() string {
+	return fmt.Sprintf("create index %s, copy the documents of %s into it and point %s to it (unless it already does)",
+		s.index, s.alias, s.alias)
+}
+
+func (s reindexStep) Apply(ctx context.Context, _ *gocql.Session) error {
+	sources, err := s.sourceIndices(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(sources, s.index) {
+		return nil
+	}
+
+	exists, err := common.ElasticsearchClient.Indices.Exists(s.index).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err = common.ElasticsearchClient.Indices.
+			Create(s.index).
+			Settings(s.settings).
+			Mappings(s.mappings).
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	actions := []types.IndicesActionVariant{}
+	if len(sources) > 0 {
+		for pass := 1; pass <= 2; pass++ {
+			log.Printf("Copying %s into %s, pass %d", s.alias, s.index, pass)
+			if err := s.copyDocuments(ctx, sources); err != nil {
+				return err
+			}
+		}
+		// Removing the indices in the same request leaves no moment without the alias or with two indices behind it
+		actions = append(actions, esdsl.NewRemoveIndexAction().Indices(sources...))
+	}
+	actions = append(actions, esdsl.NewAddAction().Index(s.index).Alias(s.alias))
+
+	_, err = common.ElasticsearchClient.Indices.
+		UpdateAliases().
+		Actions(actions...).
+		Do(ctx)
+	return err
+}
+
+// copyDocuments runs the reindex as a task and polls it, so no request stays open for the whole copy.
+// External versions only let a document through when it changed since it was copied.
+func (s reindexStep) copyDocuments(ctx context.Context, sources []string) error {
+	started, err := common.ElasticsearchClient.
+		Reindex().
+		Source(esdsl.NewReindexSource().Index(sources...)).
+		Dest(esdsl.NewReindexDestination().Index(s.index).VersionType(versiontype.External)).
+		Conflicts(conflicts.Proceed).
+		WaitForCompletion(false).
+		Refresh(true).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if started.Task == nil {
+		return fmt.Errorf("reindex of %s into %s returned no task", s.alias, s.index)
+	}
+	taskId := *started.Task
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The task would keep copying after the lock is released
+			if _, err := common.ElasticsearchClient.Tasks.Cancel().TaskId(taskId).Do(context.Background()); err != nil {
+				log.Printf("Failed to cancel reindex task %s: %v", taskId, err)
+			}
+			return ctx.Err()
+		case <-time.After(reindexPollInterval):
+		}
+
+		task, err := common.ElasticsearchClient.Tasks.Get(taskId).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if !task.Completed {
+			continue
+		}
+		if task.Error != nil {
+			return fmt.Errorf("reindex of %s into %s failed: %s", s.alias, s.index, task.Error.Type)
+		}
+
+		result := reindex.NewResponse()
+		if err := json.Unmarshal(task.Response, result); err != nil {
+			return err
+		}
+		if len(result.Failures) > 0 {
+			return fmt.Errorf("reindex of %s into %s failed on %d documents, first: %s on %s",
+				s.alias, s.index, len(result.Failures), result.Failures[0].Cause.Type, result.Failures[0].Id)
+		}
+		return nil
+	}
+}
+
+// sourceIndices lists the indices behind the alias, the alias itself when it is still a concrete index
+func (s reindexStep) sourceIndices(ctx context.Context) ([]string, error) {
+	isAlias, err := common.ElasticsearchClient.Indices.ExistsAlias(s.alias).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if isAlias {
+		aliases, err := common.ElasticsearchClient.Indices.GetAlias().Name(s.alias).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		indices := slices.Collect(maps.Keys(aliases))
+		slices.Sort(indices)
+		return indices, nil
+	}
+
+	exists, err := common.ElasticsearchClient.Indices.Exists(s.alias).Do(ctx)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return []string{s.alias}, nil
+}
@@ -0,0 +1,129 @@
+package migrations
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+)
+
+// All lists the migrations in the order they are applied, append new ones and never edit an applied one
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "create chat tables",
+		Steps: []Step{
+			CreateTable(models.ConversationRepository.TableInterface),
+			CreateTable(models.ChatMessageRepository.TableInterface),
+			CreateTable(models.ParticipantRepository.TableInterface),
+			CreateTable(models.ChatMessageHistoryRepository.TableInterface),
+			CreateTable(models.HiddenChatMessageRepository.TableInterface),
+			CreateTable(models.MessageReactionRepository.TableInterface),
+			CreateTable(models.ReadCursorRepository.TableInterface),
+			CreateTable(models.MessageStatusRepository.TableInterface),
+			CreateTable(models.IdempotencyKeyRepository.TableInterface),
+			CreateTable(models.ConversationSequenceRepository.TableInterface),
+			CreateTable(models.LegacyOutboxRepository.TableInterface),
+			CreateTable(models.OutboxLeaseRepository.TableInterface),
+			// message_time is a timeuuid, which the row struct cannot express
+			CQL(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q (
+				conversation_id uuid, bucket int, message_time timeuuid, id uuid, from_user_id uuid, content text,
+				sent_time timestamp, created_at timestamp, edited_at timestamp, deleted_at timestamp, deleted_by uuid,
+				reply_to_message_id uuid, thread_root_id uuid, sequence bigint,
+				PRIMARY KEY ((conversation_id, bucket), message_time)
+			) WITH CLUSTERING ORDER BY (message_time DESC)`, consts.KeySpace, consts.MessageByConversationTableName)),
+			CreateTable(models.MessageBucketRepository.TableInterface, "CLUSTERING ORDER BY (bucket DESC)"),
+		},
+	},
+	{
+		// Tables created before these columns existed were altered at startup, the steps skip the columns they already have
+		Version: 2,
+		Name:    "add columns of tables created by earlier releases",
+		Steps: []Step{
+			AddColumn(models.ParticipantRepository.TableInterface, "role", "int"),
+			AddColumn(models.ChatMessageRepository.TableInterface, "edited_at", "timestamp"),
+			AddColumn(models.ChatMessageRepository.TableInterface, "deleted_at", "timestamp"),
+			AddColumn(models.ChatMessageRepository.TableInterface, "deleted_by", "uuid"),
+			AddColumn(models.ConversationRepository.TableInterface, "last_message_id", "uuid"),
+			AddColumn(models.ConversationRepository.TableInterface, "last_message_at", "timestamp"),
+			AddColumn(models.ConversationRepository.TableInterface, "last_message_preview", "text"),
+			AddColumn(models.ChatMessageRepository.TableInterface, "reply_to_message_id", "uuid"),
+			AddColumn(models.ChatMessageRepository.TableInterface, "thread_root_id", "uuid"),
+			AddColumn(models.ChatMessageRepository.TableInterface, "sequence", "bigint"),
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create search indices",
+		Steps: []Step{
+			CreateIndex(consts.ConversationIndex, withKeywordFields(models.ConversationDocumentMappings, "name")),
+			CreateIndex(consts.ChatMessageIndex, withKeywordFields(models.ChatMessageDocumentMappings, "content")),
+			CreateIndex(consts.ParticipantIndex, models.ParticipantDocumentMappings),
+		},
+	},
+	{
+		// A keyword field cannot become analyzed text in place, the indices are copied into new ones behind aliases.
+		// Copying every message takes too long for startup, search keeps exact matching until it is applied.
+		// A fresh install has nothing to copy and applies it at startup.
+		Version:   4,
+		Name:      "analyze conversation names and message content",
+		Manual:    true,
+		QuickWhen: IndicesEmpty(consts.ConversationIndex, consts.ChatMessageIndex),
+		Steps: []Step{
+			ReindexIntoAlias(consts.ConversationIndex, consts.ConversationIndex+"_v2", models.SearchIndexSettings, models.ConversationDocumentMappings),
+			ReindexIntoAlias(consts.ChatMessageIndex, consts.ChatMessageIndex+"_v2", models.SearchIndexSettings, models.ChatMessageDocumentMappings),
+		},
+	},
+	{
+		// Counts of existing members are filled by the backfill-unread command
+		Version: 5,
+		Name:    "count unread messages per member",
+		Steps: []Step{
+			CQL(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q (
+				conversation_id uuid, user_id uuid, unread counter,
+				PRIMARY KEY ((conversation_id), user_id)
+			)`, consts.KeySpace, consts.UnreadCountTableName)),
+		},
+	},
+	{
+		Version: 6,
+		Name:    "reserve sequence numbers per message",
+		Steps: []Step{
+			CreateTable(models.MessageSequenceRepository.TableInterface),
+		},
+	},
+	{
+		// The entry ids are timeuuids, which the row structs cannot express
+		Version: 7,
+		Name:    "partition the outbox by hour",
+		Steps: []Step{
+			CQL(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q (
+				shard int, bucket int, entry_id timeuuid, kind text, target text, key text, payload text,
+				attempts int, relayed boolean,
+				PRIMARY KEY ((shard, bucket), entry_id)
+			) WITH CLUSTERING ORDER BY (entry_id ASC) AND default_time_to_live = %d`,
+				consts.KeySpace, consts.OutboxEntryTableName, int(models.OutboxRetention.Seconds()))),
+			CQL(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q (shard int PRIMARY KEY, bucket int, entry_id timeuuid, swept int)`,
+				consts.KeySpace, consts.OutboxCursorTableName)),
+		},
+	},
+	{
+		Version: 8,
+		Name:    "park outbox entries that keep failing",
+		Steps: []Step{
+			CreateTable(models.OutboxDeadEntryRepository.TableInterface),
+		},
+	},
+}
+
+// withKeywordFields maps the fields as keyword the way version 3 created them, before version 4 analyzed them
+func withKeywordFields(mappings types.TypeMappingVariant, fields ...string) types.TypeMappingVariant {
+	properties := maps.Clone(mappings.TypeMappingCaster().Properties)
+	for _, field := range fields {
+		properties[field] = types.NewKeywordProperty()
+	}
+	return esdsl.NewTypeMapping().Properties(properties)
+}
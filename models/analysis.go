@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+)
+
+// TextAnalyzer analyzes the free text of any language, the ICU tokenizer splits the words of scripts written
+// without spaces and the normalizer and folding make matches case and accent insensitive.
+// The indices need the analysis-icu plugin.
+const TextAnalyzer = "chat_text"
+
+var SearchIndexSettings = esdsl.NewIndexSettings().
+	Analysis(esdsl.NewIndexSettingsAnalysis().
+		AddAnalyzer(TextAnalyzer, esdsl.NewCustomAnalyzer("icu_tokenizer").
+			CharFilter("icu_normalizer").
+			Filter("icu_folding")))
+
+// newTextProperty maps analyzed text, the keyword subfield keeps the value for exact matches and sorting
+func newTextProperty() types.PropertyVariant {
+	return esdsl.NewTextProperty().
+		Analyzer(TextAnalyzer).
+		AddField("keyword", esdsl.NewKeywordProperty().IgnoreAbove(256))
+}
@@ -1,14 +1,16 @@
 package models
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/TripConnect/chat-service/consts"
+	pb "github.com/TripConnect/chat-service/protos"
 	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
 	"github.com/gocql/gocql"
 	"github.com/kristoiv/gocqltable"
-	"github.com/kristoiv/gocqltable/recipes"
-	pb "github.com/tripconnect/go-proto-lib/protos"
+	"github.com/tripconnect/go-common-utils/common"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -19,83 +21,239 @@ const (
 	Joined    ParticipantStatus = 1
 )
 
+type ParticipantRole int
+
+const (
+	MemberRole ParticipantRole = 0
+	AdminRole  ParticipantRole = 1
+	OwnerRole  ParticipantRole = 2
+)
+
+type MembershipAction string
+
+const (
+	MembershipAdded       MembershipAction = "ADDED"
+	MembershipRemoved     MembershipAction = "REMOVED"
+	MembershipLeft        MembershipAction = "LEFT"
+	MembershipRequested   MembershipAction = "REQUESTED"
+	MembershipRejected    MembershipAction = "REJECTED"
+	MembershipRoleChanged MembershipAction = "ROLE_CHANGED"
+)
+
 type ConversationEntity struct {
-	Id        gocql.UUID `cql:"id"`
-	OwnerId   gocql.UUID `cql:"owner_id"`
-	Name      string     `cql:"name"`
-	Type      int        `cql:"type"`
-	CreatedAt time.Time  `cql:"created_at"`
+	Id                 gocql.UUID `cql:"id"`
+	OwnerId            gocql.UUID `cql:"owner_id"`
+	Name               string     `cql:"name"`
+	Type               int        `cql:"type"`
+	CreatedAt          time.Time  `cql:"created_at"`
+	LastMessageId      gocql.UUID `cql:"last_message_id"`
+	LastMessageAt      time.Time  `cql:"last_message_at"`
+	LastMessagePreview string     `cql:"last_message_preview"`
 }
 
+const messagePreviewLength = 100
+
 type ParticipantEntity struct {
 	ConversationId gocql.UUID `cql:"conversation_id"`
 	NickName       string     `cql:"nick_name"`
 	UserId         gocql.UUID `cql:"user_id"`
 	Status         int        `cql:"status"`
+	Role           int        `cql:"role"`
 	CreatedAt      time.Time  `cql:"created_at"`
 }
 
+// ReadCursorEntity is the last message a participant has read, LastReadSentTime orders cursors against messages
+type ReadCursorEntity struct {
+	ConversationId    gocql.UUID `cql:"conversation_id"`
+	UserId            gocql.UUID `cql:"user_id"`
+	LastReadMessageId gocql.UUID `cql:"last_read_message_id"`
+	LastReadSentTime  time.Time  `cql:"last_read_sent_time"`
+	ReadAt            time.Time  `cql:"read_at"`
+}
+
 type ConversationDocument struct {
 	Id        gocql.UUID `json:"id"`
 	Name      string     `json:"name"`
 	Type      int        `json:"type"`
 	MemberIds []string   `json:"member_ids"`
 	CreatedAt int        `json:"created_at"`
+	// Falls back to created_at so conversations without messages are ordered too
+	LastMessageAt int `json:"last_message_at"`
 }
 
 type ParticipantDocument struct {
 	ConversationId gocql.UUID `json:"conversation_id"`
 	UserId         gocql.UUID `json:"user_id"`
 	Status         int        `json:"status"`
+	Role           int        `json:"role"`
 	CreatedAt      int        `json:"created_at"`
 }
 
+type KafkaMembershipEvent struct {
+	ConversationId gocql.UUID       `json:"conversation_id"`
+	ActorId        gocql.UUID       `json:"actor_id"`
+	UserIds        []gocql.UUID     `json:"user_ids"`
+	Action         MembershipAction `json:"action"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+type KafkaReadCursor struct {
+	ConversationId    gocql.UUID `json:"conversation_id"`
+	UserId            gocql.UUID `json:"user_id"`
+	LastReadMessageId gocql.UUID `json:"last_read_message_id"`
+	ReadAt            time.Time  `json:"read_at"`
+}
+
 var ConversationDocumentMappings = esdsl.NewTypeMapping().
 	AddProperty("id", esdsl.NewKeywordProperty()).
-	AddProperty("name", esdsl.NewKeywordProperty()).
+	AddProperty("name", newTextProperty()).
 	AddProperty("type", esdsl.NewIntegerNumberProperty()).
 	AddProperty("member_ids", esdsl.NewKeywordProperty()).
-	AddProperty("created_at", esdsl.NewLongNumberProperty())
+	AddProperty("created_at", esdsl.NewLongNumberProperty()).
+	AddProperty("last_message_at", esdsl.NewLongNumberProperty())
 
 var ParticipantDocumentMappings = esdsl.NewTypeMapping().
 	AddProperty("conversation_id", esdsl.NewKeywordProperty()).
 	AddProperty("user_id", esdsl.NewKeywordProperty()).
 	AddProperty("status", esdsl.NewIntegerNumberProperty()).
+	AddProperty("role", esdsl.NewIntegerNumberProperty()).
 	AddProperty("created_at", esdsl.NewLongNumberProperty())
 
 var ConversationRepository = struct {
-	recipes.CRUD
+	consistentTable
 }{
-	recipes.CRUD{
-		TableInterface: gocqltable.NewKeyspace(consts.KeySpace).NewTable(
-			consts.ConversationTableName,
-			[]string{"id"},
-			nil,
-			ConversationEntity{},
-		),
-	},
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.ConversationTableName,
+		[]string{"id"},
+		nil,
+		ConversationEntity{},
+	)),
 }
 
 var ParticipantRepository = struct {
-	recipes.CRUD
+	consistentTable
 }{
-	recipes.CRUD{
-		TableInterface: gocqltable.NewKeyspace(consts.KeySpace).NewTable(
-			consts.ParticipantTableName,
-			[]string{"conversation_id", "user_id", "status"},
-			nil,
-			ParticipantEntity{},
-		),
-	},
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.ParticipantTableName,
+		[]string{"conversation_id", "user_id", "status"},
+		nil,
+		ParticipantEntity{},
+	)),
+}
+
+var ReadCursorRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.ReadCursorTableName,
+		[]string{"conversation_id"},
+		[]string{"user_id"},
+		ReadCursorEntity{},
+	)),
+}
+
+// TouchConversation writes only the last message columns, guarded on no later message being recorded, so it never
+// reverts a concurrent rename or ownership transfer. The same message may be recorded again, a redelivered message
+// finishes what a failed attempt started. It reports whether the message is now the last activity and fails with
+// gocql.ErrNotFound when the conversation does not exist.
+func TouchConversation(message ChatMessageEntity) (bool, error) {
+	table := ConversationRepository.TableInterface
+	touch := func(condition string, values ...interface{}) (bool, map[string]interface{}, error) {
+		query := table.Query(fmt.Sprintf(`UPDATE %q.%q SET last_message_id = ?, last_message_at = ?, last_message_preview = ? WHERE id = ? IF %s`,
+			table.Keyspace().Name(), table.Name(), condition),
+			append([]interface{}{message.Id, message.SentTime, NewMessagePreview(message.Content), message.ConversationId}, values...)...)
+		previous := map[string]interface{}{}
+		applied, err := query.Session.Query(query.Statement, query.Values...).MapScanCAS(previous)
+		return applied, previous, err
+	}
+
+	applied, previous, err := touch("last_message_at <= ?", message.SentTime)
+	if err != nil || applied {
+		return applied, err
+	}
+	// The condition columns come back only when the row exists, a conversation without messages has no time to compare with
+	previousAt, ok := previous["last_message_at"]
+	if !ok {
+		return false, gocql.ErrNotFound
+	}
+	if lastMessageAt, ok := previousAt.(time.Time); ok && !lastMessageAt.IsZero() {
+		return false, nil
+	}
+	applied, _, err = touch("last_message_at = null")
+	return applied, err
+}
+
+// UpdateConversationPreview writes only the preview, guarded on the message still being the last one
+func UpdateConversationPreview(message ChatMessageEntity) (bool, error) {
+	table := ConversationRepository.TableInterface
+	query := table.Query(fmt.Sprintf(`UPDATE %q.%q SET last_message_preview = ? WHERE id = ? IF last_message_id = ?`,
+		table.Keyspace().Name(), table.Name()), NewMessagePreview(message.Content), message.ConversationId, message.Id)
+	return query.Session.Query(query.Statement, query.Values...).MapScanCAS(map[string]interface{}{})
+}
+
+// InsertConversationIfNotExists reports whether the conversation was created by this call, an existing row is left as it is
+func InsertConversationIfNotExists(entity ConversationEntity) (bool, error) {
+	table := ConversationRepository.TableInterface
+	query := table.Query(fmt.Sprintf(`INSERT INTO %q.%q (id, owner_id, name, type, created_at) VALUES (?, ?, ?, ?, ?) IF NOT EXISTS`,
+		table.Keyspace().Name(), table.Name()), entity.Id, entity.OwnerId, entity.Name, entity.Type, entity.CreatedAt)
+	return query.Session.Query(query.Statement, query.Values...).MapScanCAS(map[string]interface{}{})
+}
+
+// RoleOf resolves the participant role, the conversation owner wins for rows created before roles existed
+func (c ConversationEntity) RoleOf(participant ParticipantEntity) ParticipantRole {
+	if c.OwnerId == participant.UserId {
+		return OwnerRole
+	}
+	return ParticipantRole(participant.Role)
+}
+
+// FindParticipant looks the participant up under every status since status is part of the primary key
+func FindParticipant(conversationId gocql.UUID, userId gocql.UUID) (*ParticipantEntity, error) {
+	for _, status := range []ParticipantStatus{Joined, Requested} {
+		if participant, err := ParticipantRepository.Get(conversationId, userId, int(status)); err == nil {
+			return participant.(*ParticipantEntity), nil
+		} else if err != gocql.ErrNotFound {
+			return nil, err
+		}
+	}
+	return nil, gocql.ErrNotFound
+}
+
+// NewPrivateConversationId derives the id of the private conversation of the members, whatever their order.
+// common.BuildUUID sorts its arguments in place, the members of the caller are left as they are.
+func NewPrivateConversationId(memberIds []string) gocql.UUID {
+	return gocql.UUID(common.BuildUUID(slices.Clone(memberIds)...))
+}
+
+func NewParticipantDocId(conversationId gocql.UUID, userId gocql.UUID) string {
+	return fmt.Sprintf("%s%s%s", conversationId, consts.ElasticsearchSeparator, userId)
+}
+
+// LastActivityAt is the time the inbox orders the conversation by
+func (c ConversationEntity) LastActivityAt() time.Time {
+	if c.LastMessageAt.IsZero() {
+		return c.CreatedAt
+	}
+	return c.LastMessageAt
+}
+
+// NewMessagePreview cuts the content to the length shown in the inbox
+func NewMessagePreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= messagePreviewLength {
+		return content
+	}
+	return string(runes[:messagePreviewLength])
 }
 
 func NewConversationDoc(entity ConversationEntity, membersIds []string) ConversationDocument {
 	return ConversationDocument{
-		Id:        entity.Id,
-		Name:      entity.Name,
-		Type:      entity.Type,
-		CreatedAt: int(entity.CreatedAt.UnixMilli()),
-		MemberIds: membersIds,
+		Id:            entity.Id,
+		Name:          entity.Name,
+		Type:          entity.Type,
+		CreatedAt:     int(entity.CreatedAt.UnixMilli()),
+		MemberIds:     membersIds,
+		LastMessageAt: int(entity.LastActivityAt().UnixMilli()),
 	}
 }
 
@@ -104,6 +262,7 @@ func NewParticipantDoc(entity ParticipantEntity, membersIds []string) Participan
 		ConversationId: entity.ConversationId,
 		UserId:         entity.UserId,
 		Status:         entity.Status,
+		Role:           entity.Role,
 		CreatedAt:      int(entity.CreatedAt.UnixMilli()),
 	}
 }
@@ -115,10 +274,33 @@ func NewConversationPb(entity ConversationEntity, joinedMembers []ParticipantEnt
 	}
 
 	return pb.Conversation{
-		Id:        entity.Id.String(),
-		Type:      pb.ConversationType(entity.Type),
-		Name:      entity.Name,
-		MemberIds: memberIds,
-		CreatedAt: timestamppb.New(entity.CreatedAt),
+		Id:                 entity.Id.String(),
+		Type:               pb.ConversationType(entity.Type),
+		Name:               entity.Name,
+		MemberIds:          memberIds,
+		OwnerId:            entity.OwnerId.String(),
+		CreatedAt:          timestamppb.New(entity.CreatedAt),
+		LastMessageAt:      newOptionalTimestampPb(entity.LastMessageAt),
+		LastMessagePreview: entity.LastMessagePreview,
+	}
+}
+
+func NewParticipantPb(entity ParticipantEntity) pb.Participant {
+	return pb.Participant{
+		ConversationId: entity.ConversationId.String(),
+		UserId:         entity.UserId.String(),
+		NickName:       entity.NickName,
+		Status:         pb.ParticipantStatus(entity.Status),
+		Role:           pb.ParticipantRole(entity.Role),
+		CreatedAt:      timestamppb.New(entity.CreatedAt),
+	}
+}
+
+func NewReadReceiptPb(entity ReadCursorEntity) pb.ReadReceipt {
+	return pb.ReadReceipt{
+		ConversationId:    entity.ConversationId.String(),
+		UserId:            entity.UserId.String(),
+		LastReadMessageId: entity.LastReadMessageId.String(),
+		ReadAt:            timestamppb.New(entity.ReadAt),
 	}
 }
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+)
+
+// Messages of a conversation are partitioned by UTC day so a busy conversation never grows a single partition forever
+const MessageBucketDuration = 24 * time.Hour
+
+// MessageByConversationEntity is a copy of a message in the partition of its conversation and bucket,
+// MessageTime orders the partition newest first
+type MessageByConversationEntity struct {
+	ConversationId   gocql.UUID `cql:"conversation_id"`
+	Bucket           int        `cql:"bucket"`
+	MessageTime      gocql.UUID `cql:"message_time"`
+	Id               gocql.UUID `cql:"id"`
+	FromUserId       gocql.UUID `cql:"from_user_id"`
+	Content          string     `cql:"content"`
+	SentTime         time.Time  `cql:"sent_time"`
+	CreatedAt        time.Time  `cql:"created_at"`
+	EditedAt         time.Time  `cql:"edited_at"`
+	DeletedAt        time.Time  `cql:"deleted_at"`
+	DeletedBy        gocql.UUID `cql:"deleted_by"`
+	ReplyToMessageId gocql.UUID `cql:"reply_to_message_id"`
+	ThreadRootId     gocql.UUID `cql:"thread_root_id"`
+	Sequence         int64      `cql:"sequence"`
+}
+
+// MessageBucketEntity lists the buckets of a conversation holding messages, newest first
+type MessageBucketEntity struct {
+	ConversationId gocql.UUID `cql:"conversation_id"`
+	Bucket         int        `cql:"bucket"`
+}
+
+var MessageByConversationRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.MessageByConversationTableName,
+		[]string{"conversation_id", "bucket"},
+		[]string{"message_time"},
+		MessageByConversationEntity{},
+	)),
+}
+
+var MessageBucketRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.MessageBucketTableName,
+		[]string{"conversation_id"},
+		[]string{"bucket"},
+		MessageBucketEntity{},
+	)),
+}
+
+func MessageBucket(t time.Time) int {
+	return int(t.UTC().Unix() / int64(MessageBucketDuration/time.Second))
+}
+
+// NewMessageTimeUUID orders the message by its sent time, the rest of the uuid comes from the message id
+// so writing the message again keeps its clustering key
+func NewMessageTimeUUID(sentTime time.Time, messageId gocql.UUID) gocql.UUID {
+	// Cassandra keeps milliseconds, the same precision the history bounds are compared with
+	timeUUID := gocql.UUIDFromTime(sentTime.Truncate(time.Millisecond))
+	copy(timeUUID[8:], messageId[8:])
+	timeUUID[8] = timeUUID[8]&0x3F | 0x80
+	return timeUUID
+}
+
+func NewMessageByConversation(entity ChatMessageEntity) MessageByConversationEntity {
+	return MessageByConversationEntity{
+		ConversationId:   entity.ConversationId,
+		Bucket:           MessageBucket(entity.SentTime),
+		MessageTime:      NewMessageTimeUUID(entity.SentTime, entity.Id),
+		Id:               entity.Id,
+		FromUserId:       entity.FromUserId,
+		Content:          entity.Content,
+		SentTime:         entity.SentTime,
+		CreatedAt:        entity.CreatedAt,
+		EditedAt:         entity.EditedAt,
+		DeletedAt:        entity.DeletedAt,
+		DeletedBy:        entity.DeletedBy,
+		ReplyToMessageId: entity.ReplyToMessageId,
+		ThreadRootId:     entity.ThreadRootId,
+		Sequence:         entity.Sequence,
+	}
+}
+
+func (m MessageByConversationEntity) ChatMessage() ChatMessageEntity {
+	return ChatMessageEntity{
+		Id:               m.Id,
+		ConversationId:   m.ConversationId,
+		FromUserId:       m.FromUserId,
+		Content:          m.Content,
+		SentTime:         m.SentTime,
+		CreatedAt:        m.CreatedAt,
+		EditedAt:         m.EditedAt,
+		DeletedAt:        m.DeletedAt,
+		DeletedBy:        m.DeletedBy,
+		ReplyToMessageId: m.ReplyToMessageId,
+		ThreadRootId:     m.ThreadRootId,
+		Sequence:         m.Sequence,
+	}
+}
+
+// NewChatMessageRows are the rows storing the message, written together whenever the message changes
+func NewChatMessageRows(entity ChatMessageEntity) []TableRow {
+	return []TableRow{
+		{Table: MessageByConversationRepository.TableInterface, Row: NewMessageByConversation(entity)},
+		{Table: MessageBucketRepository.TableInterface, Row: MessageBucketEntity{ConversationId: entity.ConversationId, Bucket: MessageBucket(entity.SentTime)}},
+	}
+}
+
+// EditChatMessage writes only the content and edit time of the message and of its history copy, each guarded
+// on the message not being deleted so a concurrent delete for everyone is never undone. It reports whether the
+// message was edited, the copy is written after the message so it never holds an edit the message lacks.
+func EditChatMessage(entity ChatMessageEntity) (bool, error) {
+	table := ChatMessageRepository.TableInterface
+	edit := table.Query(fmt.Sprintf(`UPDATE %q.%q SET content = ?, edited_at = ? WHERE id = ? IF deleted_at = null`,
+		table.Keyspace().Name(), table.Name()), entity.Content, entity.EditedAt, entity.Id)
+	applied, err := edit.Session.Query(edit.Statement, edit.Values...).MapScanCAS(map[string]interface{}{})
+	if err != nil || !applied {
+		return applied, err
+	}
+
+	copyTable := MessageByConversationRepository.TableInterface
+	editCopy := copyTable.Query(fmt.Sprintf(`UPDATE %q.%q SET content = ?, edited_at = ? WHERE conversation_id = ? AND bucket = ? AND message_time = ? IF deleted_at = null`,
+		copyTable.Keyspace().Name(), copyTable.Name()),
+		entity.Content, entity.EditedAt, entity.ConversationId, MessageBucket(entity.SentTime), NewMessageTimeUUID(entity.SentTime, entity.Id))
+	if _, err := editCopy.Session.Query(editCopy.Statement, editCopy.Values...).MapScanCAS(map[string]interface{}{}); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// ListConversationHistory pages the messages of a conversation newest first, walking its buckets from before down to after.
+// Zero bounds are open, messages the viewer deleted for themselves are skipped.
+func ListConversationHistory(conversationId gocql.UUID, viewerId gocql.UUID, before time.Time, after time.Time, limit int) ([]ChatMessageEntity, error) {
+	bucketTable := MessageBucketRepository.TableInterface
+	bucketConditions := []string{"conversation_id = ?"}
+	bucketValues := []interface{}{conversationId}
+	if !before.IsZero() {
+		bucketConditions = append(bucketConditions, "bucket <= ?")
+		bucketValues = append(bucketValues, MessageBucket(before))
+	}
+	if !after.IsZero() {
+		bucketConditions = append(bucketConditions, "bucket >= ?")
+		bucketValues = append(bucketValues, MessageBucket(after))
+	}
+	bucketIter := Select(bucketTable, fmt.Sprintf(`SELECT * FROM %q.%q WHERE %s`,
+		bucketTable.Keyspace().Name(), bucketTable.Name(), strings.Join(bucketConditions, " AND ")), bucketValues...)
+
+	messages := []ChatMessageEntity{}
+	for row := bucketIter.Next(); row != nil && len(messages) < limit; row = bucketIter.Next() {
+		bucket := row.(*MessageBucketEntity).Bucket
+
+		var cursor *gocql.UUID
+		if !before.IsZero() {
+			upper := gocql.MinTimeUUID(before.Truncate(time.Millisecond))
+			cursor = &upper
+		}
+		for len(messages) < limit {
+			want := limit - len(messages)
+			page, err := listBucketMessages(conversationId, bucket, cursor, after, want)
+			if err != nil {
+				bucketIter.Close()
+				return nil, err
+			}
+
+			visible, err := withoutHiddenMessages(viewerId, page)
+			if err != nil {
+				bucketIter.Close()
+				return nil, err
+			}
+			messages = append(messages, visible...)
+
+			if len(page) < want {
+				break
+			}
+			cursor = &page[len(page)-1].MessageTime
+		}
+	}
+
+	if err := bucketIter.Close(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListConversationHistoryAfter pages the messages of a conversation oldest first, walking its buckets up from after.
+// The cursor is the sent time and id of the last message received, a zero id starts after every message of that millisecond
+// and a zero time starts at the first message.
+func ListConversationHistoryAfter(conversationId gocql.UUID, viewerId gocql.UUID, after time.Time, afterId gocql.UUID, limit int) ([]ChatMessageEntity, error) {
+	bucketTable := MessageBucketRepository.TableInterface
+	bucketIter := Select(bucketTable, fmt.Sprintf(`SELECT * FROM %q.%q WHERE conversation_id = ? AND bucket >= ? ORDER BY bucket ASC`,
+		bucketTable.Keyspace().Name(), bucketTable.Name()), conversationId, MessageBucket(after))
+
+	cursor := gocql.MaxTimeUUID(after.Truncate(time.Millisecond))
+	if after.IsZero() {
+		cursor = gocql.MinTimeUUID(time.Unix(0, 0))
+	} else if afterId != (gocql.UUID{}) {
+		cursor = NewMessageTimeUUID(after, afterId)
+	}
+
+	messages := []ChatMessageEntity{}
+	for row := bucketIter.Next(); row != nil && len(messages) < limit; row = bucketIter.Next() {
+		bucket := row.(*MessageBucketEntity).Bucket
+
+		for len(messages) < limit {
+			want := limit - len(messages)
+			page, err := listBucketMessagesAfter(conversationId, bucket, cursor, want)
+			if err != nil {
+				bucketIter.Close()
+				return nil, err
+			}
+
+			visible, err := withoutHiddenMessages(viewerId, page)
+			if err != nil {
+				bucketIter.Close()
+				return nil, err
+			}
+			messages = append(messages, visible...)
+
+			if len(page) < want {
+				break
+			}
+			cursor = page[len(page)-1].MessageTime
+		}
+	}
+
+	if err := bucketIter.Close(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func listBucketMessagesAfter(conversationId gocql.UUID, bucket int, cursor gocql.UUID, limit int) ([]MessageByConversationEntity, error) {
+	table := MessageByConversationRepository.TableInterface
+	iter := Select(table, fmt.Sprintf(`SELECT * FROM %q.%q WHERE conversation_id = ? AND bucket = ? AND message_time > ? ORDER BY message_time ASC LIMIT ?`,
+		table.Keyspace().Name(), table.Name()), conversationId, bucket, cursor, limit)
+
+	page := []MessageByConversationEntity{}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		page = append(page, *row.(*MessageByConversationEntity))
+	}
+	return page, iter.Close()
+}
+
+func listBucketMessages(conversationId gocql.UUID, bucket int, cursor *gocql.UUID, after time.Time, limit int) ([]MessageByConversationEntity, error) {
+	table := MessageByConversationRepository.TableInterface
+	conditions := []string{"conversation_id = ?", "bucket = ?"}
+	values := []interface{}{conversationId, bucket}
+	if cursor != nil {
+		conditions = append(conditions, "message_time < ?")
+		values = append(values, *cursor)
+	}
+	if !after.IsZero() {
+		conditions = append(conditions, "message_time > ?")
+		values = append(values, gocql.MaxTimeUUID(after.Truncate(time.Millisecond)))
+	}
+	values = append(values, limit)
+
+	iter := Select(table, fmt.Sprintf(`SELECT * FROM %q.%q WHERE %s LIMIT ?`,
+		table.Keyspace().Name(), table.Name(), strings.Join(conditions, " AND ")), values...)
+
+	page := []MessageByConversationEntity{}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		page = append(page, *row.(*MessageByConversationEntity))
+	}
+	return page, iter.Close()
+}
+
+func withoutHiddenMessages(viewerId gocql.UUID, page []MessageByConversationEntity) ([]ChatMessageEntity, error) {
+	if len(page) == 0 {
+		return nil, nil
+	}
+
+	messageIds := make([]gocql.UUID, len(page))
+	for i, message := range page {
+		messageIds[i] = message.Id
+	}
+
+	table := HiddenChatMessageRepository.TableInterface
+	iter := Select(table, fmt.Sprintf(`SELECT * FROM %q.%q WHERE user_id = ? AND message_id IN ?`,
+		table.Keyspace().Name(), table.Name()), viewerId, messageIds)
+	hidden := map[gocql.UUID]bool{}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		hidden[row.(*HiddenChatMessageEntity).MessageId] = true
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	visible := []ChatMessageEntity{}
+	for _, message := range page {
+		if !hidden[message.Id] {
+			visible = append(visible, message.ChatMessage())
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+const defaultIdempotencyWindow = 24 * time.Hour
+
+// IdempotencyKeyEntity maps a client supplied key to the message created by the first call
+type IdempotencyKeyEntity struct {
+	ConversationId gocql.UUID `cql:"conversation_id"`
+	FromUserId     gocql.UUID `cql:"from_user_id"`
+	IdempotencyKey string     `cql:"idempotency_key"`
+	MessageId      gocql.UUID `cql:"message_id"`
+	CreatedAt      time.Time  `cql:"created_at"`
+}
+
+var IdempotencyKeyRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.IdempotencyKeyTableName,
+		[]string{"conversation_id", "from_user_id"},
+		[]string{"idempotency_key"},
+		IdempotencyKeyEntity{},
+	)),
+}
+
+// IdempotencyWindow is how long a key is remembered, configured by chat.message.idempotency-window-seconds
+func IdempotencyWindow() time.Duration {
+	seconds, err := helper.ReadConfig[int]("chat.message.idempotency-window-seconds")
+	if err != nil || seconds <= 0 {
+		return defaultIdempotencyWindow
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ClaimIdempotencyKey binds the key to the message unless another message already holds it,
+// it returns the id of the message owning the key
+func ClaimIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) (gocql.UUID, error) {
+	table := IdempotencyKeyRepository.TableInterface
+	statement := fmt.Sprintf(
+		`INSERT INTO %q.%q (conversation_id, from_user_id, idempotency_key, message_id, created_at) VALUES (?, ?, ?, ?, ?) IF NOT EXISTS USING TTL ?`,
+		table.Keyspace().Name(), table.Name(),
+	)
+	query := table.Query(statement, conversationId, fromUserId, key, messageId, time.Now(), int(IdempotencyWindow().Seconds()))
+
+	existing := map[string]interface{}{}
+	applied, err := query.Session.Query(query.Statement, query.Values...).MapScanCAS(existing)
+	if err != nil {
+		return gocql.UUID{}, err
+	}
+	if applied {
+		return messageId, nil
+	}
+
+	ownerId, ok := existing["message_id"].(gocql.UUID)
+	if !ok {
+		return gocql.UUID{}, fmt.Errorf("idempotency key %q has no message id", key)
+	}
+	return ownerId, nil
+}
+
+// ReleaseIdempotencyKey frees the key held by the message so that a retry sends it again,
+// a key claimed since by another message is kept
+func ReleaseIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) error {
+	table := IdempotencyKeyRepository.TableInterface
+	statement := fmt.Sprintf(
+		`DELETE FROM %q.%q WHERE conversation_id = ? AND from_user_id = ? AND idempotency_key = ? IF message_id = ?`,
+		table.Keyspace().Name(), table.Name(),
+	)
+	query := table.Query(statement, conversationId, fromUserId, key, messageId)
+
+	_, err := query.Session.Query(query.Statement, query.Values...).MapScanCAS(map[string]interface{}{})
+	return err
+}
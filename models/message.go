@@ -1,102 +1,250 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/TripConnect/chat-service/consts"
+	pb "github.com/TripConnect/chat-service/protos"
 	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
 	"github.com/gocql/gocql"
 	"github.com/kristoiv/gocqltable"
-	"github.com/kristoiv/gocqltable/recipes"
-	pb "github.com/tripconnect/go-proto-lib/protos"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type ChatMessageEntity struct {
-	Id             gocql.UUID `cql:"id"`
+	Id               gocql.UUID `cql:"id"`
+	ConversationId   gocql.UUID `cql:"conversation_id"`
+	FromUserId       gocql.UUID `cql:"from_user_id"`
+	Content          string     `cql:"content"`
+	SentTime         time.Time  `cql:"sent_time"`
+	CreatedAt        time.Time  `cql:"created_at"`
+	EditedAt         time.Time  `cql:"edited_at"`
+	DeletedAt        time.Time  `cql:"deleted_at"`
+	DeletedBy        gocql.UUID `cql:"deleted_by"`
+	ReplyToMessageId gocql.UUID `cql:"reply_to_message_id"`
+	ThreadRootId     gocql.UUID `cql:"thread_root_id"`
+	Sequence         int64      `cql:"sequence"`
+}
+
+// HiddenChatMessageEntity marks a message deleted for one user only
+type HiddenChatMessageEntity struct {
+	UserId         gocql.UUID `cql:"user_id"`
+	MessageId      gocql.UUID `cql:"message_id"`
 	ConversationId gocql.UUID `cql:"conversation_id"`
-	FromUserId     gocql.UUID `cql:"from_user_id"`
-	Content        string     `cql:"content"`
-	SentTime       time.Time  `cql:"sent_time"`
-	CreatedAt      time.Time  `cql:"created_at"`
+	HiddenAt       time.Time  `cql:"hidden_at"`
+}
+
+// ChatMessageHistoryEntity keeps a previous content of an edited message
+type ChatMessageHistoryEntity struct {
+	MessageId  gocql.UUID `cql:"message_id"`
+	Content    string     `cql:"content"`
+	ReplacedAt time.Time  `cql:"replaced_at"`
 }
 
 type ChatMessageDocument struct {
-	Id             gocql.UUID `json:"id"`
-	ConversationId gocql.UUID `json:"conversation_id"`
-	FromUserId     gocql.UUID `json:"from_user_id"`
-	Content        string     `json:"content"`
-	SentTime       int        `json:"sent_time"`
-	CreatedAt      int        `json:"created_at"`
+	Id               gocql.UUID `json:"id"`
+	ConversationId   gocql.UUID `json:"conversation_id"`
+	FromUserId       gocql.UUID `json:"from_user_id"`
+	Content          string     `json:"content"`
+	SentTime         int        `json:"sent_time"`
+	CreatedAt        int        `json:"created_at"`
+	EditedAt         int        `json:"edited_at,omitempty"`
+	DeletedAt        int        `json:"deleted_at,omitempty"`
+	HiddenFor        []string   `json:"hidden_for,omitempty"`
+	ReplyToMessageId string     `json:"reply_to_message_id,omitempty"`
+	ThreadRootId     string     `json:"thread_root_id,omitempty"`
+	Sequence         int64      `json:"sequence"`
 }
 
 type KafkaPendingMessage struct {
-	ConversationId gocql.UUID `json:"conversation_id"`
-	MessageId      gocql.UUID `json:"message_id"`
-	FromUserId     gocql.UUID `json:"from_user_id"`
-	Content        string     `json:"content"`
-	SentTime       time.Time  `json:"sent_time"`
+	ConversationId   gocql.UUID `json:"conversation_id"`
+	MessageId        gocql.UUID `json:"message_id"`
+	FromUserId       gocql.UUID `json:"from_user_id"`
+	Content          string     `json:"content"`
+	SentTime         time.Time  `json:"sent_time"`
+	ReplyToMessageId gocql.UUID `json:"reply_to_message_id"`
+	ThreadRootId     gocql.UUID `json:"thread_root_id"`
+	IdempotencyKey   string     `json:"idempotency_key,omitempty"`
 }
 
 type KafkaSentMessage struct {
+	Id               gocql.UUID `json:"id"`
+	ConversationId   gocql.UUID `json:"conversation_id"`
+	FromUserId       gocql.UUID `json:"from_user_id"`
+	Content          string     `json:"content"`
+	SentTime         time.Time  `json:"sent_time"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ReplyToMessageId gocql.UUID `json:"reply_to_message_id"`
+	ThreadRootId     gocql.UUID `json:"thread_root_id"`
+	Sequence         int64      `json:"sequence"`
+}
+
+type KafkaEditedMessage struct {
 	Id             gocql.UUID `json:"id"`
 	ConversationId gocql.UUID `json:"conversation_id"`
 	FromUserId     gocql.UUID `json:"from_user_id"`
 	Content        string     `json:"content"`
-	SentTime       time.Time  `json:"sent_time"`
-	CreatedAt      time.Time  `json:"created_at"`
+	EditedAt       time.Time  `json:"edited_at"`
+}
+
+type DeletionMode string
+
+const (
+	DeletedForMe       DeletionMode = "FOR_ME"
+	DeletedForEveryone DeletionMode = "FOR_EVERYONE"
+)
+
+type KafkaDeletedMessage struct {
+	Id             gocql.UUID   `json:"id"`
+	ConversationId gocql.UUID   `json:"conversation_id"`
+	DeletedBy      gocql.UUID   `json:"deleted_by"`
+	Mode           DeletionMode `json:"mode"`
+	DeletedAt      time.Time    `json:"deleted_at"`
 }
 
 var ChatMessageDocumentMappings = esdsl.NewTypeMapping().
 	AddProperty("id", esdsl.NewKeywordProperty()).
 	AddProperty("conversation_id", esdsl.NewKeywordProperty()).
 	AddProperty("from_user_id", esdsl.NewKeywordProperty()).
-	AddProperty("content", esdsl.NewKeywordProperty()).
+	AddProperty("content", newTextProperty()).
 	AddProperty("sent_time", esdsl.NewLongNumberProperty()).
-	AddProperty("created_at", esdsl.NewLongNumberProperty())
+	AddProperty("created_at", esdsl.NewLongNumberProperty()).
+	AddProperty("edited_at", esdsl.NewLongNumberProperty()).
+	AddProperty("deleted_at", esdsl.NewLongNumberProperty()).
+	AddProperty("hidden_for", esdsl.NewKeywordProperty()).
+	AddProperty("reply_to_message_id", esdsl.NewKeywordProperty()).
+	AddProperty("thread_root_id", esdsl.NewKeywordProperty()).
+	AddProperty("sequence", esdsl.NewLongNumberProperty())
 
 var ChatMessageRepository = struct {
-	recipes.CRUD
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.ChatMessageTableName,
+		[]string{"id"},
+		nil,
+		ChatMessageEntity{},
+	)),
+}
+
+var ChatMessageHistoryRepository = struct {
+	consistentTable
 }{
-	recipes.CRUD{
-		TableInterface: gocqltable.NewKeyspace(consts.KeySpace).NewTable(
-			consts.ChatMessageTableName,
-			[]string{"id"},
-			nil,
-			ChatMessageEntity{},
-		),
-	},
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.ChatMessageHistoryTableName,
+		[]string{"message_id"},
+		[]string{"replaced_at"},
+		ChatMessageHistoryEntity{},
+	)),
+}
+
+var HiddenChatMessageRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.HiddenChatMessageTableName,
+		[]string{"user_id"},
+		[]string{"message_id"},
+		HiddenChatMessageEntity{},
+	)),
+}
+
+// InsertChatMessageIfNotExists stores the message with a lightweight transaction so that
+// a redelivered pending message is persisted once, it reports whether the row was created
+func InsertChatMessageIfNotExists(entity ChatMessageEntity) (bool, error) {
+	table := ChatMessageRepository.TableInterface
+	statement := fmt.Sprintf(
+		`INSERT INTO %q.%q (id, conversation_id, from_user_id, content, sent_time, created_at, reply_to_message_id, thread_root_id, sequence) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) IF NOT EXISTS`,
+		table.Keyspace().Name(), table.Name(),
+	)
+	query := table.Query(statement,
+		entity.Id, entity.ConversationId, entity.FromUserId, entity.Content,
+		entity.SentTime, entity.CreatedAt, entity.ReplyToMessageId, entity.ThreadRootId, entity.Sequence,
+	)
+	return query.Session.Query(query.Statement, query.Values...).MapScanCAS(map[string]interface{}{})
 }
 
 func NewChatMessageEntity(data KafkaPendingMessage) ChatMessageEntity {
 	return ChatMessageEntity{
-		Id:             gocql.MustRandomUUID(),
-		ConversationId: data.ConversationId,
-		FromUserId:     data.FromUserId,
-		Content:        data.Content,
-		SentTime:       data.SentTime,
-		CreatedAt:      time.Now(),
+		Id:               data.MessageId,
+		ConversationId:   data.ConversationId,
+		FromUserId:       data.FromUserId,
+		Content:          data.Content,
+		SentTime:         data.SentTime,
+		CreatedAt:        time.Now(),
+		ReplyToMessageId: data.ReplyToMessageId,
+		ThreadRootId:     data.ThreadRootId,
+	}
+}
+
+func NewSentChatMessageEntity(data KafkaSentMessage) ChatMessageEntity {
+	return ChatMessageEntity{
+		Id:               data.Id,
+		ConversationId:   data.ConversationId,
+		FromUserId:       data.FromUserId,
+		Content:          data.Content,
+		SentTime:         data.SentTime,
+		CreatedAt:        data.CreatedAt,
+		ReplyToMessageId: data.ReplyToMessageId,
+		ThreadRootId:     data.ThreadRootId,
+		Sequence:         data.Sequence,
 	}
 }
 
 func NewChatMessageDoc(entity ChatMessageEntity) ChatMessageDocument {
-	return ChatMessageDocument{
+	doc := ChatMessageDocument{
 		Id:             entity.Id,
 		ConversationId: entity.ConversationId,
 		FromUserId:     entity.FromUserId,
 		Content:        entity.Content,
 		SentTime:       int(entity.SentTime.UnixMilli()),
 		CreatedAt:      int(entity.CreatedAt.UnixMilli()),
+		Sequence:       entity.Sequence,
+	}
+	if !entity.EditedAt.IsZero() {
+		doc.EditedAt = int(entity.EditedAt.UnixMilli())
 	}
+	if !entity.DeletedAt.IsZero() {
+		doc.DeletedAt = int(entity.DeletedAt.UnixMilli())
+	}
+	if entity.ReplyToMessageId != (gocql.UUID{}) {
+		doc.ReplyToMessageId = entity.ReplyToMessageId.String()
+	}
+	if entity.ThreadRootId != (gocql.UUID{}) {
+		doc.ThreadRootId = entity.ThreadRootId.String()
+	}
+	return doc
 }
 
 func NewChatMessagePb(entity ChatMessageEntity) pb.ChatMessage {
 	return pb.ChatMessage{
-		Id:             entity.Id.String(),
-		ConversationId: entity.ConversationId.String(),
-		FromUserId:     entity.FromUserId.String(),
-		Content:        entity.Content,
-		SentTime:       timestamppb.New(entity.SentTime),
-		CreateTime:     timestamppb.New(entity.CreatedAt),
+		Id:               entity.Id.String(),
+		ConversationId:   entity.ConversationId.String(),
+		FromUserId:       entity.FromUserId.String(),
+		Content:          entity.Content,
+		SentTime:         timestamppb.New(entity.SentTime),
+		CreateTime:       timestamppb.New(entity.CreatedAt),
+		EditedAt:         newOptionalTimestampPb(entity.EditedAt),
+		DeletedAt:        newOptionalTimestampPb(entity.DeletedAt),
+		ReplyToMessageId: newOptionalUUIDPb(entity.ReplyToMessageId),
+		ThreadRootId:     newOptionalUUIDPb(entity.ThreadRootId),
+		Sequence:         entity.Sequence,
+	}
+}
+
+// newOptionalTimestampPb leaves unset timestamps empty instead of the zero time
+func newOptionalTimestampPb(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func newOptionalUUIDPb(id gocql.UUID) *string {
+	if id == (gocql.UUID{}) {
+		return nil
 	}
+	value := id.String()
+	return &value
 }
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type DeliveryStatus int
+
+const (
+	MessagePending   DeliveryStatus = 0
+	MessagePersisted DeliveryStatus = 1
+	MessageDelivered DeliveryStatus = 2
+	MessageFailed    DeliveryStatus = 3
+)
+
+// FailureReason is the code shown to the sender of a failed message, the error behind it is only logged
+type FailureReason string
+
+const (
+	NoFailure FailureReason = ""
+	// FailureEnqueueFailed is a message that never reached the pending queue
+	FailureEnqueueFailed FailureReason = "ENQUEUE_FAILED"
+	// FailureRejected is a message that can never be stored, e.g. a malformed record
+	FailureRejected FailureReason = "REJECTED"
+	// FailureRetriesExhausted is a message that kept failing to be stored
+	FailureRetriesExhausted FailureReason = "RETRIES_EXHAUSTED"
+)
+
+// Statuses are only needed while clients show send states, rows expire afterwards
+const messageStatusRetention = 7 * 24 * time.Hour
+
+// MessageStatusEntity tracks a sent message through the pending queue, keyed by the id returned in the ack
+type MessageStatusEntity struct {
+	MessageId      gocql.UUID `cql:"message_id"`
+	ConversationId gocql.UUID `cql:"conversation_id"`
+	FromUserId     gocql.UUID `cql:"from_user_id"`
+	Status         int        `cql:"status"`
+	FailureReason  string     `cql:"failure_reason"`
+	UpdatedAt      time.Time  `cql:"updated_at"`
+}
+
+type KafkaFailedMessage struct {
+	MessageId      gocql.UUID    `json:"message_id"`
+	ConversationId gocql.UUID    `json:"conversation_id"`
+	FromUserId     gocql.UUID    `json:"from_user_id"`
+	Reason         FailureReason `json:"reason"`
+	// The sequence number the message took before failing, it stays a gap in the conversation
+	Sequence int64     `json:"sequence,omitempty"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+var MessageStatusRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.MessageStatusTableName,
+		[]string{"message_id"},
+		nil,
+		MessageStatusEntity{},
+	)),
+}
+
+// SaveMessageStatus records the stage reached by the message
+func SaveMessageStatus(messageId gocql.UUID, conversationId gocql.UUID, fromUserId gocql.UUID, status DeliveryStatus, failureReason FailureReason) error {
+	entity := MessageStatusEntity{
+		MessageId:      messageId,
+		ConversationId: conversationId,
+		FromUserId:     fromUserId,
+		Status:         int(status),
+		FailureReason:  string(failureReason),
+		UpdatedAt:      time.Now(),
+	}
+	expiresAt := entity.UpdatedAt.Add(messageStatusRetention)
+	return MessageStatusRepository.InsertWithTTL(entity, &expiresAt)
+}
+
+func NewMessageStatusPb(entity MessageStatusEntity) pb.MessageStatus {
+	return pb.MessageStatus{
+		MessageId:      entity.MessageId.String(),
+		ConversationId: entity.ConversationId.String(),
+		Status:         pb.MessageDeliveryStatus(entity.Status),
+		FailureReason:  newOptionalStringPb(entity.FailureReason),
+		UpdatedAt:      timestamppb.New(entity.UpdatedAt),
+	}
+}
+
+func newOptionalStringPb(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/kristoiv/gocqltable"
+)
+
+// SchemaMigrationEntity records a migration applied to the keyspace and indices
+type SchemaMigrationEntity struct {
+	Version   int       `cql:"version"`
+	Name      string    `cql:"name"`
+	AppliedAt time.Time `cql:"applied_at"`
+}
+
+// SchemaMigrationLockEntity lets a single instance migrate at a time until the row expires
+type SchemaMigrationLockEntity struct {
+	Name  string `cql:"name"`
+	Owner string `cql:"owner"`
+}
+
+var SchemaMigrationRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.SchemaMigrationTableName,
+		[]string{"version"},
+		nil,
+		SchemaMigrationEntity{},
+	)),
+}
+
+var SchemaMigrationLockRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.SchemaMigrationLockTableName,
+		[]string{"name"},
+		nil,
+		SchemaMigrationLockEntity{},
+	)),
+}
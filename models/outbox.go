@@ -0,0 +1,355 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+	r "github.com/kristoiv/gocqltable/reflect"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+type OutboxKind string
+
+const (
+	// OutboxIndexDocument and OutboxUpdateDocument are only left in the legacy outbox table, documents are synced now
+	OutboxIndexDocument  OutboxKind = "ES_INDEX"
+	OutboxUpdateDocument OutboxKind = "ES_UPDATE"
+	// OutboxSyncDocument rebuilds the document from the row as it is when relayed, a late or repeated entry
+	// never writes older data than the row holds
+	OutboxSyncDocument OutboxKind = "ES_SYNC"
+	// OutboxHideDocument adds the user in the payload to the users the message is hidden for
+	OutboxHideDocument  OutboxKind = "ES_HIDE"
+	OutboxPublishRecord OutboxKind = "KAFKA"
+)
+
+// OutboxSync names the row a sync entry rebuilds its document from, the ids the index does not use are zero
+type OutboxSync struct {
+	ConversationId gocql.UUID `json:"conversation_id"`
+	UserId         gocql.UUID `json:"user_id"`
+	MessageId      gocql.UUID `json:"message_id"`
+}
+
+// Entries of one aggregate share a shard and are relayed in creation order, changing the count reshuffles ordering
+const OutboxShardCount = 16
+
+// Entries are kept after they are relayed, they expire instead of being deleted so the partitions read by the relay
+// hold no tombstones. The retention also bounds how far back a relay without a cursor starts.
+const OutboxRetention = 7 * 24 * time.Hour
+
+// OutboxEntity is a pending projection of a Cassandra write into Elasticsearch or Kafka.
+// The partitions of a shard are hourly buckets, the relay reads them forward from its cursor.
+type OutboxEntity struct {
+	Shard    int        `cql:"shard"`
+	Bucket   int        `cql:"bucket"`
+	EntryId  gocql.UUID `cql:"entry_id"` // time based, orders the entries of a bucket
+	Kind     string     `cql:"kind"`
+	Target   string     `cql:"target"` // index or topic
+	Key      string     `cql:"key"`    // document id or record key
+	Payload  string     `cql:"payload"`
+	Attempts int        `cql:"attempts"`
+	Relayed  bool       `cql:"relayed"`
+}
+
+// LegacyOutboxEntity is an entry of the outbox table partitioned by shard only, the relay drains it and deletes
+// its entries so the entries written by earlier releases are not lost
+type LegacyOutboxEntity struct {
+	Shard     int       `cql:"shard"`
+	CreatedAt time.Time `cql:"created_at"`
+	EntryId   string    `cql:"entry_id"`
+	Kind      string    `cql:"kind"`
+	Target    string    `cql:"target"`
+	Key       string    `cql:"key"`
+	Payload   string    `cql:"payload"`
+	Attempts  int       `cql:"attempts"`
+}
+
+// OutboxCursorEntity is the last entry relayed from a shard and the last bucket swept for entries written behind it
+type OutboxCursorEntity struct {
+	Shard   int        `cql:"shard"`
+	Bucket  int        `cql:"bucket"`
+	EntryId gocql.UUID `cql:"entry_id"`
+	Swept   int        `cql:"swept"`
+}
+
+// OutboxDeadEntity is an entry parked after failing OutboxMaxAttempts times, the relay moves past it and an operator
+// replays or drops it. Entries of the legacy table keep their text id.
+type OutboxDeadEntity struct {
+	Shard    int       `cql:"shard"`
+	EntryId  string    `cql:"entry_id"`
+	Kind     string    `cql:"kind"`
+	Target   string    `cql:"target"`
+	Key      string    `cql:"key"`
+	Payload  string    `cql:"payload"`
+	Attempts int       `cql:"attempts"`
+	Error    string    `cql:"error"`
+	ParkedAt time.Time `cql:"parked_at"`
+}
+
+// OutboxLeaseEntity grants one relay instance a shard until the row expires
+type OutboxLeaseEntity struct {
+	Shard int    `cql:"shard"`
+	Owner string `cql:"owner"`
+}
+
+var OutboxRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.OutboxEntryTableName,
+		[]string{"shard", "bucket"},
+		[]string{"entry_id"},
+		OutboxEntity{},
+	)),
+}
+
+var LegacyOutboxRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.OutboxTableName,
+		[]string{"shard"},
+		[]string{"created_at", "entry_id"},
+		LegacyOutboxEntity{},
+	)),
+}
+
+var OutboxCursorRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.OutboxCursorTableName,
+		[]string{"shard"},
+		nil,
+		OutboxCursorEntity{},
+	)),
+}
+
+var OutboxDeadEntryRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.OutboxDeadEntryTableName,
+		[]string{"shard"},
+		[]string{"entry_id"},
+		OutboxDeadEntity{},
+	)),
+}
+
+var OutboxLeaseRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.OutboxLeaseTableName,
+		[]string{"shard"},
+		nil,
+		OutboxLeaseEntity{},
+	)),
+}
+
+const defaultOutboxMaxAttempts = 10
+
+// OutboxMaxAttempts is how many times an entry is relayed before it is parked, configured by outbox.max-attempts
+func OutboxMaxAttempts() int {
+	attempts, err := helper.ReadConfig[int]("outbox.max-attempts")
+	if err != nil || attempts <= 0 {
+		return defaultOutboxMaxAttempts
+	}
+	return attempts
+}
+
+// OutboxBucket is the hour the entries written at t are stored under
+func OutboxBucket(t time.Time) int {
+	return int(t.Unix() / int64(time.Hour/time.Second))
+}
+
+// OutboxBucketStart is the first instant of the bucket
+func OutboxBucketStart(bucket int) time.Time {
+	return time.Unix(int64(bucket)*int64(time.Hour/time.Second), 0)
+}
+
+// ListOutboxEntries pages the entries of a bucket written after the entry id and before the time, oldest first
+func ListOutboxEntries(shard int, bucket int, after gocql.UUID, before time.Time, limit int) ([]OutboxEntity, error) {
+	table := OutboxRepository.TableInterface
+	iter := Select(table, fmt.Sprintf(`SELECT * FROM %q.%q WHERE shard = ? AND bucket = ? AND entry_id > ? AND entry_id < ? ORDER BY entry_id ASC LIMIT ?`,
+		table.Keyspace().Name(), table.Name()), shard, bucket, after, gocql.MinTimeUUID(before), limit)
+
+	entries := []OutboxEntity{}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		entries = append(entries, *row.(*OutboxEntity))
+	}
+	return entries, iter.Close()
+}
+
+// UpdateOutboxEntry writes the relay state of the entry, its payload is never rewritten
+func UpdateOutboxEntry(entry OutboxEntity) error {
+	table := OutboxRepository.TableInterface
+	return table.Query(fmt.Sprintf(`UPDATE %q.%q SET attempts = ?, relayed = ? WHERE shard = ? AND bucket = ? AND entry_id = ?`,
+		table.Keyspace().Name(), table.Name()), entry.Attempts, entry.Relayed, entry.Shard, entry.Bucket, entry.EntryId).Exec()
+}
+
+// MarkOutboxEntryRelayed flags an entry its writer projected itself, the failures counted by the relay are kept
+func MarkOutboxEntryRelayed(entry OutboxEntity) error {
+	table := OutboxRepository.TableInterface
+	return table.Query(fmt.Sprintf(`UPDATE %q.%q SET relayed = true WHERE shard = ? AND bucket = ? AND entry_id = ?`,
+		table.Keyspace().Name(), table.Name()), entry.Shard, entry.Bucket, entry.EntryId).Exec()
+}
+
+// TableRow is a row written in the same batch as its outbox entries. The row is inserted unless Columns limits the
+// write to those columns or Delete removes it, the keys of the row locate it either way.
+type TableRow struct {
+	Table   gocqltable.TableInterface
+	Row     interface{}
+	Columns []string
+	Delete  bool
+	// DeletePartition removes every row sharing the partition key of the row
+	DeletePartition bool
+}
+
+func outboxShard(aggregateId gocql.UUID) int {
+	hash := fnv.New32a()
+	hash.Write(aggregateId[:])
+	return int(hash.Sum32() % OutboxShardCount)
+}
+
+// NewOutboxEntry builds an entry of the aggregate, entries are ordered by the time they are built
+func NewOutboxEntry(kind OutboxKind, aggregateId gocql.UUID, target string, key string, payload interface{}) (OutboxEntity, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxEntity{}, err
+	}
+
+	entryId := gocql.TimeUUID()
+	return OutboxEntity{
+		Shard:   outboxShard(aggregateId),
+		Bucket:  OutboxBucket(entryId.Time()),
+		EntryId: entryId,
+		Kind:    string(kind),
+		Target:  target,
+		Key:     key,
+		Payload: string(raw),
+	}, nil
+}
+
+// NewMessageSyncEntry syncs the document of the message
+func NewMessageSyncEntry(entity ChatMessageEntity) (OutboxEntity, error) {
+	return NewOutboxEntry(OutboxSyncDocument, entity.ConversationId, consts.ChatMessageIndex, entity.Id.String(),
+		OutboxSync{ConversationId: entity.ConversationId, MessageId: entity.Id})
+}
+
+// NewConversationSyncEntry syncs the document of the conversation, its member ids are synced with the participants
+func NewConversationSyncEntry(conversationId gocql.UUID) (OutboxEntity, error) {
+	return NewOutboxEntry(OutboxSyncDocument, conversationId, consts.ConversationIndex, conversationId.String(),
+		OutboxSync{ConversationId: conversationId})
+}
+
+// NewParticipantSyncEntry syncs the document of the participant and its place in the member ids of the conversation
+func NewParticipantSyncEntry(conversationId gocql.UUID, userId gocql.UUID) (OutboxEntity, error) {
+	return NewOutboxEntry(OutboxSyncDocument, conversationId, consts.ParticipantIndex, NewParticipantDocId(conversationId, userId),
+		OutboxSync{ConversationId: conversationId, UserId: userId})
+}
+
+// NewHideEntry hides the message document from the user
+func NewHideEntry(hidden HiddenChatMessageEntity) (OutboxEntity, error) {
+	return NewOutboxEntry(OutboxHideDocument, hidden.ConversationId, consts.ChatMessageIndex, hidden.MessageId.String(), hidden.UserId)
+}
+
+// NewPublishEntry publishes the record keyed by its conversation, the records of a conversation keep their order
+func NewPublishEntry(conversationId gocql.UUID, topic string, data interface{}) (OutboxEntity, error) {
+	return NewOutboxEntry(OutboxPublishRecord, conversationId, topic, conversationId.String(), data)
+}
+
+func newInsertStatement(table gocqltable.TableInterface, row interface{}) (string, []interface{}) {
+	fields, values, ok := r.FieldsAndValues(row)
+	if !ok {
+		panic("Unable to get fields from struct during insert")
+	}
+
+	columns := make([]string, len(fields))
+	placeholders := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = strings.ToLower(fmt.Sprintf("%q", field))
+		placeholders[i] = "?"
+	}
+
+	statement := fmt.Sprintf(`INSERT INTO %q.%q (%s) VALUES (%s)`,
+		table.Keyspace().Name(), table.Name(), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	return statement, values
+}
+
+// newKeyConditions matches the row by its primary key, or by its partition key only
+func newKeyConditions(table gocqltable.TableInterface, columns map[string]interface{}, partition bool) (string, []interface{}) {
+	keys := table.RowKeys()
+	if !partition {
+		keys = append(slices.Clone(keys), table.RangeKeys()...)
+	}
+
+	conditions := make([]string, len(keys))
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		conditions[i] = fmt.Sprintf("%q = ?", key)
+		values[i] = columns[key]
+	}
+	return strings.Join(conditions, " AND "), values
+}
+
+func newUpdateStatement(table gocqltable.TableInterface, row interface{}, columns []string) (string, []interface{}) {
+	fields, ok := r.StructToMap(row)
+	if !ok {
+		panic("Unable to get fields from struct during update")
+	}
+
+	assignments := make([]string, len(columns))
+	values := make([]interface{}, 0, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%q = ?", column)
+		values = append(values, fields[column])
+	}
+	conditions, keyValues := newKeyConditions(table, fields, false)
+
+	statement := fmt.Sprintf(`UPDATE %q.%q SET %s WHERE %s`,
+		table.Keyspace().Name(), table.Name(), strings.Join(assignments, ", "), conditions)
+	return statement, append(values, keyValues...)
+}
+
+func newDeleteStatement(table gocqltable.TableInterface, row interface{}, partition bool) (string, []interface{}) {
+	fields, ok := r.StructToMap(row)
+	if !ok {
+		panic("Unable to get fields from struct during delete")
+	}
+
+	conditions, values := newKeyConditions(table, fields, partition)
+	return fmt.Sprintf(`DELETE FROM %q.%q WHERE %s`, table.Keyspace().Name(), table.Name(), conditions), values
+}
+
+// SaveWithOutbox writes the rows and the outbox entries in one logged batch, either all of them are stored or none
+func SaveWithOutbox(rows []TableRow, entries ...OutboxEntity) error {
+	session := Session()
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	for _, row := range rows {
+		var statement string
+		var values []interface{}
+		switch {
+		case row.Delete || row.DeletePartition:
+			statement, values = newDeleteStatement(row.Table, row.Row, row.DeletePartition)
+		case len(row.Columns) > 0:
+			statement, values = newUpdateStatement(row.Table, row.Row, row.Columns)
+		default:
+			statement, values = newInsertStatement(row.Table, row.Row)
+		}
+		batch.Query(statement, values...)
+	}
+	for _, entry := range entries {
+		statement, values := newInsertStatement(OutboxRepository.TableInterface, entry)
+		batch.Query(statement, values...)
+	}
+
+	return session.ExecuteBatch(batch)
+}
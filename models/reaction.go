@@ -0,0 +1,71 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+)
+
+type ReactionAction string
+
+const (
+	ReactionAdded   ReactionAction = "ADDED"
+	ReactionRemoved ReactionAction = "REMOVED"
+)
+
+type MessageReactionEntity struct {
+	MessageId gocql.UUID `cql:"message_id"`
+	Emoji     string     `cql:"emoji"`
+	UserId    gocql.UUID `cql:"user_id"`
+	CreatedAt time.Time  `cql:"created_at"`
+}
+
+type KafkaMessageReaction struct {
+	MessageId      gocql.UUID     `json:"message_id"`
+	ConversationId gocql.UUID     `json:"conversation_id"`
+	UserId         gocql.UUID     `json:"user_id"`
+	Emoji          string         `json:"emoji"`
+	Action         ReactionAction `json:"action"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+var MessageReactionRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.MessageReactionTableName,
+		[]string{"message_id"},
+		[]string{"emoji", "user_id"},
+		MessageReactionEntity{},
+	)),
+}
+
+// NewReactionSummariesPb aggregates the reactions of a message per emoji, ordered by first use
+func NewReactionSummariesPb(reactions []*MessageReactionEntity, viewerId gocql.UUID) []*pb.ReactionSummary {
+	sorted := make([]*MessageReactionEntity, len(reactions))
+	copy(sorted, reactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	summaries := []*pb.ReactionSummary{}
+	byEmoji := map[string]*pb.ReactionSummary{}
+	for _, reaction := range sorted {
+		summary, ok := byEmoji[reaction.Emoji]
+		if !ok {
+			summary = &pb.ReactionSummary{Emoji: reaction.Emoji}
+			byEmoji[reaction.Emoji] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Count++
+		if reaction.UserId == viewerId {
+			summary.ReactedByMe = true
+		}
+	}
+
+	return summaries
+}
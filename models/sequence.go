@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+)
+
+const maxSequenceAttempts = 10
+
+// A reservation is only read until the message row holds its number, a redelivery comes long before it expires
+const messageSequenceRetention = 7 * 24 * time.Hour
+
+// ConversationSequenceEntity holds the last sequence number given out in a conversation
+// and the message it was given to, so a retried message gets its number back
+type ConversationSequenceEntity struct {
+	ConversationId gocql.UUID `cql:"conversation_id"`
+	LastSequence   int64      `cql:"last_sequence"`
+	LastMessageId  gocql.UUID `cql:"last_message_id"`
+}
+
+var ConversationSequenceRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.ConversationSequenceTableName,
+		[]string{"conversation_id"},
+		nil,
+		ConversationSequenceEntity{},
+	)),
+}
+
+// MessageSequenceEntity is the number reserved for a message, a redelivered message gets it back
+// even when later messages took numbers in between
+type MessageSequenceEntity struct {
+	MessageId      gocql.UUID `cql:"message_id"`
+	ConversationId gocql.UUID `cql:"conversation_id"`
+	Sequence       int64      `cql:"sequence"`
+}
+
+var MessageSequenceRepository = struct {
+	consistentTable
+}{
+	consistent(gocqltable.NewKeyspace(consts.KeySpace).NewTable(
+		consts.MessageSequenceTableName,
+		[]string{"message_id"},
+		nil,
+		MessageSequenceEntity{},
+	)),
+}
+
+// GetMessageSequence returns the number reserved for the message, gocql.ErrNotFound when it has none
+func GetMessageSequence(messageId gocql.UUID) (int64, error) {
+	reservation, err := MessageSequenceRepository.Get(messageId)
+	if err != nil {
+		return 0, err
+	}
+	return reservation.(*MessageSequenceEntity).Sequence, nil
+}
+
+func reserveMessageSequence(conversationId gocql.UUID, messageId gocql.UUID, sequence int64) error {
+	table := MessageSequenceRepository.TableInterface
+	statement := fmt.Sprintf(
+		`INSERT INTO %q.%q (message_id, conversation_id, sequence) VALUES (?, ?, ?) USING TTL ?`,
+		table.Keyspace().Name(), table.Name(),
+	)
+	return table.Query(statement, messageId, conversationId, sequence, int(messageSequenceRetention.Seconds())).Exec()
+}
+
+// NextConversationSequence gives the message the next sequence number of its conversation, or the number it was
+// given before. Lightweight transactions keep the numbers unique when several consumers race.
+// A message dropped after getting its number leaves a gap, the failed message event carries the skipped number.
+func NextConversationSequence(conversationId gocql.UUID, messageId gocql.UUID) (int64, error) {
+	table := ConversationSequenceRepository.TableInterface
+	insertStatement := fmt.Sprintf(
+		`INSERT INTO %q.%q (conversation_id, last_sequence, last_message_id) VALUES (?, ?, ?) IF NOT EXISTS`,
+		table.Keyspace().Name(), table.Name(),
+	)
+	updateStatement := fmt.Sprintf(
+		`UPDATE %q.%q SET last_sequence = ?, last_message_id = ? WHERE conversation_id = ? IF last_sequence = ?`,
+		table.Keyspace().Name(), table.Name(),
+	)
+
+	if reserved, err := GetMessageSequence(messageId); err == nil {
+		return reserved, nil
+	} else if err != gocql.ErrNotFound {
+		return 0, err
+	}
+
+	for attempt := 0; attempt < maxSequenceAttempts; attempt++ {
+		current, err := ConversationSequenceRepository.Get(conversationId)
+		if err == gocql.ErrNotFound {
+			query := table.Query(insertStatement, conversationId, int64(1), messageId)
+			applied, err := query.Session.Query(query.Statement, query.Values...).MapScanCAS(map[string]interface{}{})
+			if err != nil {
+				return 0, err
+			}
+			if applied {
+				return 1, reserveMessageSequence(conversationId, messageId, 1)
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		sequence := current.(*ConversationSequenceEntity)
+		if sequence.LastMessageId == messageId {
+			return sequence.LastSequence, reserveMessageSequence(conversationId, messageId, sequence.LastSequence)
+		}
+
+		next := sequence.LastSequence + 1
+		query := table.Query(updateStatement, next, messageId, conversationId, sequence.LastSequence)
+		applied, err := query.Session.Query(query.Statement, query.Values...).MapScanCAS(map[string]interface{}{})
+		if err != nil {
+			return 0, err
+		}
+		if applied {
+			return next, reserveMessageSequence(conversationId, messageId, next)
+		}
+	}
+
+	return 0, fmt.Errorf("too much contention on the sequence of conversation %s", conversationId)
+}
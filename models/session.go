@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+	"github.com/kristoiv/gocqltable/recipes"
+
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+// Every statement runs on one session, its default consistency is the write level so writes, batches and
+// lightweight transactions keep it, reads set the read level on the query
+var (
+	session         *gocql.Session
+	readConsistency = gocql.Quorum
+)
+
+// SetSession wires the session and the consistency levels, the session is also the default session of gocqltable
+func SetSession(s *gocql.Session, read gocql.Consistency, write gocql.Consistency) {
+	s.SetConsistency(write)
+	session = s
+	readConsistency = read
+	gocqltable.SetDefaultSession(s)
+}
+
+// Session runs statements that change data at the write consistency, lightweight transactions included
+func Session() *gocql.Session {
+	return session
+}
+
+// readQuery runs a SELECT at the read consistency
+func readQuery(statement string, values ...interface{}) *gocql.Query {
+	return session.Query(statement, values...).Consistency(readConsistency)
+}
+
+// Select runs a SELECT on the table at the read consistency, the rows are scanned into the row type of the table
+func Select(table gocqltable.TableInterface, statement string, values ...interface{}) *Iterator {
+	return &Iterator{
+		iter: readQuery(statement, values...).Iter(),
+		row:  table.Row(),
+	}
+}
+
+// Iterator scans the rows like the iterator of gocqltable, Next returns nil after the last row
+type Iterator struct {
+	iter *gocql.Iter
+	row  interface{}
+}
+
+func (i *Iterator) Next() interface{} {
+	m := map[string]interface{}{}
+	if !i.iter.MapScan(m) {
+		return nil
+	}
+	v := reflect.New(reflect.TypeOf(i.row))
+	r.MapToStruct(m, v.Interface())
+	r.MapToStruct(ucfirstKeys(m), v.Interface())
+	return v.Interface()
+}
+
+func (i *Iterator) Close() error {
+	return i.iter.Close()
+}
+
+func ucfirstKeys(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if key == "" {
+			continue
+		}
+		result[strings.ToUpper(key[:1])+key[1:]] = value
+	}
+	return result
+}
+
+// consistentTable reads at the read consistency, the writes of recipes.CRUD keep the write level of the session
+type consistentTable struct {
+	recipes.CRUD
+}
+
+func consistent(table gocqltable.Table) consistentTable {
+	return consistentTable{CRUD: recipes.CRUD{TableInterface: table}}
+}
+
+// keyConditions matches the first len(ids) row and range keys
+func (t consistentTable) keyConditions(ids []interface{}) []string {
+	conditions := []string{}
+	for _, key := range append(t.RowKeys(), t.RangeKeys()...) {
+		if len(conditions) == len(ids) {
+			break
+		}
+		conditions = append(conditions, fmt.Sprintf("%q = ?", strings.ToLower(key)))
+	}
+	return conditions
+}
+
+func (t consistentTable) Get(ids ...interface{}) (interface{}, error) {
+	keys := len(t.RowKeys()) + len(t.RangeKeys())
+	if len(ids) < keys {
+		return nil, fmt.Errorf("too few key values to get the row (%d of %d)", len(ids), keys)
+	}
+	iter := Select(t.TableInterface, fmt.Sprintf(`SELECT * FROM %q.%q WHERE %s LIMIT 1`,
+		t.Keyspace().Name(), t.Name(), strings.Join(t.keyConditions(ids), " AND ")), ids[:keys]...)
+	row := iter.Next()
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return nil, gocql.ErrNotFound
+	}
+	return row, nil
+}
+
+func (t consistentTable) List(ids ...interface{}) (interface{}, error) {
+	return t.Range(ids...).Fetch()
+}
+
+// Range selects the rows matching the leading keys
+func (t consistentTable) Range(ids ...interface{}) rangeQuery {
+	conditions := t.keyConditions(ids)
+	return rangeQuery{table: t, conditions: conditions, values: ids[:len(conditions)]}
+}
+
+type rangeQuery struct {
+	table      consistentTable
+	conditions []string
+	values     []interface{}
+	limit      int
+}
+
+func (q rangeQuery) Limit(limit int) rangeQuery {
+	q.limit = limit
+	return q
+}
+
+// Fetch returns a slice of pointers to the row type of the table
+func (q rangeQuery) Fetch() (interface{}, error) {
+	statement := fmt.Sprintf(`SELECT * FROM %q.%q`, q.table.Keyspace().Name(), q.table.Name())
+	if len(q.conditions) > 0 {
+		statement += " WHERE " + strings.Join(q.conditions, " AND ")
+	}
+	if q.limit > 0 {
+		statement += " LIMIT " + strconv.Itoa(q.limit)
+	}
+
+	result := reflect.Zero(reflect.SliceOf(reflect.PointerTo(reflect.TypeOf(q.table.Row()))))
+	iter := Select(q.table.TableInterface, statement, q.values...)
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		result = reflect.Append(result, reflect.ValueOf(row))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return result.Interface(), nil
+}
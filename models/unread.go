@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/gocql/gocql"
+)
+
+// unreadCountTable keeps a counter per member, counter columns cannot go through recipes.CRUD
+func unreadCountTable() string {
+	return fmt.Sprintf("%q.%q", consts.KeySpace, consts.UnreadCountTableName)
+}
+
+// IncrementUnreadCounts adds one unread message to the counters of the members in one counter batch
+func IncrementUnreadCounts(conversationId gocql.UUID, userIds []gocql.UUID) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	session := Session()
+	batch := session.NewBatch(gocql.CounterBatch)
+	statement := fmt.Sprintf(`UPDATE %s SET unread = unread + 1 WHERE conversation_id = ? AND user_id = ?`, unreadCountTable())
+	for _, userId := range userIds {
+		batch.Query(statement, conversationId, userId)
+	}
+	return session.ExecuteBatch(batch)
+}
+
+// GetUnreadCount fails with gocql.ErrNotFound when nothing was counted for the member
+func GetUnreadCount(conversationId gocql.UUID, userId gocql.UUID) (int64, error) {
+	var unread int64
+	statement := fmt.Sprintf(`SELECT unread FROM %s WHERE conversation_id = ? AND user_id = ?`, unreadCountTable())
+	if err := readQuery(statement, conversationId, userId).Scan(&unread); err != nil {
+		return 0, err
+	}
+	return unread, nil
+}
+
+// SetUnreadCount moves the counter of the member to count, counters only add so the difference to the current
+// value is added. The current value is read at the write consistency so it holds every acknowledged increment.
+// This is not atomic, a message counted after the caller read the history and before this read is taken out again
+// until the next recount.
+func SetUnreadCount(conversationId gocql.UUID, userId gocql.UUID, count int64) error {
+	var current int64
+	statement := fmt.Sprintf(`SELECT unread FROM %s WHERE conversation_id = ? AND user_id = ?`, unreadCountTable())
+	if err := Session().Query(statement, conversationId, userId).Scan(&current); err != nil && err != gocql.ErrNotFound {
+		return err
+	}
+	if current == count {
+		return nil
+	}
+	// A retried counter update would add the difference twice
+	statement = fmt.Sprintf(`UPDATE %s SET unread = unread + ? WHERE conversation_id = ? AND user_id = ?`, unreadCountTable())
+	return Session().Query(statement, count-current, conversationId, userId).Idempotent(false).Exec()
+}
@@ -0,0 +1,315 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/TripConnect/chat-service/kafka/producers"
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/tripconnect/go-common-utils/common"
+	"github.com/tripconnect/go-common-utils/helper"
+)
+
+const (
+	defaultPollInterval = time.Second
+	leaseTTL            = 30 * time.Second
+	relayBatchSize      = 100
+	settleDelay         = time.Second
+	// Writes taking longer are assumed failed, their callers retry them
+	sweepDelay = time.Minute
+)
+
+// Identifies this instance in the shard leases
+var relayId = uuid.NewString()
+
+// RunRelay projects the outbox entries into Elasticsearch and Kafka until the context is cancelled.
+// Each shard is relayed by a single instance at a time and its entries are projected in order,
+// a failing entry is retried on the next poll and holds back the entries after it until it is parked.
+func RunRelay(ctx context.Context) {
+	pollInterval := defaultPollInterval
+	if interval, err := helper.ReadConfig[int]("outbox.poll-interval-ms"); err == nil && interval > 0 {
+		pollInterval = time.Duration(interval) * time.Millisecond
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for shard := 0; shard < models.OutboxShardCount; shard++ {
+				if acquireLease(shard) {
+					relayShard(ctx, shard)
+				}
+			}
+		}
+	}
+}
+
+// acquireLease takes a free shard or extends the lease this instance already holds
+func acquireLease(shard int) bool {
+	table := models.OutboxLeaseRepository.TableInterface
+	ttl := int(leaseTTL.Seconds())
+
+	insert := table.Query(fmt.Sprintf(`INSERT INTO %q.%q (shard, owner) VALUES (?, ?) IF NOT EXISTS USING TTL ?`,
+		table.Keyspace().Name(), table.Name()), shard, relayId, ttl)
+	applied, err := insert.Session.Query(insert.Statement, insert.Values...).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		log.Printf("Failed to acquire outbox shard %d: %v", shard, err)
+		return false
+	}
+	if applied {
+		return true
+	}
+
+	renew := table.Query(fmt.Sprintf(`UPDATE %q.%q USING TTL ? SET owner = ? WHERE shard = ? IF owner = ?`,
+		table.Keyspace().Name(), table.Name()), ttl, relayId, shard, relayId)
+	applied, err = renew.Session.Query(renew.Statement, renew.Values...).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		log.Printf("Failed to renew outbox shard %d: %v", shard, err)
+		return false
+	}
+	return applied
+}
+
+// legacyDrained remembers the shards with no entries left in the legacy outbox table, only RunRelay touches it
+var legacyDrained [models.OutboxShardCount]bool
+
+// relayShard projects the entries after the cursor of the shard. Entries younger than settleDelay are left for a later
+// poll, a batch still being written or a clock slightly ahead would otherwise land behind the cursor. Finished buckets
+// are swept once more after sweepDelay for the entries that still landed behind it.
+func relayShard(ctx context.Context, shard int) {
+	if !relayLegacyShard(ctx, shard) {
+		return
+	}
+
+	cursor, err := getCursor(shard)
+	if err != nil {
+		log.Printf("Failed to read outbox cursor of shard %d: %v", shard, err)
+		return
+	}
+
+	for cursor.Swept+1 < cursor.Bucket && time.Now().After(models.OutboxBucketStart(cursor.Swept+2).Add(sweepDelay)) {
+		if !sweepBucket(ctx, shard, cursor.Swept+1) {
+			return
+		}
+		cursor.Swept++
+		saveCursor(cursor)
+	}
+
+	for {
+		horizon := time.Now().Add(-settleDelay)
+		entries, err := models.ListOutboxEntries(shard, cursor.Bucket, cursor.EntryId, horizon, relayBatchSize)
+		if err != nil {
+			log.Printf("Failed to read outbox shard %d: %v", shard, err)
+			return
+		}
+
+		relayed := 0
+		for _, entry := range entries {
+			// Records published by their writer right after the batch are only relayed when that failed
+			if !entry.Relayed && !relayEntry(ctx, entry) {
+				break
+			}
+			cursor.EntryId = entry.EntryId
+			relayed++
+		}
+		if relayed > 0 {
+			saveCursor(cursor)
+		}
+		// A failed entry is retried on the next poll, a full page or the current hour is continued there
+		if relayed < len(entries) || len(entries) == relayBatchSize || cursor.Bucket >= models.OutboxBucket(horizon) {
+			return
+		}
+
+		cursor.Bucket++
+		cursor.EntryId = gocql.MinTimeUUID(models.OutboxBucketStart(cursor.Bucket))
+		saveCursor(cursor)
+	}
+}
+
+// getCursor starts a shard without cursor at the oldest bucket the entries can still be in
+func getCursor(shard int) (models.OutboxCursorEntity, error) {
+	row, err := models.OutboxCursorRepository.Get(shard)
+	if err == gocql.ErrNotFound {
+		bucket := models.OutboxBucket(time.Now().Add(-models.OutboxRetention))
+		return models.OutboxCursorEntity{
+			Shard:   shard,
+			Bucket:  bucket,
+			EntryId: gocql.MinTimeUUID(models.OutboxBucketStart(bucket)),
+			Swept:   bucket - 1,
+		}, nil
+	}
+	if err != nil {
+		return models.OutboxCursorEntity{}, err
+	}
+	return *row.(*models.OutboxCursorEntity), nil
+}
+
+// saveCursor failing only makes the next pass relay the same entries again
+func saveCursor(cursor models.OutboxCursorEntity) {
+	if err := models.OutboxCursorRepository.Insert(cursor); err != nil {
+		log.Printf("Failed to save outbox cursor of shard %d: %v", cursor.Shard, err)
+	}
+}
+
+// sweepBucket relays the entries of a finished bucket that were not relayed yet, out of order since later entries
+// were relayed before them
+func sweepBucket(ctx context.Context, shard int, bucket int) bool {
+	after := gocql.MinTimeUUID(models.OutboxBucketStart(bucket))
+	end := models.OutboxBucketStart(bucket + 1)
+	for {
+		entries, err := models.ListOutboxEntries(shard, bucket, after, end, relayBatchSize)
+		if err != nil {
+			log.Printf("Failed to sweep outbox shard %d: %v", shard, err)
+			return false
+		}
+
+		for _, entry := range entries {
+			if entry.Relayed {
+				continue
+			}
+			log.Printf("Relaying outbox entry %s written behind the cursor of shard %d", entry.EntryId, shard)
+			if !relayEntry(ctx, entry) {
+				return false
+			}
+		}
+
+		if len(entries) < relayBatchSize {
+			return true
+		}
+		after = entries[len(entries)-1].EntryId
+	}
+}
+
+// relayEntry projects the entry and marks it relayed, a failure is counted on the entry and holds back the shard.
+// An entry failing models.OutboxMaxAttempts times is parked and marked relayed, the entries after it go on.
+func relayEntry(ctx context.Context, entry models.OutboxEntity) bool {
+	if err := project(ctx, entry.Kind, entry.Target, entry.Key, entry.Payload); err != nil {
+		entry.Attempts++
+		log.Printf("Failed to relay outbox entry %s (attempt %d): %v", entry.EntryId, entry.Attempts, err)
+		if entry.Attempts < models.OutboxMaxAttempts() {
+			if err := models.UpdateOutboxEntry(entry); err != nil {
+				log.Printf("Failed to update outbox entry %s: %v", entry.EntryId, err)
+			}
+			return false
+		}
+		if !parkEntry(models.OutboxDeadEntity{
+			Shard:    entry.Shard,
+			EntryId:  entry.EntryId.String(),
+			Kind:     entry.Kind,
+			Target:   entry.Target,
+			Key:      entry.Key,
+			Payload:  entry.Payload,
+			Attempts: entry.Attempts,
+		}, err) {
+			return false
+		}
+	}
+
+	// Left unmarked, the sweep of the bucket relays the entry again
+	entry.Relayed = true
+	if err := models.UpdateOutboxEntry(entry); err != nil {
+		log.Printf("Failed to update outbox entry %s: %v", entry.EntryId, err)
+	}
+	return true
+}
+
+// relayLegacyShard drains the entries written by releases before the outbox was partitioned by hour,
+// it reports whether none are left
+func relayLegacyShard(ctx context.Context, shard int) bool {
+	if legacyDrained[shard] {
+		return true
+	}
+
+	rows, err := models.LegacyOutboxRepository.Range(shard).Limit(relayBatchSize).Fetch()
+	if err != nil {
+		log.Printf("Failed to read legacy outbox shard %d: %v", shard, err)
+		return false
+	}
+
+	entries := rows.([]*models.LegacyOutboxEntity)
+	for _, entry := range entries {
+		if err := project(ctx, entry.Kind, entry.Target, entry.Key, entry.Payload); err != nil {
+			entry.Attempts++
+			log.Printf("Failed to relay outbox entry %s (attempt %d): %v", entry.EntryId, entry.Attempts, err)
+			if entry.Attempts < models.OutboxMaxAttempts() {
+				if err := models.LegacyOutboxRepository.Update(*entry); err != nil {
+					log.Printf("Failed to update outbox entry %s: %v", entry.EntryId, err)
+				}
+				return false
+			}
+			if !parkEntry(models.OutboxDeadEntity{
+				Shard:    entry.Shard,
+				EntryId:  entry.EntryId,
+				Kind:     entry.Kind,
+				Target:   entry.Target,
+				Key:      entry.Key,
+				Payload:  entry.Payload,
+				Attempts: entry.Attempts,
+			}, err) {
+				return false
+			}
+		}
+
+		if err := models.LegacyOutboxRepository.Delete(*entry); err != nil {
+			log.Printf("Failed to delete outbox entry %s: %v", entry.EntryId, err)
+			return false
+		}
+	}
+
+	legacyDrained[shard] = len(entries) == 0
+	return legacyDrained[shard]
+}
+
+// parkEntry moves an entry that keeps failing to the dead entries, it reports whether the relay can move past it
+func parkEntry(dead models.OutboxDeadEntity, cause error) bool {
+	dead.Error = cause.Error()
+	dead.ParkedAt = time.Now()
+	if err := models.OutboxDeadEntryRepository.Insert(dead); err != nil {
+		log.Printf("Failed to park outbox entry %s: %v", dead.EntryId, err)
+		return false
+	}
+	log.Printf("Parked outbox entry %s of shard %d after %d attempts: %s %s/%s: %v",
+		dead.EntryId, dead.Shard, dead.Attempts, dead.Kind, dead.Target, dead.Key, cause)
+	return true
+}
+
+func project(ctx context.Context, kind string, target string, key string, payload string) error {
+	switch models.OutboxKind(kind) {
+	case models.OutboxIndexDocument:
+		_, err := common.ElasticsearchClient.
+			Index(target).
+			Id(key).
+			Raw(strings.NewReader(payload)).
+			Do(ctx)
+		return err
+	case models.OutboxUpdateDocument:
+		_, err := common.ElasticsearchClient.
+			Update(target, key).
+			Doc(json.RawMessage(payload)).
+			Do(ctx)
+		return err
+	case models.OutboxSyncDocument:
+		return syncDocument(ctx, target, payload)
+	case models.OutboxHideDocument:
+		return hideDocument(ctx, key, payload)
+	case models.OutboxPublishRecord:
+		return producers.KeyedPublisher.WriteMessages(ctx, kafka.Message{
+			Topic: target,
+			Key:   []byte(key),
+			Value: []byte(payload),
+		})
+	default:
+		return fmt.Errorf("unknown outbox entry kind %q", kind)
+	}
+}
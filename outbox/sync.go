@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/TripConnect/chat-service/store/cassandra"
+	"github.com/TripConnect/chat-service/store/elastic"
+	"github.com/gocql/gocql"
+)
+
+// The documents are rebuilt from the rows as they are when the entry is relayed
+var (
+	rows   store.Storage = cassandra.Storage{}
+	search store.Search  = elastic.Search{}
+)
+
+// syncDocument writes the document named by the entry from its row, it converges whatever order the entries of a
+// row are relayed in since every entry writes the latest state of the row
+func syncDocument(ctx context.Context, index string, payload string) error {
+	var sync models.OutboxSync
+	if err := json.Unmarshal([]byte(payload), &sync); err != nil {
+		return err
+	}
+
+	switch index {
+	case consts.ChatMessageIndex:
+		message, err := rows.GetChatMessage(sync.MessageId)
+		if err == gocql.ErrNotFound {
+			log.Printf("Skipping sync of message %s without row", sync.MessageId)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return search.UpdateChatMessage(ctx, *message)
+	case consts.ConversationIndex:
+		conversation, err := rows.GetConversation(sync.ConversationId)
+		if err == gocql.ErrNotFound {
+			log.Printf("Skipping sync of conversation %s without row", sync.ConversationId)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return search.UpdateConversation(ctx, *conversation)
+	case consts.ParticipantIndex:
+		return syncParticipant(ctx, sync.ConversationId, sync.UserId)
+	default:
+		return fmt.Errorf("unknown index %q to sync", index)
+	}
+}
+
+// syncParticipant indexes the participant and keeps the member ids of the conversation to the joined participants,
+// a participant without row is removed from both
+func syncParticipant(ctx context.Context, conversationId gocql.UUID, userId gocql.UUID) error {
+	participant, err := rows.FindParticipant(conversationId, userId)
+	if err == gocql.ErrNotFound {
+		if err := search.DeleteParticipant(ctx, conversationId, userId); err != nil {
+			return err
+		}
+		return search.RemoveConversationMembers(ctx, conversationId, []gocql.UUID{userId})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := search.IndexParticipant(ctx, *participant); err != nil {
+		return err
+	}
+	if participant.Status == int(models.Joined) {
+		return search.AddConversationMembers(ctx, conversationId, []gocql.UUID{userId})
+	}
+	return search.RemoveConversationMembers(ctx, conversationId, []gocql.UUID{userId})
+}
+
+func hideDocument(ctx context.Context, messageId string, payload string) error {
+	id, err := gocql.ParseUUID(messageId)
+	if err != nil {
+		return err
+	}
+	var userId gocql.UUID
+	if err := json.Unmarshal([]byte(payload), &userId); err != nil {
+		return err
+	}
+	return search.HideChatMessage(ctx, id, userId)
+}
@@ -0,0 +1,3114 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: chat_service.proto
+
+package protos
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MessageDeliveryStatus int32
+
+const (
+	MessageDeliveryStatus_PENDING   MessageDeliveryStatus = 0
+	MessageDeliveryStatus_PERSISTED MessageDeliveryStatus = 1
+	MessageDeliveryStatus_DELIVERED MessageDeliveryStatus = 2
+	MessageDeliveryStatus_FAILED    MessageDeliveryStatus = 3
+)
+
+// Enum value maps for MessageDeliveryStatus.
+var (
+	MessageDeliveryStatus_name = map[int32]string{
+		0: "PENDING",
+		1: "PERSISTED",
+		2: "DELIVERED",
+		3: "FAILED",
+	}
+	MessageDeliveryStatus_value = map[string]int32{
+		"PENDING":   0,
+		"PERSISTED": 1,
+		"DELIVERED": 2,
+		"FAILED":    3,
+	}
+)
+
+func (x MessageDeliveryStatus) Enum() *MessageDeliveryStatus {
+	p := new(MessageDeliveryStatus)
+	*p = x
+	return p
+}
+
+func (x MessageDeliveryStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MessageDeliveryStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_service_proto_enumTypes[0].Descriptor()
+}
+
+func (MessageDeliveryStatus) Type() protoreflect.EnumType {
+	return &file_chat_service_proto_enumTypes[0]
+}
+
+func (x MessageDeliveryStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MessageDeliveryStatus.Descriptor instead.
+func (MessageDeliveryStatus) EnumDescriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{0}
+}
+
+type ConversationType int32
+
+const (
+	ConversationType_PRIVATE ConversationType = 0
+	ConversationType_GROUP   ConversationType = 1
+)
+
+// Enum value maps for ConversationType.
+var (
+	ConversationType_name = map[int32]string{
+		0: "PRIVATE",
+		1: "GROUP",
+	}
+	ConversationType_value = map[string]int32{
+		"PRIVATE": 0,
+		"GROUP":   1,
+	}
+)
+
+func (x ConversationType) Enum() *ConversationType {
+	p := new(ConversationType)
+	*p = x
+	return p
+}
+
+func (x ConversationType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConversationType) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_service_proto_enumTypes[1].Descriptor()
+}
+
+func (ConversationType) Type() protoreflect.EnumType {
+	return &file_chat_service_proto_enumTypes[1]
+}
+
+func (x ConversationType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConversationType.Descriptor instead.
+func (ConversationType) EnumDescriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{1}
+}
+
+type ParticipantStatus int32
+
+const (
+	ParticipantStatus_REQUESTED ParticipantStatus = 0
+	ParticipantStatus_JOINED    ParticipantStatus = 1
+)
+
+// Enum value maps for ParticipantStatus.
+var (
+	ParticipantStatus_name = map[int32]string{
+		0: "REQUESTED",
+		1: "JOINED",
+	}
+	ParticipantStatus_value = map[string]int32{
+		"REQUESTED": 0,
+		"JOINED":    1,
+	}
+)
+
+func (x ParticipantStatus) Enum() *ParticipantStatus {
+	p := new(ParticipantStatus)
+	*p = x
+	return p
+}
+
+func (x ParticipantStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ParticipantStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_service_proto_enumTypes[2].Descriptor()
+}
+
+func (ParticipantStatus) Type() protoreflect.EnumType {
+	return &file_chat_service_proto_enumTypes[2]
+}
+
+func (x ParticipantStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ParticipantStatus.Descriptor instead.
+func (ParticipantStatus) EnumDescriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{2}
+}
+
+type ParticipantRole int32
+
+const (
+	ParticipantRole_MEMBER ParticipantRole = 0
+	ParticipantRole_ADMIN  ParticipantRole = 1
+	ParticipantRole_OWNER  ParticipantRole = 2
+)
+
+// Enum value maps for ParticipantRole.
+var (
+	ParticipantRole_name = map[int32]string{
+		0: "MEMBER",
+		1: "ADMIN",
+		2: "OWNER",
+	}
+	ParticipantRole_value = map[string]int32{
+		"MEMBER": 0,
+		"ADMIN":  1,
+		"OWNER":  2,
+	}
+)
+
+func (x ParticipantRole) Enum() *ParticipantRole {
+	p := new(ParticipantRole)
+	*p = x
+	return p
+}
+
+func (x ParticipantRole) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ParticipantRole) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_service_proto_enumTypes[3].Descriptor()
+}
+
+func (ParticipantRole) Type() protoreflect.EnumType {
+	return &file_chat_service_proto_enumTypes[3]
+}
+
+func (x ParticipantRole) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ParticipantRole.Descriptor instead.
+func (ParticipantRole) EnumDescriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{3}
+}
+
+type ChatMessage struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	FromUserId     string                 `protobuf:"bytes,3,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`
+	Content        string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	SentTime       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=sent_time,json=sentTime,proto3" json:"sent_time,omitempty"`
+	CreateTime     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	EditedAt       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=edited_at,json=editedAt,proto3,oneof" json:"edited_at,omitempty"`
+	// Set on tombstoned messages, their content is cleared
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=deleted_at,json=deletedAt,proto3,oneof" json:"deleted_at,omitempty"`
+	Reactions []*ReactionSummary     `protobuf:"bytes,9,rep,name=reactions,proto3" json:"reactions,omitempty"`
+	// The message quoted by this reply
+	ReplyToMessageId *string `protobuf:"bytes,10,opt,name=reply_to_message_id,json=replyToMessageId,proto3,oneof" json:"reply_to_message_id,omitempty"`
+	// The first message of the thread this reply belongs to
+	ThreadRootId *string `protobuf:"bytes,11,opt,name=thread_root_id,json=threadRootId,proto3,oneof" json:"thread_root_id,omitempty"`
+	// Only filled on thread roots returned by GetThread
+	ReplyCount int32 `protobuf:"varint,12,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"`
+	// Increases with each message of the conversation. A message that fails after taking its number leaves a gap,
+	// so a client that sees a jump reloads the history and treats numbers still missing as skipped
+	Sequence int64 `protobuf:"varint,13,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Only filled by SearchChatMessages, HTML escaped fragments of the content with the matched terms wrapped in <em> tags
+	Highlights    []string `protobuf:"bytes,14,rep,name=highlights,proto3" json:"highlights,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_chat_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChatMessage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetFromUserId() string {
+	if x != nil {
+		return x.FromUserId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetSentTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentTime
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetEditedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EditedAt
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetReactions() []*ReactionSummary {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetReplyToMessageId() string {
+	if x != nil && x.ReplyToMessageId != nil {
+		return *x.ReplyToMessageId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetThreadRootId() string {
+	if x != nil && x.ThreadRootId != nil {
+		return *x.ThreadRootId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetReplyCount() int32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetHighlights() []string {
+	if x != nil {
+		return x.Highlights
+	}
+	return nil
+}
+
+type ReactionSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Emoji         string                 `protobuf:"bytes,1,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	ReactedByMe   bool                   `protobuf:"varint,3,opt,name=reacted_by_me,json=reactedByMe,proto3" json:"reacted_by_me,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactionSummary) Reset() {
+	*x = ReactionSummary{}
+	mi := &file_chat_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionSummary) ProtoMessage() {}
+
+func (x *ReactionSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionSummary.ProtoReflect.Descriptor instead.
+func (*ReactionSummary) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReactionSummary) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *ReactionSummary) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ReactionSummary) GetReactedByMe() bool {
+	if x != nil {
+		return x.ReactedByMe
+	}
+	return false
+}
+
+type CreateChatMessageAck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Same value as message_id, kept for older clients
+	CorrelationId string `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	// The id the message is persisted and delivered with
+	MessageId     string `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateChatMessageAck) Reset() {
+	*x = CreateChatMessageAck{}
+	mi := &file_chat_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateChatMessageAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateChatMessageAck) ProtoMessage() {}
+
+func (x *CreateChatMessageAck) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateChatMessageAck.ProtoReflect.Descriptor instead.
+func (*CreateChatMessageAck) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateChatMessageAck) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *CreateChatMessageAck) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type FindConversationRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId    string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	MessagePageNumber int32                  `protobuf:"varint,2,opt,name=message_page_number,json=messagePageNumber,proto3" json:"message_page_number,omitempty"`
+	MessagePageSize   int32                  `protobuf:"varint,3,opt,name=message_page_size,json=messagePageSize,proto3" json:"message_page_size,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *FindConversationRequest) Reset() {
+	*x = FindConversationRequest{}
+	mi := &file_chat_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindConversationRequest) ProtoMessage() {}
+
+func (x *FindConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindConversationRequest.ProtoReflect.Descriptor instead.
+func (*FindConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FindConversationRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *FindConversationRequest) GetMessagePageNumber() int32 {
+	if x != nil {
+		return x.MessagePageNumber
+	}
+	return 0
+}
+
+func (x *FindConversationRequest) GetMessagePageSize() int32 {
+	if x != nil {
+		return x.MessagePageSize
+	}
+	return 0
+}
+
+type CreateConversationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	OwnerId       *string          `protobuf:"bytes,1,opt,name=owner_id,json=ownerId,proto3,oneof" json:"owner_id,omitempty"`
+	Name          *string          `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Type          ConversationType `protobuf:"varint,3,opt,name=type,proto3,enum=backend.chat_service.ConversationType" json:"type,omitempty"`
+	MemberIds     []string         `protobuf:"bytes,4,rep,name=member_ids,json=memberIds,proto3" json:"member_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateConversationRequest) Reset() {
+	*x = CreateConversationRequest{}
+	mi := &file_chat_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateConversationRequest) ProtoMessage() {}
+
+func (x *CreateConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateConversationRequest.ProtoReflect.Descriptor instead.
+func (*CreateConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{4}
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *CreateConversationRequest) GetOwnerId() string {
+	if x != nil && x.OwnerId != nil {
+		return *x.OwnerId
+	}
+	return ""
+}
+
+func (x *CreateConversationRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *CreateConversationRequest) GetType() ConversationType {
+	if x != nil {
+		return x.Type
+	}
+	return ConversationType_PRIVATE
+}
+
+func (x *CreateConversationRequest) GetMemberIds() []string {
+	if x != nil {
+		return x.MemberIds
+	}
+	return nil
+}
+
+type CreateChatMessageRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	FromUserId       string  `protobuf:"bytes,2,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`
+	Content          string  `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	ReplyToMessageId *string `protobuf:"bytes,4,opt,name=reply_to_message_id,json=replyToMessageId,proto3,oneof" json:"reply_to_message_id,omitempty"`
+	ThreadRootId     *string `protobuf:"bytes,5,opt,name=thread_root_id,json=threadRootId,proto3,oneof" json:"thread_root_id,omitempty"`
+	// Retries with the same key from the same sender and conversation return the first ack
+	IdempotencyKey *string `protobuf:"bytes,6,opt,name=idempotency_key,json=idempotencyKey,proto3,oneof" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateChatMessageRequest) Reset() {
+	*x = CreateChatMessageRequest{}
+	mi := &file_chat_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateChatMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateChatMessageRequest) ProtoMessage() {}
+
+func (x *CreateChatMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateChatMessageRequest.ProtoReflect.Descriptor instead.
+func (*CreateChatMessageRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreateChatMessageRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *CreateChatMessageRequest) GetFromUserId() string {
+	if x != nil {
+		return x.FromUserId
+	}
+	return ""
+}
+
+func (x *CreateChatMessageRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreateChatMessageRequest) GetReplyToMessageId() string {
+	if x != nil && x.ReplyToMessageId != nil {
+		return *x.ReplyToMessageId
+	}
+	return ""
+}
+
+func (x *CreateChatMessageRequest) GetThreadRootId() string {
+	if x != nil && x.ThreadRootId != nil {
+		return *x.ThreadRootId
+	}
+	return ""
+}
+
+func (x *CreateChatMessageRequest) GetIdempotencyKey() string {
+	if x != nil && x.IdempotencyKey != nil {
+		return *x.IdempotencyKey
+	}
+	return ""
+}
+
+type GetChatMessagesRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Before         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=before,proto3,oneof" json:"before,omitempty"`
+	After          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=after,proto3,oneof" json:"after,omitempty"`
+	Limit          int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChatMessagesRequest) Reset() {
+	*x = GetChatMessagesRequest{}
+	mi := &file_chat_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChatMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChatMessagesRequest) ProtoMessage() {}
+
+func (x *GetChatMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChatMessagesRequest.ProtoReflect.Descriptor instead.
+func (*GetChatMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetChatMessagesRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *GetChatMessagesRequest) GetBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *GetChatMessagesRequest) GetAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+func (x *GetChatMessagesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *GetChatMessagesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type SearchChatMessagesRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId *string                `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3,oneof" json:"conversation_id,omitempty"`
+	Term           string                 `protobuf:"bytes,2,opt,name=term,proto3" json:"term,omitempty"`
+	Before         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=before,proto3,oneof" json:"before,omitempty"`
+	After          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=after,proto3,oneof" json:"after,omitempty"`
+	Limit          int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId string `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Match the words of the term next to each other and in order instead of anywhere in the content
+	Phrase        bool `protobuf:"varint,7,opt,name=phrase,proto3" json:"phrase,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchChatMessagesRequest) Reset() {
+	*x = SearchChatMessagesRequest{}
+	mi := &file_chat_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchChatMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchChatMessagesRequest) ProtoMessage() {}
+
+func (x *SearchChatMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchChatMessagesRequest.ProtoReflect.Descriptor instead.
+func (*SearchChatMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchChatMessagesRequest) GetConversationId() string {
+	if x != nil && x.ConversationId != nil {
+		return *x.ConversationId
+	}
+	return ""
+}
+
+func (x *SearchChatMessagesRequest) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+func (x *SearchChatMessagesRequest) GetBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *SearchChatMessagesRequest) GetAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+func (x *SearchChatMessagesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *SearchChatMessagesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SearchChatMessagesRequest) GetPhrase() bool {
+	if x != nil {
+		return x.Phrase
+	}
+	return false
+}
+
+type SubscribeConversationRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ConversationIds []string               `protobuf:"bytes,1,rep,name=conversation_ids,json=conversationIds,proto3" json:"conversation_ids,omitempty"`
+	// Resume cursor, messages sent after this time are replayed before live ones
+	After *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=after,proto3,oneof" json:"after,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeConversationRequest) Reset() {
+	*x = SubscribeConversationRequest{}
+	mi := &file_chat_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeConversationRequest) ProtoMessage() {}
+
+func (x *SubscribeConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeConversationRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SubscribeConversationRequest) GetConversationIds() []string {
+	if x != nil {
+		return x.ConversationIds
+	}
+	return nil
+}
+
+func (x *SubscribeConversationRequest) GetAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *SubscribeConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ChatMessages struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*ChatMessage         `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessages) Reset() {
+	*x = ChatMessages{}
+	mi := &file_chat_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessages) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessages) ProtoMessage() {}
+
+func (x *ChatMessages) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessages.ProtoReflect.Descriptor instead.
+func (*ChatMessages) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ChatMessages) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type Conversation struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type               ConversationType       `protobuf:"varint,2,opt,name=type,proto3,enum=backend.chat_service.ConversationType" json:"type,omitempty"`
+	Name               string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	MemberIds          []string               `protobuf:"bytes,4,rep,name=member_ids,json=memberIds,proto3" json:"member_ids,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	OwnerId            string                 `protobuf:"bytes,7,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
+	LastMessageAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=last_message_at,json=lastMessageAt,proto3,oneof" json:"last_message_at,omitempty"`
+	LastMessagePreview string                 `protobuf:"bytes,9,opt,name=last_message_preview,json=lastMessagePreview,proto3" json:"last_message_preview,omitempty"`
+	// Messages of others sent after the caller's read cursor
+	UnreadCount   int32 `protobuf:"varint,10,opt,name=unread_count,json=unreadCount,proto3" json:"unread_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Conversation) Reset() {
+	*x = Conversation{}
+	mi := &file_chat_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Conversation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conversation) ProtoMessage() {}
+
+func (x *Conversation) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conversation.ProtoReflect.Descriptor instead.
+func (*Conversation) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Conversation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Conversation) GetType() ConversationType {
+	if x != nil {
+		return x.Type
+	}
+	return ConversationType_PRIVATE
+}
+
+func (x *Conversation) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Conversation) GetMemberIds() []string {
+	if x != nil {
+		return x.MemberIds
+	}
+	return nil
+}
+
+func (x *Conversation) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Conversation) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *Conversation) GetLastMessageAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastMessageAt
+	}
+	return nil
+}
+
+func (x *Conversation) GetLastMessagePreview() string {
+	if x != nil {
+		return x.LastMessagePreview
+	}
+	return ""
+}
+
+func (x *Conversation) GetUnreadCount() int32 {
+	if x != nil {
+		return x.UnreadCount
+	}
+	return 0
+}
+
+type SearchConversationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string            `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Type          *ConversationType `protobuf:"varint,2,opt,name=type,proto3,enum=backend.chat_service.ConversationType,oneof" json:"type,omitempty"`
+	Term          string            `protobuf:"bytes,3,opt,name=term,proto3" json:"term,omitempty"`
+	PageNumber    int32             `protobuf:"varint,4,opt,name=page_number,json=pageNumber,proto3" json:"page_number,omitempty"`
+	PageSize      int32             `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchConversationsRequest) Reset() {
+	*x = SearchConversationsRequest{}
+	mi := &file_chat_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchConversationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchConversationsRequest) ProtoMessage() {}
+
+func (x *SearchConversationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchConversationsRequest.ProtoReflect.Descriptor instead.
+func (*SearchConversationsRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{11}
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *SearchConversationsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SearchConversationsRequest) GetType() ConversationType {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return ConversationType_PRIVATE
+}
+
+func (x *SearchConversationsRequest) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+func (x *SearchConversationsRequest) GetPageNumber() int32 {
+	if x != nil {
+		return x.PageNumber
+	}
+	return 0
+}
+
+func (x *SearchConversationsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type Conversations struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conversations []*Conversation        `protobuf:"bytes,1,rep,name=conversations,proto3" json:"conversations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Conversations) Reset() {
+	*x = Conversations{}
+	mi := &file_chat_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Conversations) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conversations) ProtoMessage() {}
+
+func (x *Conversations) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conversations.ProtoReflect.Descriptor instead.
+func (*Conversations) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Conversations) GetConversations() []*Conversation {
+	if x != nil {
+		return x.Conversations
+	}
+	return nil
+}
+
+type AddParticipantsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string   `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MemberIds     []string `protobuf:"bytes,3,rep,name=member_ids,json=memberIds,proto3" json:"member_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddParticipantsRequest) Reset() {
+	*x = AddParticipantsRequest{}
+	mi := &file_chat_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddParticipantsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddParticipantsRequest) ProtoMessage() {}
+
+func (x *AddParticipantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddParticipantsRequest.ProtoReflect.Descriptor instead.
+func (*AddParticipantsRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AddParticipantsRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *AddParticipantsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AddParticipantsRequest) GetMemberIds() []string {
+	if x != nil {
+		return x.MemberIds
+	}
+	return nil
+}
+
+type RemoveParticipantRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MemberId      string `protobuf:"bytes,3,opt,name=member_id,json=memberId,proto3" json:"member_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveParticipantRequest) Reset() {
+	*x = RemoveParticipantRequest{}
+	mi := &file_chat_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveParticipantRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveParticipantRequest) ProtoMessage() {}
+
+func (x *RemoveParticipantRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveParticipantRequest.ProtoReflect.Descriptor instead.
+func (*RemoveParticipantRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RemoveParticipantRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *RemoveParticipantRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RemoveParticipantRequest) GetMemberId() string {
+	if x != nil {
+		return x.MemberId
+	}
+	return ""
+}
+
+type LeaveConversationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveConversationRequest) Reset() {
+	*x = LeaveConversationRequest{}
+	mi := &file_chat_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveConversationRequest) ProtoMessage() {}
+
+func (x *LeaveConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveConversationRequest.ProtoReflect.Descriptor instead.
+func (*LeaveConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LeaveConversationRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *LeaveConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type Participant struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UserId         string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	NickName       string                 `protobuf:"bytes,3,opt,name=nick_name,json=nickName,proto3" json:"nick_name,omitempty"`
+	Status         ParticipantStatus      `protobuf:"varint,4,opt,name=status,proto3,enum=backend.chat_service.ParticipantStatus" json:"status,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Role           ParticipantRole        `protobuf:"varint,6,opt,name=role,proto3,enum=backend.chat_service.ParticipantRole" json:"role,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Participant) Reset() {
+	*x = Participant{}
+	mi := &file_chat_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Participant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Participant) ProtoMessage() {}
+
+func (x *Participant) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Participant.ProtoReflect.Descriptor instead.
+func (*Participant) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Participant) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *Participant) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Participant) GetNickName() string {
+	if x != nil {
+		return x.NickName
+	}
+	return ""
+}
+
+func (x *Participant) GetStatus() ParticipantStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ParticipantStatus_REQUESTED
+}
+
+func (x *Participant) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Participant) GetRole() ParticipantRole {
+	if x != nil {
+		return x.Role
+	}
+	return ParticipantRole_MEMBER
+}
+
+type Participants struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Participants  []*Participant         `protobuf:"bytes,1,rep,name=participants,proto3" json:"participants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Participants) Reset() {
+	*x = Participants{}
+	mi := &file_chat_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Participants) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Participants) ProtoMessage() {}
+
+func (x *Participants) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Participants.ProtoReflect.Descriptor instead.
+func (*Participants) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Participants) GetParticipants() []*Participant {
+	if x != nil {
+		return x.Participants
+	}
+	return nil
+}
+
+type RequestJoinConversationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestJoinConversationRequest) Reset() {
+	*x = RequestJoinConversationRequest{}
+	mi := &file_chat_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestJoinConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestJoinConversationRequest) ProtoMessage() {}
+
+func (x *RequestJoinConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestJoinConversationRequest.ProtoReflect.Descriptor instead.
+func (*RequestJoinConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RequestJoinConversationRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *RequestJoinConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ResolveJoinRequestRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MemberId      string `protobuf:"bytes,3,opt,name=member_id,json=memberId,proto3" json:"member_id,omitempty"`
+	Approve       bool   `protobuf:"varint,4,opt,name=approve,proto3" json:"approve,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveJoinRequestRequest) Reset() {
+	*x = ResolveJoinRequestRequest{}
+	mi := &file_chat_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveJoinRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveJoinRequestRequest) ProtoMessage() {}
+
+func (x *ResolveJoinRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveJoinRequestRequest.ProtoReflect.Descriptor instead.
+func (*ResolveJoinRequestRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ResolveJoinRequestRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *ResolveJoinRequestRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ResolveJoinRequestRequest) GetMemberId() string {
+	if x != nil {
+		return x.MemberId
+	}
+	return ""
+}
+
+func (x *ResolveJoinRequestRequest) GetApprove() bool {
+	if x != nil {
+		return x.Approve
+	}
+	return false
+}
+
+type GetConversationMembersRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string            `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        ParticipantStatus `protobuf:"varint,3,opt,name=status,proto3,enum=backend.chat_service.ParticipantStatus" json:"status,omitempty"`
+	PageNumber    int32             `protobuf:"varint,4,opt,name=page_number,json=pageNumber,proto3" json:"page_number,omitempty"`
+	PageSize      int32             `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConversationMembersRequest) Reset() {
+	*x = GetConversationMembersRequest{}
+	mi := &file_chat_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConversationMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConversationMembersRequest) ProtoMessage() {}
+
+func (x *GetConversationMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConversationMembersRequest.ProtoReflect.Descriptor instead.
+func (*GetConversationMembersRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetConversationMembersRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *GetConversationMembersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetConversationMembersRequest) GetStatus() ParticipantStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ParticipantStatus_REQUESTED
+}
+
+func (x *GetConversationMembersRequest) GetPageNumber() int32 {
+	if x != nil {
+		return x.PageNumber
+	}
+	return 0
+}
+
+func (x *GetConversationMembersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type UpdateConversationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateConversationRequest) Reset() {
+	*x = UpdateConversationRequest{}
+	mi := &file_chat_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateConversationRequest) ProtoMessage() {}
+
+func (x *UpdateConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateConversationRequest.ProtoReflect.Descriptor instead.
+func (*UpdateConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateConversationRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *UpdateConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateConversationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type UpdateParticipantRoleRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string          `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MemberId      string          `protobuf:"bytes,3,opt,name=member_id,json=memberId,proto3" json:"member_id,omitempty"`
+	Role          ParticipantRole `protobuf:"varint,4,opt,name=role,proto3,enum=backend.chat_service.ParticipantRole" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateParticipantRoleRequest) Reset() {
+	*x = UpdateParticipantRoleRequest{}
+	mi := &file_chat_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateParticipantRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateParticipantRoleRequest) ProtoMessage() {}
+
+func (x *UpdateParticipantRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateParticipantRoleRequest.ProtoReflect.Descriptor instead.
+func (*UpdateParticipantRoleRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpdateParticipantRoleRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *UpdateParticipantRoleRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateParticipantRoleRequest) GetMemberId() string {
+	if x != nil {
+		return x.MemberId
+	}
+	return ""
+}
+
+func (x *UpdateParticipantRoleRequest) GetRole() ParticipantRole {
+	if x != nil {
+		return x.Role
+	}
+	return ParticipantRole_MEMBER
+}
+
+type TransferOwnershipRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	// Deprecated: Marked as deprecated in chat_service.proto.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	NewOwnerId    string `protobuf:"bytes,3,opt,name=new_owner_id,json=newOwnerId,proto3" json:"new_owner_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferOwnershipRequest) Reset() {
+	*x = TransferOwnershipRequest{}
+	mi := &file_chat_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferOwnershipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferOwnershipRequest) ProtoMessage() {}
+
+func (x *TransferOwnershipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferOwnershipRequest.ProtoReflect.Descriptor instead.
+func (*TransferOwnershipRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TransferOwnershipRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in chat_service.proto.
+func (x *TransferOwnershipRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TransferOwnershipRequest) GetNewOwnerId() string {
+	if x != nil {
+		return x.NewOwnerId
+	}
+	return ""
+}
+
+type EditChatMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditChatMessageRequest) Reset() {
+	*x = EditChatMessageRequest{}
+	mi := &file_chat_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditChatMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditChatMessageRequest) ProtoMessage() {}
+
+func (x *EditChatMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditChatMessageRequest.ProtoReflect.Descriptor instead.
+func (*EditChatMessageRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *EditChatMessageRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *EditChatMessageRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type DeleteChatMessageRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	MessageId string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	// Tombstone the message for every member instead of hiding it for the caller only
+	ForEveryone   bool `protobuf:"varint,2,opt,name=for_everyone,json=forEveryone,proto3" json:"for_everyone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteChatMessageRequest) Reset() {
+	*x = DeleteChatMessageRequest{}
+	mi := &file_chat_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteChatMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteChatMessageRequest) ProtoMessage() {}
+
+func (x *DeleteChatMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteChatMessageRequest.ProtoReflect.Descriptor instead.
+func (*DeleteChatMessageRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DeleteChatMessageRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *DeleteChatMessageRequest) GetForEveryone() bool {
+	if x != nil {
+		return x.ForEveryone
+	}
+	return false
+}
+
+type AddReactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Emoji         string                 `protobuf:"bytes,2,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddReactionRequest) Reset() {
+	*x = AddReactionRequest{}
+	mi := &file_chat_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddReactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddReactionRequest) ProtoMessage() {}
+
+func (x *AddReactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddReactionRequest.ProtoReflect.Descriptor instead.
+func (*AddReactionRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AddReactionRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *AddReactionRequest) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+type RemoveReactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Emoji         string                 `protobuf:"bytes,2,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveReactionRequest) Reset() {
+	*x = RemoveReactionRequest{}
+	mi := &file_chat_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveReactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveReactionRequest) ProtoMessage() {}
+
+func (x *RemoveReactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveReactionRequest.ProtoReflect.Descriptor instead.
+func (*RemoveReactionRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RemoveReactionRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *RemoveReactionRequest) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+type GetThreadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootMessageId string                 `protobuf:"bytes,1,opt,name=root_message_id,json=rootMessageId,proto3" json:"root_message_id,omitempty"`
+	After         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=after,proto3,oneof" json:"after,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThreadRequest) Reset() {
+	*x = GetThreadRequest{}
+	mi := &file_chat_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThreadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThreadRequest) ProtoMessage() {}
+
+func (x *GetThreadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThreadRequest.ProtoReflect.Descriptor instead.
+func (*GetThreadRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetThreadRequest) GetRootMessageId() string {
+	if x != nil {
+		return x.RootMessageId
+	}
+	return ""
+}
+
+func (x *GetThreadRequest) GetAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+func (x *GetThreadRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type Thread struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          *ChatMessage           `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Replies       []*ChatMessage         `protobuf:"bytes,2,rep,name=replies,proto3" json:"replies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thread) Reset() {
+	*x = Thread{}
+	mi := &file_chat_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thread) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thread) ProtoMessage() {}
+
+func (x *Thread) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thread.ProtoReflect.Descriptor instead.
+func (*Thread) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *Thread) GetRoot() *ChatMessage {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+func (x *Thread) GetReplies() []*ChatMessage {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
+type MarkReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkReadRequest) Reset() {
+	*x = MarkReadRequest{}
+	mi := &file_chat_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkReadRequest) ProtoMessage() {}
+
+func (x *MarkReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkReadRequest.ProtoReflect.Descriptor instead.
+func (*MarkReadRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *MarkReadRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type ReadReceipt struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId    string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	UserId            string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LastReadMessageId string                 `protobuf:"bytes,3,opt,name=last_read_message_id,json=lastReadMessageId,proto3" json:"last_read_message_id,omitempty"`
+	ReadAt            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=read_at,json=readAt,proto3" json:"read_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ReadReceipt) Reset() {
+	*x = ReadReceipt{}
+	mi := &file_chat_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadReceipt) ProtoMessage() {}
+
+func (x *ReadReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadReceipt.ProtoReflect.Descriptor instead.
+func (*ReadReceipt) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ReadReceipt) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ReadReceipt) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ReadReceipt) GetLastReadMessageId() string {
+	if x != nil {
+		return x.LastReadMessageId
+	}
+	return ""
+}
+
+func (x *ReadReceipt) GetReadAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReadAt
+	}
+	return nil
+}
+
+type GetMessageReadersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMessageReadersRequest) Reset() {
+	*x = GetMessageReadersRequest{}
+	mi := &file_chat_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMessageReadersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMessageReadersRequest) ProtoMessage() {}
+
+func (x *GetMessageReadersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMessageReadersRequest.ProtoReflect.Descriptor instead.
+func (*GetMessageReadersRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetMessageReadersRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type ReadReceipts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Receipts      []*ReadReceipt         `protobuf:"bytes,1,rep,name=receipts,proto3" json:"receipts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadReceipts) Reset() {
+	*x = ReadReceipts{}
+	mi := &file_chat_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadReceipts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadReceipts) ProtoMessage() {}
+
+func (x *ReadReceipts) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadReceipts.ProtoReflect.Descriptor instead.
+func (*ReadReceipts) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ReadReceipts) GetReceipts() []*ReadReceipt {
+	if x != nil {
+		return x.Receipts
+	}
+	return nil
+}
+
+type GetInboxRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The next_cursor of the previous page, empty for the first page
+	Cursor        string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit         int32  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInboxRequest) Reset() {
+	*x = GetInboxRequest{}
+	mi := &file_chat_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInboxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInboxRequest) ProtoMessage() {}
+
+func (x *GetInboxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInboxRequest.ProtoReflect.Descriptor instead.
+func (*GetInboxRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GetInboxRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *GetInboxRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type Inbox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conversations []*Conversation        `protobuf:"bytes,1,rep,name=conversations,proto3" json:"conversations,omitempty"`
+	// Empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Inbox) Reset() {
+	*x = Inbox{}
+	mi := &file_chat_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Inbox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Inbox) ProtoMessage() {}
+
+func (x *Inbox) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Inbox.ProtoReflect.Descriptor instead.
+func (*Inbox) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Inbox) GetConversations() []*Conversation {
+	if x != nil {
+		return x.Conversations
+	}
+	return nil
+}
+
+func (x *Inbox) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type GetMessageStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The message id returned by CreateChatMessage
+	MessageId     string `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMessageStatusRequest) Reset() {
+	*x = GetMessageStatusRequest{}
+	mi := &file_chat_service_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMessageStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMessageStatusRequest) ProtoMessage() {}
+
+func (x *GetMessageStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMessageStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetMessageStatusRequest) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetMessageStatusRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+type MessageStatus struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MessageId      string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Status         MessageDeliveryStatus  `protobuf:"varint,3,opt,name=status,proto3,enum=backend.chat_service.MessageDeliveryStatus" json:"status,omitempty"`
+	// A stable code when failed: ENQUEUE_FAILED, REJECTED or RETRIES_EXHAUSTED
+	FailureReason *string                `protobuf:"bytes,4,opt,name=failure_reason,json=failureReason,proto3,oneof" json:"failure_reason,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageStatus) Reset() {
+	*x = MessageStatus{}
+	mi := &file_chat_service_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageStatus) ProtoMessage() {}
+
+func (x *MessageStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_service_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageStatus.ProtoReflect.Descriptor instead.
+func (*MessageStatus) Descriptor() ([]byte, []int) {
+	return file_chat_service_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *MessageStatus) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *MessageStatus) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *MessageStatus) GetStatus() MessageDeliveryStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MessageDeliveryStatus_PENDING
+}
+
+func (x *MessageStatus) GetFailureReason() string {
+	if x != nil && x.FailureReason != nil {
+		return *x.FailureReason
+	}
+	return ""
+}
+
+func (x *MessageStatus) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+var File_chat_service_proto protoreflect.FileDescriptor
+
+const file_chat_service_proto_rawDesc = "" +
+	"\n" +
+	"\x12chat_service.proto\x12\x14backend.chat_service\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbf\x05\n" +
+	"\vChatMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x12 \n" +
+	"\ffrom_user_id\x18\x03 \x01(\tR\n" +
+	"fromUserId\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x127\n" +
+	"\tsent_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bsentTime\x12;\n" +
+	"\vcreate_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12<\n" +
+	"\tedited_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampH\x00R\beditedAt\x88\x01\x01\x12>\n" +
+	"\n" +
+	"deleted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampH\x01R\tdeletedAt\x88\x01\x01\x12C\n" +
+	"\treactions\x18\t \x03(\v2%.backend.chat_service.ReactionSummaryR\treactions\x122\n" +
+	"\x13reply_to_message_id\x18\n" +
+	" \x01(\tH\x02R\x10replyToMessageId\x88\x01\x01\x12)\n" +
+	"\x0ethread_root_id\x18\v \x01(\tH\x03R\fthreadRootId\x88\x01\x01\x12\x1f\n" +
+	"\vreply_count\x18\f \x01(\x05R\n" +
+	"replyCount\x12\x1a\n" +
+	"\bsequence\x18\r \x01(\x03R\bsequence\x12\x1e\n" +
+	"\n" +
+	"highlights\x18\x0e \x03(\tR\n" +
+	"highlightsB\f\n" +
+	"\n" +
+	"_edited_atB\r\n" +
+	"\v_deleted_atB\x16\n" +
+	"\x14_reply_to_message_idB\x11\n" +
+	"\x0f_thread_root_id\"a\n" +
+	"\x0fReactionSummary\x12\x14\n" +
+	"\x05emoji\x18\x01 \x01(\tR\x05emoji\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\"\n" +
+	"\rreacted_by_me\x18\x03 \x01(\bR\vreactedByMe\"\\\n" +
+	"\x14CreateChatMessageAck\x12%\n" +
+	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\"\x9e\x01\n" +
+	"\x17FindConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12.\n" +
+	"\x13message_page_number\x18\x02 \x01(\x05R\x11messagePageNumber\x12*\n" +
+	"\x11message_page_size\x18\x03 \x01(\x05R\x0fmessagePageSize\"\xc9\x01\n" +
+	"\x19CreateConversationRequest\x12\"\n" +
+	"\bowner_id\x18\x01 \x01(\tB\x02\x18\x01H\x00R\aownerId\x88\x01\x01\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x01R\x04name\x88\x01\x01\x12:\n" +
+	"\x04type\x18\x03 \x01(\x0e2&.backend.chat_service.ConversationTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"member_ids\x18\x04 \x03(\tR\tmemberIdsB\v\n" +
+	"\t_owner_idB\a\n" +
+	"\x05_name\"\xcf\x02\n" +
+	"\x18CreateChatMessageRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12$\n" +
+	"\ffrom_user_id\x18\x02 \x01(\tB\x02\x18\x01R\n" +
+	"fromUserId\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x122\n" +
+	"\x13reply_to_message_id\x18\x04 \x01(\tH\x00R\x10replyToMessageId\x88\x01\x01\x12)\n" +
+	"\x0ethread_root_id\x18\x05 \x01(\tH\x01R\fthreadRootId\x88\x01\x01\x12,\n" +
+	"\x0fidempotency_key\x18\x06 \x01(\tH\x02R\x0eidempotencyKey\x88\x01\x01B\x16\n" +
+	"\x14_reply_to_message_idB\x11\n" +
+	"\x0f_thread_root_idB\x12\n" +
+	"\x10_idempotency_key\"\xf9\x01\n" +
+	"\x16GetChatMessagesRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x127\n" +
+	"\x06before\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x06before\x88\x01\x01\x125\n" +
+	"\x05after\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampH\x01R\x05after\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x1b\n" +
+	"\auser_id\x18\x05 \x01(\tB\x02\x18\x01R\x06userIdB\t\n" +
+	"\a_beforeB\b\n" +
+	"\x06_after\"\xc1\x02\n" +
+	"\x19SearchChatMessagesRequest\x12,\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tH\x00R\x0econversationId\x88\x01\x01\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\tR\x04term\x127\n" +
+	"\x06before\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampH\x01R\x06before\x88\x01\x01\x125\n" +
+	"\x05after\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampH\x02R\x05after\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x1b\n" +
+	"\auser_id\x18\x06 \x01(\tB\x02\x18\x01R\x06userId\x12\x16\n" +
+	"\x06phrase\x18\a \x01(\bR\x06phraseB\x12\n" +
+	"\x10_conversation_idB\t\n" +
+	"\a_beforeB\b\n" +
+	"\x06_after\"\xa7\x01\n" +
+	"\x1cSubscribeConversationRequest\x12)\n" +
+	"\x10conversation_ids\x18\x01 \x03(\tR\x0fconversationIds\x125\n" +
+	"\x05after\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x05after\x88\x01\x01\x12\x1b\n" +
+	"\auser_id\x18\x03 \x01(\tB\x02\x18\x01R\x06userIdB\b\n" +
+	"\x06_after\"M\n" +
+	"\fChatMessages\x12=\n" +
+	"\bmessages\x18\x01 \x03(\v2!.backend.chat_service.ChatMessageR\bmessages\"\x95\x03\n" +
+	"\fConversation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12:\n" +
+	"\x04type\x18\x02 \x01(\x0e2&.backend.chat_service.ConversationTypeR\x04type\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"member_ids\x18\x04 \x03(\tR\tmemberIds\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x19\n" +
+	"\bowner_id\x18\a \x01(\tR\aownerId\x12G\n" +
+	"\x0flast_message_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampH\x00R\rlastMessageAt\x88\x01\x01\x120\n" +
+	"\x14last_message_preview\x18\t \x01(\tR\x12lastMessagePreview\x12!\n" +
+	"\funread_count\x18\n" +
+	" \x01(\x05R\vunreadCountB\x12\n" +
+	"\x10_last_message_at\"\xd5\x01\n" +
+	"\x1aSearchConversationsRequest\x12\x1b\n" +
+	"\auser_id\x18\x01 \x01(\tB\x02\x18\x01R\x06userId\x12?\n" +
+	"\x04type\x18\x02 \x01(\x0e2&.backend.chat_service.ConversationTypeH\x00R\x04type\x88\x01\x01\x12\x12\n" +
+	"\x04term\x18\x03 \x01(\tR\x04term\x12\x1f\n" +
+	"\vpage_number\x18\x04 \x01(\x05R\n" +
+	"pageNumber\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSizeB\a\n" +
+	"\x05_type\"Y\n" +
+	"\rConversations\x12H\n" +
+	"\rconversations\x18\x01 \x03(\v2\".backend.chat_service.ConversationR\rconversations\"}\n" +
+	"\x16AddParticipantsRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12\x1d\n" +
+	"\n" +
+	"member_ids\x18\x03 \x03(\tR\tmemberIds\"}\n" +
+	"\x18RemoveParticipantRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12\x1b\n" +
+	"\tmember_id\x18\x03 \x01(\tR\bmemberId\"`\n" +
+	"\x18LeaveConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\"\xa3\x02\n" +
+	"\vParticipant\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tnick_name\x18\x03 \x01(\tR\bnickName\x12?\n" +
+	"\x06status\x18\x04 \x01(\x0e2'.backend.chat_service.ParticipantStatusR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\x04role\x18\x06 \x01(\x0e2%.backend.chat_service.ParticipantRoleR\x04role\"U\n" +
+	"\fParticipants\x12E\n" +
+	"\fparticipants\x18\x01 \x03(\v2!.backend.chat_service.ParticipantR\fparticipants\"f\n" +
+	"\x1eRequestJoinConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\"\x98\x01\n" +
+	"\x19ResolveJoinRequestRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12\x1b\n" +
+	"\tmember_id\x18\x03 \x01(\tR\bmemberId\x12\x18\n" +
+	"\aapprove\x18\x04 \x01(\bR\aapprove\"\xe4\x01\n" +
+	"\x1dGetConversationMembersRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12?\n" +
+	"\x06status\x18\x03 \x01(\x0e2'.backend.chat_service.ParticipantStatusR\x06status\x12\x1f\n" +
+	"\vpage_number\x18\x04 \x01(\x05R\n" +
+	"pageNumber\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\"u\n" +
+	"\x19UpdateConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"\xbc\x01\n" +
+	"\x1cUpdateParticipantRoleRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12\x1b\n" +
+	"\tmember_id\x18\x03 \x01(\tR\bmemberId\x129\n" +
+	"\x04role\x18\x04 \x01(\x0e2%.backend.chat_service.ParticipantRoleR\x04role\"\x82\x01\n" +
+	"\x18TransferOwnershipRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1b\n" +
+	"\auser_id\x18\x02 \x01(\tB\x02\x18\x01R\x06userId\x12 \n" +
+	"\fnew_owner_id\x18\x03 \x01(\tR\n" +
+	"newOwnerId\"Q\n" +
+	"\x16EditChatMessageRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\\\n" +
+	"\x18DeleteChatMessageRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12!\n" +
+	"\ffor_everyone\x18\x02 \x01(\bR\vforEveryone\"I\n" +
+	"\x12AddReactionRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x14\n" +
+	"\x05emoji\x18\x02 \x01(\tR\x05emoji\"L\n" +
+	"\x15RemoveReactionRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x14\n" +
+	"\x05emoji\x18\x02 \x01(\tR\x05emoji\"\x91\x01\n" +
+	"\x10GetThreadRequest\x12&\n" +
+	"\x0froot_message_id\x18\x01 \x01(\tR\rrootMessageId\x125\n" +
+	"\x05after\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x05after\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limitB\b\n" +
+	"\x06_after\"|\n" +
+	"\x06Thread\x125\n" +
+	"\x04root\x18\x01 \x01(\v2!.backend.chat_service.ChatMessageR\x04root\x12;\n" +
+	"\areplies\x18\x02 \x03(\v2!.backend.chat_service.ChatMessageR\areplies\"0\n" +
+	"\x0fMarkReadRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\"\xb5\x01\n" +
+	"\vReadReceipt\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12/\n" +
+	"\x14last_read_message_id\x18\x03 \x01(\tR\x11lastReadMessageId\x123\n" +
+	"\aread_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06readAt\"9\n" +
+	"\x18GetMessageReadersRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\"M\n" +
+	"\fReadReceipts\x12=\n" +
+	"\breceipts\x18\x01 \x03(\v2!.backend.chat_service.ReadReceiptR\breceipts\"?\n" +
+	"\x0fGetInboxRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"r\n" +
+	"\x05Inbox\x12H\n" +
+	"\rconversations\x18\x01 \x03(\v2\".backend.chat_service.ConversationR\rconversations\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"8\n" +
+	"\x17GetMessageStatusRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\"\x96\x02\n" +
+	"\rMessageStatus\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x12C\n" +
+	"\x06status\x18\x03 \x01(\x0e2+.backend.chat_service.MessageDeliveryStatusR\x06status\x12*\n" +
+	"\x0efailure_reason\x18\x04 \x01(\tH\x00R\rfailureReason\x88\x01\x01\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\x11\n" +
+	"\x0f_failure_reason*N\n" +
+	"\x15MessageDeliveryStatus\x12\v\n" +
+	"\aPENDING\x10\x00\x12\r\n" +
+	"\tPERSISTED\x10\x01\x12\r\n" +
+	"\tDELIVERED\x10\x02\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x03**\n" +
+	"\x10ConversationType\x12\v\n" +
+	"\aPRIVATE\x10\x00\x12\t\n" +
+	"\x05GROUP\x10\x01*.\n" +
+	"\x11ParticipantStatus\x12\r\n" +
+	"\tREQUESTED\x10\x00\x12\n" +
+	"\n" +
+	"\x06JOINED\x10\x01*3\n" +
+	"\x0fParticipantRole\x12\n" +
+	"\n" +
+	"\x06MEMBER\x10\x00\x12\t\n" +
+	"\x05ADMIN\x10\x01\x12\t\n" +
+	"\x05OWNER\x10\x022\xbb\x14\n" +
+	"\vChatService\x12k\n" +
+	"\x12CreateConversation\x12/.backend.chat_service.CreateConversationRequest\x1a\".backend.chat_service.Conversation\"\x00\x12g\n" +
+	"\x10FindConversation\x12-.backend.chat_service.FindConversationRequest\x1a\".backend.chat_service.Conversation\"\x00\x12n\n" +
+	"\x13SearchConversations\x120.backend.chat_service.SearchConversationsRequest\x1a#.backend.chat_service.Conversations\"\x00\x12P\n" +
+	"\bGetInbox\x12%.backend.chat_service.GetInboxRequest\x1a\x1b.backend.chat_service.Inbox\"\x00\x12e\n" +
+	"\x0fAddParticipants\x12,.backend.chat_service.AddParticipantsRequest\x1a\".backend.chat_service.Conversation\"\x00\x12i\n" +
+	"\x11RemoveParticipant\x12..backend.chat_service.RemoveParticipantRequest\x1a\".backend.chat_service.Conversation\"\x00\x12]\n" +
+	"\x11LeaveConversation\x12..backend.chat_service.LeaveConversationRequest\x1a\x16.google.protobuf.Empty\"\x00\x12t\n" +
+	"\x17RequestJoinConversation\x124.backend.chat_service.RequestJoinConversationRequest\x1a!.backend.chat_service.Participant\"\x00\x12j\n" +
+	"\x12ResolveJoinRequest\x12/.backend.chat_service.ResolveJoinRequestRequest\x1a!.backend.chat_service.Participant\"\x00\x12s\n" +
+	"\x16GetConversationMembers\x123.backend.chat_service.GetConversationMembersRequest\x1a\".backend.chat_service.Participants\"\x00\x12k\n" +
+	"\x12UpdateConversation\x12/.backend.chat_service.UpdateConversationRequest\x1a\".backend.chat_service.Conversation\"\x00\x12p\n" +
+	"\x15UpdateParticipantRole\x122.backend.chat_service.UpdateParticipantRoleRequest\x1a!.backend.chat_service.Participant\"\x00\x12i\n" +
+	"\x11TransferOwnership\x12..backend.chat_service.TransferOwnershipRequest\x1a\".backend.chat_service.Conversation\"\x00\x12q\n" +
+	"\x11CreateChatMessage\x12..backend.chat_service.CreateChatMessageRequest\x1a*.backend.chat_service.CreateChatMessageAck\"\x00\x12h\n" +
+	"\x10GetMessageStatus\x12-.backend.chat_service.GetMessageStatusRequest\x1a#.backend.chat_service.MessageStatus\"\x00\x12e\n" +
+	"\x0fGetChatMessages\x12,.backend.chat_service.GetChatMessagesRequest\x1a\".backend.chat_service.ChatMessages\"\x00\x12k\n" +
+	"\x12SearchChatMessages\x12/.backend.chat_service.SearchChatMessagesRequest\x1a\".backend.chat_service.ChatMessages\"\x00\x12d\n" +
+	"\x0fEditChatMessage\x12,.backend.chat_service.EditChatMessageRequest\x1a!.backend.chat_service.ChatMessage\"\x00\x12]\n" +
+	"\x11DeleteChatMessage\x12..backend.chat_service.DeleteChatMessageRequest\x1a\x16.google.protobuf.Empty\"\x00\x12\\\n" +
+	"\vAddReaction\x12(.backend.chat_service.AddReactionRequest\x1a!.backend.chat_service.ChatMessage\"\x00\x12b\n" +
+	"\x0eRemoveReaction\x12+.backend.chat_service.RemoveReactionRequest\x1a!.backend.chat_service.ChatMessage\"\x00\x12S\n" +
+	"\tGetThread\x12&.backend.chat_service.GetThreadRequest\x1a\x1c.backend.chat_service.Thread\"\x00\x12V\n" +
+	"\bMarkRead\x12%.backend.chat_service.MarkReadRequest\x1a!.backend.chat_service.ReadReceipt\"\x00\x12i\n" +
+	"\x11GetMessageReaders\x12..backend.chat_service.GetMessageReadersRequest\x1a\".backend.chat_service.ReadReceipts\"\x00\x12r\n" +
+	"\x15SubscribeConversation\x122.backend.chat_service.SubscribeConversationRequest\x1a!.backend.chat_service.ChatMessage\"\x000\x01B3Z1github.com/TripConnect/chat-service/protos;protosb\x06proto3"
+
+var (
+	file_chat_service_proto_rawDescOnce sync.Once
+	file_chat_service_proto_rawDescData []byte
+)
+
+func file_chat_service_proto_rawDescGZIP() []byte {
+	file_chat_service_proto_rawDescOnce.Do(func() {
+		file_chat_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chat_service_proto_rawDesc), len(file_chat_service_proto_rawDesc)))
+	})
+	return file_chat_service_proto_rawDescData
+}
+
+var file_chat_service_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_chat_service_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
+var file_chat_service_proto_goTypes = []any{
+	(MessageDeliveryStatus)(0),             // 0: backend.chat_service.MessageDeliveryStatus
+	(ConversationType)(0),                  // 1: backend.chat_service.ConversationType
+	(ParticipantStatus)(0),                 // 2: backend.chat_service.ParticipantStatus
+	(ParticipantRole)(0),                   // 3: backend.chat_service.ParticipantRole
+	(*ChatMessage)(nil),                    // 4: backend.chat_service.ChatMessage
+	(*ReactionSummary)(nil),                // 5: backend.chat_service.ReactionSummary
+	(*CreateChatMessageAck)(nil),           // 6: backend.chat_service.CreateChatMessageAck
+	(*FindConversationRequest)(nil),        // 7: backend.chat_service.FindConversationRequest
+	(*CreateConversationRequest)(nil),      // 8: backend.chat_service.CreateConversationRequest
+	(*CreateChatMessageRequest)(nil),       // 9: backend.chat_service.CreateChatMessageRequest
+	(*GetChatMessagesRequest)(nil),         // 10: backend.chat_service.GetChatMessagesRequest
+	(*SearchChatMessagesRequest)(nil),      // 11: backend.chat_service.SearchChatMessagesRequest
+	(*SubscribeConversationRequest)(nil),   // 12: backend.chat_service.SubscribeConversationRequest
+	(*ChatMessages)(nil),                   // 13: backend.chat_service.ChatMessages
+	(*Conversation)(nil),                   // 14: backend.chat_service.Conversation
+	(*SearchConversationsRequest)(nil),     // 15: backend.chat_service.SearchConversationsRequest
+	(*Conversations)(nil),                  // 16: backend.chat_service.Conversations
+	(*AddParticipantsRequest)(nil),         // 17: backend.chat_service.AddParticipantsRequest
+	(*RemoveParticipantRequest)(nil),       // 18: backend.chat_service.RemoveParticipantRequest
+	(*LeaveConversationRequest)(nil),       // 19: backend.chat_service.LeaveConversationRequest
+	(*Participant)(nil),                    // 20: backend.chat_service.Participant
+	(*Participants)(nil),                   // 21: backend.chat_service.Participants
+	(*RequestJoinConversationRequest)(nil), // 22: backend.chat_service.RequestJoinConversationRequest
+	(*ResolveJoinRequestRequest)(nil),      // 23: backend.chat_service.ResolveJoinRequestRequest
+	(*GetConversationMembersRequest)(nil),  // 24: backend.chat_service.GetConversationMembersRequest
+	(*UpdateConversationRequest)(nil),      // 25: backend.chat_service.UpdateConversationRequest
+	(*UpdateParticipantRoleRequest)(nil),   // 26: backend.chat_service.UpdateParticipantRoleRequest
+	(*TransferOwnershipRequest)(nil),       // 27: backend.chat_service.TransferOwnershipRequest
+	(*EditChatMessageRequest)(nil),         // 28: backend.chat_service.EditChatMessageRequest
+	(*DeleteChatMessageRequest)(nil),       // 29: backend.chat_service.DeleteChatMessageRequest
+	(*AddReactionRequest)(nil),             // 30: backend.chat_service.AddReactionRequest
+	(*RemoveReactionRequest)(nil),          // 31: backend.chat_service.RemoveReactionRequest
+	(*GetThreadRequest)(nil),               // 32: backend.chat_service.GetThreadRequest
+	(*Thread)(nil),                         // 33: backend.chat_service.Thread
+	(*MarkReadRequest)(nil),                // 34: backend.chat_service.MarkReadRequest
+	(*ReadReceipt)(nil),                    // 35: backend.chat_service.ReadReceipt
+	(*GetMessageReadersRequest)(nil),       // 36: backend.chat_service.GetMessageReadersRequest
+	(*ReadReceipts)(nil),                   // 37: backend.chat_service.ReadReceipts
+	(*GetInboxRequest)(nil),                // 38: backend.chat_service.GetInboxRequest
+	(*Inbox)(nil),                          // 39: backend.chat_service.Inbox
+	(*GetMessageStatusRequest)(nil),        // 40: backend.chat_service.GetMessageStatusRequest
+	(*MessageStatus)(nil),                  // 41: backend.chat_service.MessageStatus
+	(*timestamppb.Timestamp)(nil),          // 42: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                  // 43: google.protobuf.Empty
+}
+var file_chat_service_proto_depIdxs = []int32{
+	42, // 0: backend.chat_service.ChatMessage.sent_time:type_name -> google.protobuf.Timestamp
+	42, // 1: backend.chat_service.ChatMessage.create_time:type_name -> google.protobuf.Timestamp
+	42, // 2: backend.chat_service.ChatMessage.edited_at:type_name -> google.protobuf.Timestamp
+	42, // 3: backend.chat_service.ChatMessage.deleted_at:type_name -> google.protobuf.Timestamp
+	5,  // 4: backend.chat_service.ChatMessage.reactions:type_name -> backend.chat_service.ReactionSummary
+	1,  // 5: backend.chat_service.CreateConversationRequest.type:type_name -> backend.chat_service.ConversationType
+	42, // 6: backend.chat_service.GetChatMessagesRequest.before:type_name -> google.protobuf.Timestamp
+	42, // 7: backend.chat_service.GetChatMessagesRequest.after:type_name -> google.protobuf.Timestamp
+	42, // 8: backend.chat_service.SearchChatMessagesRequest.before:type_name -> google.protobuf.Timestamp
+	42, // 9: backend.chat_service.SearchChatMessagesRequest.after:type_name -> google.protobuf.Timestamp
+	42, // 10: backend.chat_service.SubscribeConversationRequest.after:type_name -> google.protobuf.Timestamp
+	4,  // 11: backend.chat_service.ChatMessages.messages:type_name -> backend.chat_service.ChatMessage
+	1,  // 12: backend.chat_service.Conversation.type:type_name -> backend.chat_service.ConversationType
+	42, // 13: backend.chat_service.Conversation.created_at:type_name -> google.protobuf.Timestamp
+	42, // 14: backend.chat_service.Conversation.last_message_at:type_name -> google.protobuf.Timestamp
+	1,  // 15: backend.chat_service.SearchConversationsRequest.type:type_name -> backend.chat_service.ConversationType
+	14, // 16: backend.chat_service.Conversations.conversations:type_name -> backend.chat_service.Conversation
+	2,  // 17: backend.chat_service.Participant.status:type_name -> backend.chat_service.ParticipantStatus
+	42, // 18: backend.chat_service.Participant.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 19: backend.chat_service.Participant.role:type_name -> backend.chat_service.ParticipantRole
+	20, // 20: backend.chat_service.Participants.participants:type_name -> backend.chat_service.Participant
+	2,  // 21: backend.chat_service.GetConversationMembersRequest.status:type_name -> backend.chat_service.ParticipantStatus
+	3,  // 22: backend.chat_service.UpdateParticipantRoleRequest.role:type_name -> backend.chat_service.ParticipantRole
+	42, // 23: backend.chat_service.GetThreadRequest.after:type_name -> google.protobuf.Timestamp
+	4,  // 24: backend.chat_service.Thread.root:type_name -> backend.chat_service.ChatMessage
+	4,  // 25: backend.chat_service.Thread.replies:type_name -> backend.chat_service.ChatMessage
+	42, // 26: backend.chat_service.ReadReceipt.read_at:type_name -> google.protobuf.Timestamp
+	35, // 27: backend.chat_service.ReadReceipts.receipts:type_name -> backend.chat_service.ReadReceipt
+	14, // 28: backend.chat_service.Inbox.conversations:type_name -> backend.chat_service.Conversation
+	0,  // 29: backend.chat_service.MessageStatus.status:type_name -> backend.chat_service.MessageDeliveryStatus
+	42, // 30: backend.chat_service.MessageStatus.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 31: backend.chat_service.ChatService.CreateConversation:input_type -> backend.chat_service.CreateConversationRequest
+	7,  // 32: backend.chat_service.ChatService.FindConversation:input_type -> backend.chat_service.FindConversationRequest
+	15, // 33: backend.chat_service.ChatService.SearchConversations:input_type -> backend.chat_service.SearchConversationsRequest
+	38, // 34: backend.chat_service.ChatService.GetInbox:input_type -> backend.chat_service.GetInboxRequest
+	17, // 35: backend.chat_service.ChatService.AddParticipants:input_type -> backend.chat_service.AddParticipantsRequest
+	18, // 36: backend.chat_service.ChatService.RemoveParticipant:input_type -> backend.chat_service.RemoveParticipantRequest
+	19, // 37: backend.chat_service.ChatService.LeaveConversation:input_type -> backend.chat_service.LeaveConversationRequest
+	22, // 38: backend.chat_service.ChatService.RequestJoinConversation:input_type -> backend.chat_service.RequestJoinConversationRequest
+	23, // 39: backend.chat_service.ChatService.ResolveJoinRequest:input_type -> backend.chat_service.ResolveJoinRequestRequest
+	24, // 40: backend.chat_service.ChatService.GetConversationMembers:input_type -> backend.chat_service.GetConversationMembersRequest
+	25, // 41: backend.chat_service.ChatService.UpdateConversation:input_type -> backend.chat_service.UpdateConversationRequest
+	26, // 42: backend.chat_service.ChatService.UpdateParticipantRole:input_type -> backend.chat_service.UpdateParticipantRoleRequest
+	27, // 43: backend.chat_service.ChatService.TransferOwnership:input_type -> backend.chat_service.TransferOwnershipRequest
+	9,  // 44: backend.chat_service.ChatService.CreateChatMessage:input_type -> backend.chat_service.CreateChatMessageRequest
+	40, // 45: backend.chat_service.ChatService.GetMessageStatus:input_type -> backend.chat_service.GetMessageStatusRequest
+	10, // 46: backend.chat_service.ChatService.GetChatMessages:input_type -> backend.chat_service.GetChatMessagesRequest
+	11, // 47: backend.chat_service.ChatService.SearchChatMessages:input_type -> backend.chat_service.SearchChatMessagesRequest
+	28, // 48: backend.chat_service.ChatService.EditChatMessage:input_type -> backend.chat_service.EditChatMessageRequest
+	29, // 49: backend.chat_service.ChatService.DeleteChatMessage:input_type -> backend.chat_service.DeleteChatMessageRequest
+	30, // 50: backend.chat_service.ChatService.AddReaction:input_type -> backend.chat_service.AddReactionRequest
+	31, // 51: backend.chat_service.ChatService.RemoveReaction:input_type -> backend.chat_service.RemoveReactionRequest
+	32, // 52: backend.chat_service.ChatService.GetThread:input_type -> backend.chat_service.GetThreadRequest
+	34, // 53: backend.chat_service.ChatService.MarkRead:input_type -> backend.chat_service.MarkReadRequest
+	36, // 54: backend.chat_service.ChatService.GetMessageReaders:input_type -> backend.chat_service.GetMessageReadersRequest
+	12, // 55: backend.chat_service.ChatService.SubscribeConversation:input_type -> backend.chat_service.SubscribeConversationRequest
+	14, // 56: backend.chat_service.ChatService.CreateConversation:output_type -> backend.chat_service.Conversation
+	14, // 57: backend.chat_service.ChatService.FindConversation:output_type -> backend.chat_service.Conversation
+	16, // 58: backend.chat_service.ChatService.SearchConversations:output_type -> backend.chat_service.Conversations
+	39, // 59: backend.chat_service.ChatService.GetInbox:output_type -> backend.chat_service.Inbox
+	14, // 60: backend.chat_service.ChatService.AddParticipants:output_type -> backend.chat_service.Conversation
+	14, // 61: backend.chat_service.ChatService.RemoveParticipant:output_type -> backend.chat_service.Conversation
+	43, // 62: backend.chat_service.ChatService.LeaveConversation:output_type -> google.protobuf.Empty
+	20, // 63: backend.chat_service.ChatService.RequestJoinConversation:output_type -> backend.chat_service.Participant
+	20, // 64: backend.chat_service.ChatService.ResolveJoinRequest:output_type -> backend.chat_service.Participant
+	21, // 65: backend.chat_service.ChatService.GetConversationMembers:output_type -> backend.chat_service.Participants
+	14, // 66: backend.chat_service.ChatService.UpdateConversation:output_type -> backend.chat_service.Conversation
+	20, // 67: backend.chat_service.ChatService.UpdateParticipantRole:output_type -> backend.chat_service.Participant
+	14, // 68: backend.chat_service.ChatService.TransferOwnership:output_type -> backend.chat_service.Conversation
+	6,  // 69: backend.chat_service.ChatService.CreateChatMessage:output_type -> backend.chat_service.CreateChatMessageAck
+	41, // 70: backend.chat_service.ChatService.GetMessageStatus:output_type -> backend.chat_service.MessageStatus
+	13, // 71: backend.chat_service.ChatService.GetChatMessages:output_type -> backend.chat_service.ChatMessages
+	13, // 72: backend.chat_service.ChatService.SearchChatMessages:output_type -> backend.chat_service.ChatMessages
+	4,  // 73: backend.chat_service.ChatService.EditChatMessage:output_type -> backend.chat_service.ChatMessage
+	43, // 74: backend.chat_service.ChatService.DeleteChatMessage:output_type -> google.protobuf.Empty
+	4,  // 75: backend.chat_service.ChatService.AddReaction:output_type -> backend.chat_service.ChatMessage
+	4,  // 76: backend.chat_service.ChatService.RemoveReaction:output_type -> backend.chat_service.ChatMessage
+	33, // 77: backend.chat_service.ChatService.GetThread:output_type -> backend.chat_service.Thread
+	35, // 78: backend.chat_service.ChatService.MarkRead:output_type -> backend.chat_service.ReadReceipt
+	37, // 79: backend.chat_service.ChatService.GetMessageReaders:output_type -> backend.chat_service.ReadReceipts
+	4,  // 80: backend.chat_service.ChatService.SubscribeConversation:output_type -> backend.chat_service.ChatMessage
+	56, // [56:81] is the sub-list for method output_type
+	31, // [31:56] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
+}
+
+func init() { file_chat_service_proto_init() }
+func file_chat_service_proto_init() {
+	if File_chat_service_proto != nil {
+		return
+	}
+	file_chat_service_proto_msgTypes[0].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[4].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[5].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[6].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[7].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[8].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[10].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[11].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[28].OneofWrappers = []any{}
+	file_chat_service_proto_msgTypes[37].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chat_service_proto_rawDesc), len(file_chat_service_proto_rawDesc)),
+			NumEnums:      4,
+			NumMessages:   38,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_chat_service_proto_goTypes,
+		DependencyIndexes: file_chat_service_proto_depIdxs,
+		EnumInfos:         file_chat_service_proto_enumTypes,
+		MessageInfos:      file_chat_service_proto_msgTypes,
+	}.Build()
+	File_chat_service_proto = out.File
+	file_chat_service_proto_goTypes = nil
+	file_chat_service_proto_depIdxs = nil
+}
@@ -0,0 +1,136 @@
+package realtime
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+)
+
+const subscriptionBufferSize = 256
+
+// Subscription receives the persisted messages of the conversations it was created for
+type Subscription struct {
+	userId          gocql.UUID
+	conversationIds []gocql.UUID
+	messages        chan models.ChatMessageEntity
+	overflow        chan struct{}
+	overflowOnce    sync.Once
+	revoked         chan struct{}
+}
+
+// Messages returns the channel of delivered messages
+func (s *Subscription) Messages() <-chan models.ChatMessageEntity {
+	return s.messages
+}
+
+// Overflow is closed when the subscriber is too slow and messages were dropped,
+// the client should reconnect and resume from its last received message
+func (s *Subscription) Overflow() <-chan struct{} {
+	return s.overflow
+}
+
+// Revoked is closed once the user is no longer a member of any of the conversations of the subscription
+func (s *Subscription) Revoked() <-chan struct{} {
+	return s.revoked
+}
+
+// Hub fans out persisted chat messages to the subscribers of this instance
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[gocql.UUID]map[*Subscription]struct{}
+}
+
+var ChatMessageHub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[gocql.UUID]map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe registers a subscription of the user to the conversations
+func (h *Hub) Subscribe(userId gocql.UUID, conversationIds []gocql.UUID) *Subscription {
+	sub := &Subscription{
+		userId:          userId,
+		conversationIds: conversationIds,
+		messages:        make(chan models.ChatMessageEntity, subscriptionBufferSize),
+		overflow:        make(chan struct{}),
+		revoked:         make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, conversationId := range conversationIds {
+		if _, ok := h.subscribers[conversationId]; !ok {
+			h.subscribers[conversationId] = map[*Subscription]struct{}{}
+		}
+		h.subscribers[conversationId][sub] = struct{}{}
+	}
+
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, conversationId := range sub.conversationIds {
+		delete(h.subscribers[conversationId], sub)
+		if len(h.subscribers[conversationId]) == 0 {
+			delete(h.subscribers, conversationId)
+		}
+	}
+}
+
+// RemoveMember stops the subscriptions of the user to the conversation, called when the user left or was removed.
+// A subscription left without conversations is revoked.
+func (h *Hub) RemoveMember(conversationId gocql.UUID, userId gocql.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[conversationId] {
+		if sub.userId != userId {
+			continue
+		}
+		delete(h.subscribers[conversationId], sub)
+		sub.conversationIds = slices.DeleteFunc(sub.conversationIds, func(id gocql.UUID) bool { return id == conversationId })
+		if len(sub.conversationIds) == 0 {
+			close(sub.revoked)
+		}
+	}
+	if len(h.subscribers[conversationId]) == 0 {
+		delete(h.subscribers, conversationId)
+	}
+}
+
+// Follows reports whether the subscription still follows the conversation, messages buffered before the user was
+// removed are dropped with it
+func (h *Hub) Follows(sub *Subscription, conversationId gocql.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return slices.Contains(sub.conversationIds, conversationId)
+}
+
+// Publish delivers the message without blocking, subscribers with a full buffer are flagged as overflowed.
+// It returns the number of subscribers the message was handed to, the subscriptions of its sender are not counted.
+func (h *Hub) Publish(message models.ChatMessageEntity) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	delivered := 0
+	for sub := range h.subscribers[message.ConversationId] {
+		select {
+		case sub.messages <- message:
+			if sub.userId != message.FromUserId {
+				delivered++
+			}
+		default:
+			sub.overflowOnce.Do(func() { close(sub.overflow) })
+		}
+	}
+	return delivered
+}
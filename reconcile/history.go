@@ -0,0 +1,21 @@
+package reconcile
+
+import (
+	"context"
+
+	"github.com/TripConnect/chat-service/models"
+)
+
+// BackfillHistory copies every message into the conversation history tables,
+// messages stored before the tables existed are only found by id otherwise
+func BackfillHistory(ctx context.Context) (int, error) {
+	copied := 0
+	err := scanTable(ctx, models.ChatMessageRepository.TableInterface, func(row any) error {
+		if err := models.SaveWithOutbox(models.NewChatMessageRows(*row.(*models.ChatMessageEntity))); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
@@ -0,0 +1,32 @@
+package reconcile
+
+import (
+	"expvar"
+	"time"
+)
+
+// Drift counters of every run since the process started, served with the other expvars on /debug/vars
+var (
+	driftTotal = expvar.NewMap("reconcile_drift_total")
+	runsTotal  = expvar.NewInt("reconcile_runs_total")
+	lastRunAt  = expvar.NewInt("reconcile_last_run_unix")
+)
+
+// export adds the drift of the run to the counters, keyed by index and kind of drift
+func (r Report) export() {
+	for index, drift := range r {
+		for kind, count := range map[string]int{
+			"scanned":   drift.Scanned,
+			"missing":   drift.Missing,
+			"stale":     drift.Stale,
+			"orphaned":  drift.Orphaned,
+			"repaired":  drift.Repaired,
+			"conflicts": drift.Conflicts,
+			"failed":    drift.Failed,
+		} {
+			driftTotal.Add(index+"."+kind, int64(count))
+		}
+	}
+	runsTotal.Add(1)
+	lastRunAt.Set(time.Now().Unix())
+}
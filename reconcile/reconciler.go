@@ -0,0 +1,543 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/gocql/gocql"
+	"github.com/kristoiv/gocqltable"
+	"github.com/tripconnect/go-common-utils/common"
+)
+
+const (
+	reconcileBatchSize = 500
+	scrollKeepAlive    = "1m"
+)
+
+// Drift counts what a run found in one index
+type Drift struct {
+	Scanned   int // rows compared with their document
+	Missing   int // rows without a document
+	Stale     int // documents that differ from their row
+	Orphaned  int // documents without a row
+	Repaired  int
+	Conflicts int // orphans written by another writer while being deleted, checked again on the next run
+	Failed    int
+}
+
+// Report is the drift of every reconciled index
+type Report map[string]*Drift
+
+func (r Report) Log() {
+	for _, index := range []string{consts.ParticipantIndex, consts.ConversationIndex, consts.ChatMessageIndex} {
+		if drift, ok := r[index]; ok {
+			log.Printf("Reconciled %s: scanned=%d missing=%d stale=%d orphaned=%d repaired=%d conflicts=%d failed=%d",
+				index, drift.Scanned, drift.Missing, drift.Stale, drift.Orphaned, drift.Repaired, drift.Conflicts, drift.Failed)
+		}
+	}
+}
+
+// candidate is a row to compare with its document, check returns the outbox entries that repair the document and
+// none when it matches. Repairs go through the outbox so the relay rebuilds the document from the row as it is then.
+type candidate struct {
+	id             string
+	conversationId gocql.UUID
+	check          func(current *types.GetResult) ([]models.OutboxEntity, error)
+}
+
+type indexReconciler struct {
+	index  string
+	dryRun bool
+	drift  *Drift
+	batch  []candidate
+	// prefetch loads what the checks of a batch need besides the documents
+	prefetch func(ctx context.Context, batch []candidate) error
+}
+
+// Run compares the Cassandra tables with their indices and, unless dryRun is set, repairs the missing and stale
+// documents and removes the documents without row. Rows and documents are streamed a batch at a time.
+func Run(ctx context.Context, dryRun bool) (Report, error) {
+	report := Report{}
+	defer report.export()
+
+	for _, pass := range []func(context.Context, bool, Report) error{
+		reconcileParticipants,
+		reconcileConversations,
+		reconcileMessages,
+		removeOrphans,
+	} {
+		if err := pass(ctx, dryRun, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func (r Report) reconciler(index string, dryRun bool) *indexReconciler {
+	if _, ok := r[index]; !ok {
+		r[index] = &Drift{}
+	}
+	return &indexReconciler{index: index, dryRun: dryRun, drift: r[index]}
+}
+
+// reconcileParticipants compares the participant documents and the place of each participant in the member ids
+// of its conversation, which only the participant rows can tell
+func reconcileParticipants(ctx context.Context, dryRun bool, report Report) error {
+	participants := report.reconciler(consts.ParticipantIndex, dryRun)
+	memberIds := map[gocql.UUID][]string{}
+	participants.prefetch = func(ctx context.Context, batch []candidate) error {
+		clear(memberIds)
+		ids := []string{}
+		for _, c := range batch {
+			if !slices.Contains(ids, c.conversationId.String()) {
+				ids = append(ids, c.conversationId.String())
+			}
+		}
+		return fetchDocuments(ctx, consts.ConversationIndex, ids, func(_ int, current *types.GetResult) {
+			var doc models.ConversationDocument
+			if current.Found && json.Unmarshal(current.Source_, &doc) == nil {
+				memberIds[doc.Id] = doc.MemberIds
+			}
+		})
+	}
+
+	err := scanTable(ctx, models.ParticipantRepository.TableInterface, func(row any) error {
+		participant := *row.(*models.ParticipantEntity)
+		return participants.add(ctx, candidate{
+			id:             models.NewParticipantDocId(participant.ConversationId, participant.UserId),
+			conversationId: participant.ConversationId,
+			check: func(current *types.GetResult) ([]models.OutboxEntity, error) {
+				matches := current.Found && sameDocument(models.NewParticipantDoc(participant, nil), current.Source_)
+				// A conversation without document is repaired by its own pass, the sync adds its members
+				if members, ok := memberIds[participant.ConversationId]; ok {
+					joined := participant.Status == int(models.Joined)
+					matches = matches && slices.Contains(members, participant.UserId.String()) == joined
+				}
+				if matches {
+					return nil, nil
+				}
+				return newEntries(models.NewParticipantSyncEntry(participant.ConversationId, participant.UserId))
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return participants.flush(ctx)
+}
+
+// reconcileConversations compares the conversation documents, a member id without joined participant is removed
+// by the sync of that participant
+func reconcileConversations(ctx context.Context, dryRun bool, report Report) error {
+	conversations := report.reconciler(consts.ConversationIndex, dryRun)
+	err := scanTable(ctx, models.ConversationRepository.TableInterface, func(row any) error {
+		conversation := *row.(*models.ConversationEntity)
+		return conversations.add(ctx, candidate{
+			id: conversation.Id.String(),
+			check: func(current *types.GetResult) ([]models.OutboxEntity, error) {
+				if !current.Found || !sameDocument(models.NewConversationDoc(conversation, nil), current.Source_, "member_ids") {
+					return newEntries(models.NewConversationSyncEntry(conversation.Id))
+				}
+
+				var doc models.ConversationDocument
+				if err := json.Unmarshal(current.Source_, &doc); err != nil {
+					return nil, err
+				}
+				entries := []models.OutboxEntity{}
+				for _, memberId := range doc.MemberIds {
+					userId, err := gocql.ParseUUID(memberId)
+					if err != nil {
+						return nil, err
+					}
+					if _, err := models.ParticipantRepository.Get(conversation.Id, userId, int(models.Joined)); err == nil {
+						continue
+					} else if err != gocql.ErrNotFound {
+						return nil, err
+					}
+					entry, err := models.NewParticipantSyncEntry(conversation.Id, userId)
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, entry)
+				}
+				return entries, nil
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return conversations.flush(ctx)
+}
+
+// reconcileMessages compares the message documents, then the users they are hidden for. Hidden messages are
+// partitioned by user so they are scanned on their own.
+func reconcileMessages(ctx context.Context, dryRun bool, report Report) error {
+	messages := report.reconciler(consts.ChatMessageIndex, dryRun)
+	err := scanTable(ctx, models.ChatMessageRepository.TableInterface, func(row any) error {
+		message := *row.(*models.ChatMessageEntity)
+		return messages.add(ctx, candidate{
+			id: message.Id.String(),
+			check: func(current *types.GetResult) ([]models.OutboxEntity, error) {
+				if current.Found && sameDocument(models.NewChatMessageDoc(message), current.Source_, "hidden_for") {
+					return nil, nil
+				}
+				return newEntries(models.NewMessageSyncEntry(message))
+			},
+		})
+	})
+	if err == nil {
+		err = messages.flush(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = scanTable(ctx, models.HiddenChatMessageRepository.TableInterface, func(row any) error {
+		hidden := *row.(*models.HiddenChatMessageEntity)
+		return messages.add(ctx, candidate{
+			id: hidden.MessageId.String(),
+			check: func(current *types.GetResult) ([]models.OutboxEntity, error) {
+				var doc models.ChatMessageDocument
+				if current.Found && json.Unmarshal(current.Source_, &doc) == nil && slices.Contains(doc.HiddenFor, hidden.UserId.String()) {
+					return nil, nil
+				}
+				return newEntries(models.NewHideEntry(hidden))
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return messages.flush(ctx)
+}
+
+func newEntries(entry models.OutboxEntity, err error) ([]models.OutboxEntity, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []models.OutboxEntity{entry}, nil
+}
+
+// RunPeriodically reconciles every interval until the context is cancelled
+func RunPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := Run(ctx, false)
+			report.Log()
+			if err != nil {
+				log.Printf("Reconciliation stopped early: %v", err)
+			}
+		}
+	}
+}
+
+// scanTable visits every row of the table, the driver fetches them a page at a time
+func scanTable(ctx context.Context, table gocqltable.TableInterface, visit func(row any) error) error {
+	iter := models.Select(table, fmt.Sprintf(`SELECT * FROM %q.%q`, table.Keyspace().Name(), table.Name()))
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		if err := ctx.Err(); err != nil {
+			iter.Close()
+			return err
+		}
+		if err := visit(row); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+// fetchDocuments gets the documents by id, visit is called with the position of each id that could be read
+func fetchDocuments(ctx context.Context, index string, ids []string, visit func(i int, current *types.GetResult)) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	res, err := common.ElasticsearchClient.Mget().Index(index).Ids(ids...).Do(ctx)
+	if err != nil {
+		return err
+	}
+	for i, item := range res.Docs {
+		current, ok := item.(*types.GetResult)
+		if !ok {
+			log.Printf("Failed to get document %s/%s: %v", index, ids[i], item)
+			continue
+		}
+		visit(i, current)
+	}
+	return nil
+}
+
+func (r *indexReconciler) add(ctx context.Context, c candidate) error {
+	r.batch = append(r.batch, c)
+	if len(r.batch) < reconcileBatchSize {
+		return nil
+	}
+	return r.flush(ctx)
+}
+
+func (r *indexReconciler) flush(ctx context.Context) error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+	batch := r.batch
+	r.batch = nil
+
+	if r.prefetch != nil {
+		if err := r.prefetch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	ids := make([]string, len(batch))
+	for i, c := range batch {
+		ids[i] = c.id
+	}
+	found := 0
+	err := fetchDocuments(ctx, r.index, ids, func(i int, current *types.GetResult) {
+		c, id := batch[i], ids[i]
+		found++
+		r.drift.Scanned++
+
+		entries, err := c.check(current)
+		if err != nil {
+			log.Printf("Failed to check document %s/%s: %v", r.index, id, err)
+			r.drift.Failed++
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+		if current.Found {
+			r.drift.Stale++
+		} else {
+			r.drift.Missing++
+		}
+		if r.dryRun {
+			return
+		}
+
+		if err := models.SaveWithOutbox(nil, entries...); err != nil {
+			log.Printf("Failed to repair document %s/%s: %v", r.index, id, err)
+			r.drift.Failed++
+			return
+		}
+		r.drift.Repaired++
+	})
+	r.drift.Failed += len(batch) - found
+	return err
+}
+
+// orphanCheck tells whether the row of a document still exists, remove deletes the document of a row that does not
+type orphanCheck struct {
+	index  string
+	exists func(hit types.Hit) (bool, error)
+	remove func(ctx context.Context, hit types.Hit) error
+}
+
+// removeOrphans scrolls every index for documents whose row is gone
+func removeOrphans(ctx context.Context, dryRun bool, report Report) error {
+	checks := []orphanCheck{
+		{
+			index: consts.ParticipantIndex,
+			exists: func(hit types.Hit) (bool, error) {
+				var doc models.ParticipantDocument
+				if err := json.Unmarshal(hit.Source_, &doc); err != nil {
+					return false, err
+				}
+				_, err := models.FindParticipant(doc.ConversationId, doc.UserId)
+				return rowExists(err)
+			},
+			// The sync of a participant without row deletes its document and its member id
+			remove: func(ctx context.Context, hit types.Hit) error {
+				var doc models.ParticipantDocument
+				if err := json.Unmarshal(hit.Source_, &doc); err != nil {
+					return err
+				}
+				entry, err := models.NewParticipantSyncEntry(doc.ConversationId, doc.UserId)
+				if err != nil {
+					return err
+				}
+				return models.SaveWithOutbox(nil, entry)
+			},
+		},
+		{
+			index: consts.ConversationIndex,
+			exists: func(hit types.Hit) (bool, error) {
+				_, err := models.ConversationRepository.Get(*hit.Id_)
+				return rowExists(err)
+			},
+			remove: deleteDocument(consts.ConversationIndex),
+		},
+		{
+			index: consts.ChatMessageIndex,
+			exists: func(hit types.Hit) (bool, error) {
+				_, err := models.ChatMessageRepository.Get(*hit.Id_)
+				return rowExists(err)
+			},
+			remove: deleteDocument(consts.ChatMessageIndex),
+		},
+	}
+
+	for _, check := range checks {
+		drift := report.reconciler(check.index, dryRun).drift
+		err := scrollIndex(ctx, check.index, func(hit types.Hit) {
+			exists, err := check.exists(hit)
+			if err != nil {
+				log.Printf("Failed to check document %s/%s: %v", check.index, *hit.Id_, err)
+				drift.Failed++
+				return
+			}
+			if exists {
+				return
+			}
+			drift.Orphaned++
+			if dryRun {
+				return
+			}
+
+			if err := check.remove(ctx, hit); err != nil {
+				var esErr *types.ElasticsearchError
+				if errors.As(err, &esErr) && esErr.Status == http.StatusConflict {
+					drift.Conflicts++
+					return
+				}
+				log.Printf("Failed to remove document %s/%s: %v", check.index, *hit.Id_, err)
+				drift.Failed++
+				return
+			}
+			drift.Repaired++
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rowExists(err error) (bool, error) {
+	if err == gocql.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// deleteDocument deletes the document at the sequence number it was read with, a document written meanwhile is kept
+func deleteDocument(index string) func(ctx context.Context, hit types.Hit) error {
+	return func(ctx context.Context, hit types.Hit) error {
+		request := common.ElasticsearchClient.Delete(index, *hit.Id_)
+		if hit.SeqNo_ != nil && hit.PrimaryTerm_ != nil {
+			request.IfSeqNo(strconv.FormatInt(*hit.SeqNo_, 10)).IfPrimaryTerm(strconv.FormatInt(*hit.PrimaryTerm_, 10))
+		}
+		_, err := request.Do(ctx)
+		return err
+	}
+}
+
+// scrollIndex visits every document of the index a batch at a time
+func scrollIndex(ctx context.Context, index string, visit func(hit types.Hit)) error {
+	res, err := common.ElasticsearchClient.Search().
+		Index(index).
+		Query(esdsl.NewMatchAllQuery()).
+		Size(reconcileBatchSize).
+		SeqNoPrimaryTerm(true).
+		Scroll(scrollKeepAlive).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	hits, scrollId := res.Hits.Hits, res.ScrollId_
+	defer func() {
+		if scrollId != nil {
+			if _, err := common.ElasticsearchClient.ClearScroll().ScrollId(*scrollId).Do(context.Background()); err != nil {
+				log.Printf("Failed to clear scroll of %s: %v", index, err)
+			}
+		}
+	}()
+
+	for len(hits) > 0 {
+		for _, hit := range hits {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			visit(hit)
+		}
+		if scrollId == nil {
+			return nil
+		}
+
+		next, err := common.ElasticsearchClient.Scroll().
+			ScrollId(*scrollId).
+			Scroll(esdsl.NewDuration().String(scrollKeepAlive)).
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+		hits, scrollId = next.Hits.Hits, next.ScrollId_
+	}
+	return nil
+}
+
+// sameDocument compares the documents as JSON, empty lists equal absent ones and id lists are compared as sets.
+// The ignored fields are kept by their own writes and left out of the comparison.
+func sameDocument(expected any, source json.RawMessage, ignored ...string) bool {
+	raw, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+
+	var want, got map[string]any
+	if err := json.Unmarshal(raw, &want); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(source, &got); err != nil {
+		return false
+	}
+	for _, field := range ignored {
+		delete(want, field)
+		delete(got, field)
+	}
+	return reflect.DeepEqual(normalizeDocument(want), normalizeDocument(got))
+}
+
+func normalizeDocument(doc map[string]any) map[string]any {
+	for field, value := range doc {
+		switch value := value.(type) {
+		case nil:
+			delete(doc, field)
+		case []any:
+			if len(value) == 0 {
+				delete(doc, field)
+				continue
+			}
+			slices.SortFunc(value, compareJSON)
+		}
+	}
+	return doc
+}
+
+func compareJSON(a, b any) int {
+	left, _ := json.Marshal(a)
+	right, _ := json.Marshal(b)
+	return bytes.Compare(left, right)
+}
@@ -0,0 +1,39 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/TripConnect/chat-service/store/cassandra"
+	"github.com/gocql/gocql"
+)
+
+// BackfillUnreadCounts counts the unread messages of every joined participant from their read cursor,
+// members of conversations older than the counters have none otherwise
+func BackfillUnreadCounts(ctx context.Context) (int, error) {
+	storage := cassandra.Storage{}
+	counted := 0
+	err := scanTable(ctx, models.ParticipantRepository.TableInterface, func(row any) error {
+		participant := row.(*models.ParticipantEntity)
+		if participant.Status != int(models.Joined) {
+			return nil
+		}
+
+		var after time.Time
+		var afterId gocql.UUID
+		if cursor, err := storage.GetReadCursor(participant.ConversationId, participant.UserId); err == nil {
+			after, afterId = cursor.LastReadSentTime, cursor.LastReadMessageId
+		} else if err != gocql.ErrNotFound {
+			return err
+		}
+
+		if err := store.RecountUnreadMessages(storage, participant.ConversationId, participant.UserId, after, afterId); err != nil {
+			return err
+		}
+		counted++
+		return nil
+	})
+	return counted, err
+}
@@ -4,197 +4,271 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strconv"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/TripConnect/chat-service/consts"
 	"github.com/TripConnect/chat-service/models"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/store"
 	"github.com/gocql/gocql"
-	"github.com/tripconnect/go-common-utils/advance_search"
-	"github.com/tripconnect/go-common-utils/common"
-	pb "github.com/tripconnect/go-proto-lib/protos"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func getConversationMembers(
+func (s *Server) getConversationMembers(
 	ctx context.Context,
 	conversationId gocql.UUID, status models.ParticipantStatus,
 	pagerNumber int, pageSize int) ([]models.ParticipantEntity, error) {
-	esQuery := esdsl.NewBoolQuery().
-		Must(
-			esdsl.NewMatchPhraseQuery("conversation_id", conversationId.String()),
-			esdsl.NewMatchPhraseQuery("status", strconv.Itoa(int(status))),
-		)
-
-	searchResult, err := advance_search.NewAdvanceSearch[models.ParticipantDocument]().
-		Client(common.ElasticsearchClient).
-		Query(esQuery).
-		Index(consts.ParticipantIndex).
-		Page(pagerNumber, pageSize).
-		Sort(esdsl.NewSortOptions().AddSortOption("created_at", esdsl.NewFieldSort(sortorder.Desc))).
-		Search()
-
+	docs, err := s.Search.SearchParticipants(ctx, store.ParticipantQuery{
+		ConversationId: conversationId,
+		Status:         status,
+		PageNumber:     pagerNumber,
+		PageSize:       pageSize,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	participants := []models.ParticipantEntity{}
-	for _, doc := range searchResult.Data {
-		pk := map[string]interface{}{
-			"conversation_id": conversationId,
-			"user_id":         doc.UserId,
-			"status":          int(status),
-		}
-		if participant, err := models.ParticipantRepository.Get(pk); err == nil {
-			participants = append(participants, participant.(models.ParticipantEntity))
+	for _, doc := range docs {
+		if participant, err := s.Store.GetParticipant(conversationId, doc.UserId, status); err == nil {
+			participants = append(participants, *participant)
 		}
 	}
 
 	return participants, nil
 }
 
+const userConversationsPageSize = 500
+
+// getUserConversationIds lists every conversation the user has joined
+func (s *Server) getUserConversationIds(ctx context.Context, userId gocql.UUID) ([]gocql.UUID, error) {
+	var conversationIds []gocql.UUID
+	for pageNumber := 0; ; pageNumber++ {
+		docs, err := s.Search.SearchParticipants(ctx, store.ParticipantQuery{
+			UserId:     userId,
+			Status:     models.Joined,
+			PageNumber: pageNumber,
+			PageSize:   userConversationsPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			conversationIds = append(conversationIds, doc.ConversationId)
+		}
+
+		if len(docs) < userConversationsPageSize {
+			return conversationIds, nil
+		}
+	}
+}
+
 func (s *Server) CreateConversation(ctx context.Context, req *pb.CreateConversationRequest) (*pb.Conversation, error) {
-	var conversationId gocql.UUID
-	var ownerId gocql.UUID
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	memberIds := []gocql.UUID{}
+	for _, rawMemberId := range req.GetMemberIds() {
+		memberId, err := gocql.ParseUUID(rawMemberId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid memberIds")
+		}
+		if !slices.Contains(memberIds, memberId) {
+			memberIds = append(memberIds, memberId)
+		}
+	}
+	// The caller is always a member of the conversation it creates
+	if !slices.Contains(memberIds, userId) {
+		memberIds = append(memberIds, userId)
+	}
 
 	if req.GetType() == pb.ConversationType_PRIVATE {
-		conversationId, _ = gocql.UUIDFromBytes(common.BuildUUID(req.GetMemberIds()...).Bytes())
-		ownerId, _ = gocql.ParseUUID("11111111-1111-1111-1111-111111111111")
-	} else {
-		if parsedOwnerId, ownerError := gocql.ParseUUID(req.GetOwnerId()); ownerError != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid ownerId")
-		} else {
-			conversationId = gocql.MustRandomUUID()
-			ownerId = parsedOwnerId
+		if len(memberIds) != 2 {
+			return nil, status.Error(codes.InvalidArgument, "a private conversation has exactly two members")
 		}
+		return s.createPrivateConversation(req, memberIds)
+	}
+	if strings.TrimSpace(req.GetName()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty name")
 	}
 
 	conversation := models.ConversationEntity{
-		Id:        conversationId,
+		Id:        gocql.MustRandomUUID(),
 		Name:      req.GetName(),
 		Type:      int(req.GetType()),
-		OwnerId:   ownerId,
+		OwnerId:   userId,
 		CreatedAt: time.Now(),
 	}
 
-	insertErr := models.ConversationRepository.Insert(conversation)
-	if insertErr != nil {
-		log.Fatalf("Failed to insert conversation: %v", insertErr)
-		return nil, insertErr
+	// The conversation and its participants are stored with the syncs of their documents, the relay indexes them
+	var w outboxWrite
+	w.row(models.TableRow{Table: models.ConversationRepository.TableInterface, Row: conversation})
+	participants := saveNewParticipants(&w, conversation, memberIds)
+
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to insert conversation %s: %v", conversation.Id, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
-	conversationDoc := models.NewConversationDoc(conversation, req.GetMemberIds())
-	common.ElasticsearchClient.
-		Index(consts.ConversationIndex).
-		Id(conversationDoc.Id.String()).
-		Request(&conversationDoc).
-		Do(ctx)
+	// The documents may not be indexed yet, the members are taken from the stored rows
+	pbConversation := models.NewConversationPb(conversation, participants)
 
-	for _, participantId := range req.GetMemberIds() {
-		if userId, err := gocql.ParseUUID(participantId); err == nil {
-			participant := models.ParticipantEntity{
-				ConversationId: conversation.Id,
-				UserId:         userId,
-				NickName:       "",
-				Status:         int(models.Joined),
-				CreatedAt:      time.Now(),
-			}
-			models.ParticipantRepository.Insert(participant)
-			participantDoc := models.NewParticipantDoc(participant, req.GetMemberIds())
-			common.ElasticsearchClient.
-				Index(consts.ParticipantIndex).
-				Request(&participantDoc).
-				Do(ctx)
-		}
+	return &pbConversation, nil
+}
+
+// createPrivateConversation stores the conversation of the two members under the id derived from them.
+// When it already exists it is returned as it is, its members keep their roles and status and its activity is left alone.
+func (s *Server) createPrivateConversation(req *pb.CreateConversationRequest, memberIds []gocql.UUID) (*pb.Conversation, error) {
+	rawMemberIds := []string{}
+	for _, memberId := range memberIds {
+		rawMemberIds = append(rawMemberIds, memberId.String())
 	}
 
-	// TODO: Adding to proto params
-	pbJoinedMembers, err := getConversationMembers(ctx, conversationId, models.Joined, 0, 50)
+	// Private conversations have no owner
+	conversation := models.ConversationEntity{
+		Id:        models.NewPrivateConversationId(rawMemberIds),
+		Name:      req.GetName(),
+		Type:      int(req.GetType()),
+		CreatedAt: time.Now(),
+	}
+	created, err := s.Store.InsertConversationIfNotExists(conversation)
 	if err != nil {
-		fmt.Printf("cannot get conversation memebers %s %v", conversationId, err)
-		pbJoinedMembers = []models.ParticipantEntity{}
+		log.Printf("Failed to insert conversation %s: %v", conversation.Id, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
-	pbConversation := models.NewConversationPb(conversation, pbJoinedMembers)
+	participants := []models.ParticipantEntity{}
+	if !created {
+		existing, err := s.Store.GetConversation(conversation.Id)
+		if err != nil {
+			log.Printf("Failed to get conversation %s: %v", conversation.Id, err)
+			return nil, status.Error(codes.Internal, codes.Internal.String())
+		}
+		conversation = *existing
 
+		for _, memberId := range memberIds {
+			participant, err := s.Store.GetParticipant(conversation.Id, memberId, models.Joined)
+			if err == gocql.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to get participant %s of conversation %s: %v", memberId, conversation.Id, err)
+				return nil, status.Error(codes.Internal, codes.Internal.String())
+			}
+			participants = append(participants, *participant)
+		}
+		if len(participants) > 0 {
+			pbConversation := models.NewConversationPb(conversation, participants)
+			return &pbConversation, nil
+		}
+		// No member has a row when a create stopped after the conversation row, this one finishes it
+	}
+
+	var w outboxWrite
+	participants = saveNewParticipants(&w, conversation, memberIds)
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to insert participants of conversation %s: %v", conversation.Id, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	pbConversation := models.NewConversationPb(conversation, participants)
 	return &pbConversation, nil
 }
 
+// saveNewParticipants writes the members of a new conversation with the sync of the conversation document,
+// the owner if any gets the owner role
+func saveNewParticipants(w *outboxWrite, conversation models.ConversationEntity, memberIds []gocql.UUID) []models.ParticipantEntity {
+	w.entry(models.NewConversationSyncEntry(conversation.Id))
+
+	participants := []models.ParticipantEntity{}
+	for _, memberId := range memberIds {
+		participant := models.ParticipantEntity{
+			ConversationId: conversation.Id,
+			UserId:         memberId,
+			NickName:       "",
+			Status:         int(models.Joined),
+			Role:           int(models.MemberRole),
+			CreatedAt:      time.Now(),
+		}
+		if memberId == conversation.OwnerId {
+			participant.Role = int(models.OwnerRole)
+		}
+		saveParticipant(w, participant)
+		participants = append(participants, participant)
+	}
+	return participants
+}
+
 func (s *Server) FindConversation(ctx context.Context, req *pb.FindConversationRequest) (*pb.Conversation, error) {
-	conversation, err := models.ConversationRepository.Get(req.GetConversationId())
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	conversation, err := s.Store.GetConversation(conversationId)
 	if err != nil {
 		return nil, status.Error(codes.NotFound, codes.NotFound.String())
 	}
 
+	if _, err := s.requireMember(conversation.Id, userId); err != nil {
+		return nil, err
+	}
+
 	// TODO: Move pagination of member to proto file
-	pbJoinedMembers, err := getConversationMembers(ctx, conversation.(*models.ConversationEntity).Id, models.Joined, 0, 50)
+	pbJoinedMembers, err := s.getConversationMembers(ctx, conversation.Id, models.Joined, 0, 50)
 	if err != nil {
 		fmt.Printf("cannot get conversation memebers %s %v", req.GetConversationId(), err)
 		pbJoinedMembers = []models.ParticipantEntity{}
 	}
-	pbConversation := models.NewConversationPb(*conversation.(*models.ConversationEntity), pbJoinedMembers)
+	pbConversation := models.NewConversationPb(*conversation, pbJoinedMembers)
 
 	return &pbConversation, nil
 }
 
 func (s *Server) SearchConversations(ctx context.Context, req *pb.SearchConversationsRequest) (*pb.Conversations, error) {
-	var musts []types.QueryVariant = []types.QueryVariant{
-		esdsl.NewMatchPhraseQuery("member_ids", req.GetUserId()),
-		esdsl.NewMatchPhraseQuery("type", strconv.Itoa(int(req.GetType().Number()))),
-	}
-
-	if len(req.GetTerm()) > 0 {
-		searchTerm := req.GetTerm()
-		musts = append(musts, esdsl.NewWildcardQuery("name", searchTerm))
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
 	}
 
-	esQuery := esdsl.NewBoolQuery().
-		Must(musts...)
-
-	sort := esdsl.NewSortOptions().AddSortOption("created_at", esdsl.NewFieldSort(sortorder.Desc))
-
-	searchResult, err := advance_search.NewAdvanceSearch[models.ConversationDocument]().
-		Client(common.ElasticsearchClient).
-		Query(esQuery).
-		Index(consts.ConversationIndex).
-		Page(int(req.GetPageNumber()), int(req.GetPageSize())).
-		Sort(sort).
-		Search()
+	conversationType := int(req.GetType().Number())
+	docs, err := s.Search.SearchConversations(ctx, store.ConversationQuery{
+		MemberId:   userId,
+		Type:       &conversationType,
+		Name:       req.GetTerm(),
+		PageNumber: int(req.GetPageNumber()),
+		PageSize:   int(req.GetPageSize()),
+	})
 
 	if err != nil {
-		log.Fatalf("Search failed: %v", err)
+		log.Printf("Failed to search conversations of %s: %v", userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
 	var ids []gocql.UUID
-	for _, conv := range searchResult.Data {
+	for _, conv := range docs {
 		ids = append(ids, conv.Id)
 	}
 
 	var convs []*models.ConversationEntity
 	for _, id := range ids {
-		if entity, err := models.ConversationRepository.Get(id); err == nil {
-			convs = append(convs, entity.(*models.ConversationEntity))
+		if entity, err := s.Store.GetConversation(id); err == nil {
+			convs = append(convs, entity)
 		} else {
 			fmt.Printf("failed to get conversation entity %s: %v", id, err)
 		}
 	}
 
-	var conversations []*pb.Conversation
-	for _, conv := range convs {
-		pbJoinedMembers, err := getConversationMembers(ctx, conv.Id, models.Joined, 0, 50)
-		if err != nil {
-			fmt.Printf("cannot get conversation memebers %s %v", conv.Id, err)
-			pbJoinedMembers = []models.ParticipantEntity{}
-		}
-
-		conversation := models.NewConversationPb(*conv, pbJoinedMembers)
-		conversations = append(conversations, &conversation)
-	}
-
-	result := &pb.Conversations{Conversations: conversations}
+	result := &pb.Conversations{Conversations: s.newConversationPbsForUser(ctx, convs, userId)}
 	return result, nil
 }
@@ -0,0 +1,242 @@
+package rpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCreateConversation(t *testing.T) {
+	runRpcCases(t, (*Server).CreateConversation, []rpcCase[*pb.CreateConversationRequest, *pb.Conversation]{
+		{
+			name: "unauthenticated",
+			req:  &pb.CreateConversationRequest{Type: pb.ConversationType_GROUP},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "group owned by the caller",
+			caller: ownerId,
+			req:    &pb.CreateConversationRequest{Name: ptr("Ski trip"), Type: pb.ConversationType_GROUP, MemberIds: []string{memberId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				if resp.GetType() != pb.ConversationType_GROUP || resp.GetName() != "Ski trip" || resp.GetOwnerId() != ownerId.String() {
+					t.Fatalf("conversation = %v", resp)
+				}
+				assertIds(t, resp.GetMemberIds(), ownerId, memberId)
+
+				conversationId := mustParseUUID(resp.GetId())
+				owner, err := f.storage.GetParticipant(conversationId, ownerId, models.Joined)
+				if err != nil || owner.Role != int(models.OwnerRole) {
+					t.Fatalf("owner = %v, %v", owner, err)
+				}
+				// One document for the conversation and one per participant
+				if entries := f.storage.Outbox(); len(entries) != 3 {
+					t.Fatalf("outbox entries = %d, want 3", len(entries))
+				}
+			},
+		},
+		{
+			name:   "private conversation id derived from the members",
+			caller: memberId,
+			req:    &pb.CreateConversationRequest{Type: pb.ConversationType_PRIVATE, MemberIds: []string{outsiderId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				wantId := models.NewPrivateConversationId([]string{outsiderId.String(), memberId.String()})
+				if resp.GetId() != wantId.String() {
+					t.Fatalf("id = %s, want %s", resp.GetId(), wantId)
+				}
+				if resp.GetOwnerId() != (gocql.UUID{}).String() {
+					t.Fatalf("owner = %s, want none", resp.GetOwnerId())
+				}
+				assertIds(t, resp.GetMemberIds(), memberId, outsiderId)
+			},
+		},
+		{
+			name:   "existing private conversation keeps its activity",
+			caller: ownerId,
+			req:    &pb.CreateConversationRequest{Type: pb.ConversationType_PRIVATE, MemberIds: []string{memberId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				if resp.GetId() != privateId.String() || resp.GetLastMessagePreview() != "hi" {
+					t.Fatalf("conversation = %v", resp)
+				}
+				conversation, _ := f.storage.GetConversation(privateId)
+				if conversation.LastMessageId != privateMessageId {
+					t.Fatalf("last message = %s, want %s", conversation.LastMessageId, privateMessageId)
+				}
+				assertIds(t, resp.GetMemberIds(), ownerId, memberId)
+			},
+		},
+		{
+			name:   "existing private conversation keeps its members",
+			caller: memberId,
+			setup: func(f *fixture) {
+				participant, _ := f.storage.GetParticipant(privateId, memberId, models.Joined)
+				participant.NickName = "Sam"
+				_ = f.storage.InsertParticipant(*participant)
+			},
+			req: &pb.CreateConversationRequest{Type: pb.ConversationType_PRIVATE, MemberIds: []string{ownerId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				participant, _ := f.storage.GetParticipant(privateId, memberId, models.Joined)
+				if participant.NickName != "Sam" || !participant.CreatedAt.Equal(now.Add(-time.Hour)) {
+					t.Fatalf("participant = %v", participant)
+				}
+				if entries := f.storage.Outbox(); len(entries) != 0 {
+					t.Fatalf("outbox entries = %d, want none", len(entries))
+				}
+			},
+		},
+		{
+			name:   "private conversation left without members is finished",
+			caller: memberId,
+			setup: func(f *fixture) {
+				id := models.NewPrivateConversationId([]string{outsiderId.String(), memberId.String()})
+				f.conversation(models.ConversationEntity{Id: id, Type: int(pb.ConversationType_PRIVATE), CreatedAt: now})
+			},
+			req: &pb.CreateConversationRequest{Type: pb.ConversationType_PRIVATE, MemberIds: []string{outsiderId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				assertIds(t, resp.GetMemberIds(), memberId, outsiderId)
+				if _, err := f.storage.GetParticipant(mustParseUUID(resp.GetId()), outsiderId, models.Joined); err != nil {
+					t.Fatalf("participant: %v", err)
+				}
+			},
+		},
+		{
+			name:   "private conversation with the caller alone",
+			caller: memberId,
+			req:    &pb.CreateConversationRequest{Type: pb.ConversationType_PRIVATE, MemberIds: []string{memberId.String()}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "private conversation with three members",
+			caller: memberId,
+			req:    &pb.CreateConversationRequest{Type: pb.ConversationType_PRIVATE, MemberIds: []string{ownerId.String(), outsiderId.String()}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "invalid member id",
+			caller: ownerId,
+			req:    &pb.CreateConversationRequest{Name: ptr("Ski trip"), Type: pb.ConversationType_GROUP, MemberIds: []string{"not-a-uuid"}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "group without a name",
+			caller: ownerId,
+			req:    &pb.CreateConversationRequest{Name: ptr(" "), Type: pb.ConversationType_GROUP, MemberIds: []string{memberId.String()}},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestFindConversation(t *testing.T) {
+	runRpcCases(t, (*Server).FindConversation, []rpcCase[*pb.FindConversationRequest, *pb.Conversation]{
+		{
+			name:   "group with its joined members",
+			caller: memberId,
+			req:    &pb.FindConversationRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				if resp.GetName() != "Trip planning" {
+					t.Fatalf("name = %q", resp.GetName())
+				}
+				assertIds(t, resp.GetMemberIds(), ownerId, adminId, memberId)
+			},
+		},
+		{
+			name: "unauthenticated",
+			req:  &pb.FindConversationRequest{ConversationId: groupId.String()},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "outsider",
+			caller: outsiderId,
+			req:    &pb.FindConversationRequest{ConversationId: groupId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid id",
+			caller: memberId,
+			req:    &pb.FindConversationRequest{ConversationId: "not-a-uuid"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "unknown conversation",
+			caller: memberId,
+			req:    &pb.FindConversationRequest{ConversationId: unknownId.String()},
+			code:   codes.NotFound,
+		},
+	})
+}
+
+func TestSearchConversations(t *testing.T) {
+	conversationIds := func(resp *pb.Conversations) []string {
+		ids := []string{}
+		for _, conversation := range resp.GetConversations() {
+			ids = append(ids, conversation.GetId())
+		}
+		return ids
+	}
+
+	runRpcCases(t, (*Server).SearchConversations, []rpcCase[*pb.SearchConversationsRequest, *pb.Conversations]{
+		{
+			name: "unauthenticated",
+			req:  &pb.SearchConversationsRequest{Term: "trip", PageSize: 10},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "search failure",
+			caller: memberId,
+			setup:  func(f *fixture) { f.search.Err = errors.New("index unavailable") },
+			req:    &pb.SearchConversationsRequest{Term: "trip", PageSize: 10},
+			code:   codes.Internal,
+		},
+		{
+			name:   "groups matching the name with unread counts",
+			caller: memberId,
+			req:    &pb.SearchConversationsRequest{Type: ptr(pb.ConversationType_GROUP), Term: "trip", PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversations) {
+				assertIds(t, conversationIds(resp), groupId)
+				// Only the reply of the owner was sent by someone else
+				if unread := resp.GetConversations()[0].GetUnreadCount(); unread != 1 {
+					t.Fatalf("unread = %d, want 1", unread)
+				}
+			},
+		},
+		{
+			name:   "unread count starts after the read cursor",
+			caller: memberId,
+			setup:  func(f *fixture) { f.readCursor(memberId, replyId) },
+			req:    &pb.SearchConversationsRequest{Type: ptr(pb.ConversationType_GROUP), Term: "trip", PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversations) {
+				if unread := resp.GetConversations()[0].GetUnreadCount(); unread != 0 {
+					t.Fatalf("unread = %d, want 0", unread)
+				}
+			},
+		},
+		{
+			name:   "private conversations",
+			caller: memberId,
+			req:    &pb.SearchConversationsRequest{Type: ptr(pb.ConversationType_PRIVATE), PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversations) {
+				assertIds(t, conversationIds(resp), privateId)
+			},
+		},
+		{
+			name:   "every word has to match",
+			caller: memberId,
+			req:    &pb.SearchConversationsRequest{Type: ptr(pb.ConversationType_GROUP), Term: "ski trip", PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversations) {
+				assertIds(t, conversationIds(resp))
+			},
+		},
+		{
+			name:   "conversations of others are left out",
+			caller: outsiderId,
+			req:    &pb.SearchConversationsRequest{Type: ptr(pb.ConversationType_GROUP), PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversations) {
+				assertIds(t, conversationIds(resp))
+			},
+		},
+	})
+}
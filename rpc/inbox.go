@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultInboxLimit = 20
+
+// refreshConversationPreview keeps the inbox preview in sync when the last message is edited or deleted
+func (s *Server) refreshConversationPreview(entity models.ChatMessageEntity) {
+	if _, err := s.Store.UpdateConversationPreview(entity); err != nil {
+		log.Printf("Failed to update preview of conversation %s: %v", entity.ConversationId, err)
+	}
+}
+
+// newConversationPbsForUser builds the conversations with their members and the unread count of the user
+func (s *Server) newConversationPbsForUser(ctx context.Context, conversations []*models.ConversationEntity, userId gocql.UUID) []*pb.Conversation {
+	pbConversations := make([]*pb.Conversation, len(conversations))
+	var wg sync.WaitGroup
+	wg.Add(len(conversations))
+
+	for i, conv := range conversations {
+		go func(i int, conv models.ConversationEntity) {
+			defer wg.Done()
+			pbJoinedMembers, err := s.getConversationMembers(ctx, conv.Id, models.Joined, 0, 50)
+			if err != nil {
+				fmt.Printf("cannot get conversation memebers %s %v", conv.Id, err)
+				pbJoinedMembers = []models.ParticipantEntity{}
+			}
+
+			conversation := models.NewConversationPb(conv, pbJoinedMembers)
+			if unreadCount, err := s.Store.GetUnreadCount(conv.Id, userId); err == nil {
+				conversation.UnreadCount = int32(unreadCount)
+			} else if err != gocql.ErrNotFound {
+				log.Printf("Failed to get unread count of %s: %v", conv.Id, err)
+			}
+			pbConversations[i] = &conversation
+		}(i, *conv)
+	}
+
+	wg.Wait()
+	return pbConversations
+}
+
+// The inbox cursor is the last activity in milliseconds and the id of the last conversation of the page
+func newInboxCursor(doc models.ConversationDocument) string {
+	return fmt.Sprintf("%d%s%s", doc.LastMessageAt, consts.ElasticsearchSeparator, doc.Id)
+}
+
+func parseInboxCursor(cursor string) (int64, gocql.UUID, error) {
+	rawActivity, rawId, found := strings.Cut(cursor, consts.ElasticsearchSeparator)
+	if !found {
+		return 0, gocql.UUID{}, fmt.Errorf("malformed cursor %q", cursor)
+	}
+
+	activity, err := strconv.ParseInt(rawActivity, 10, 64)
+	if err != nil {
+		return 0, gocql.UUID{}, err
+	}
+
+	id, err := gocql.ParseUUID(rawId)
+	if err != nil {
+		return 0, gocql.UUID{}, err
+	}
+
+	return activity, id, nil
+}
+
+func (s *Server) GetInbox(ctx context.Context, req *pb.GetInboxRequest) (*pb.Inbox, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultInboxLimit
+	}
+
+	query := store.ConversationQuery{MemberId: userId, PageSize: limit}
+
+	if req.GetCursor() != "" {
+		activity, id, err := parseInboxCursor(req.GetCursor())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+		query.After = &store.ActivityCursor{LastMessageAt: activity, Id: id}
+	}
+
+	docs, err := s.Search.SearchConversations(ctx, query)
+
+	if err != nil {
+		log.Printf("Failed to search inbox of %s: %v", userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	var convs []*models.ConversationEntity
+	for _, doc := range docs {
+		if entity, err := s.Store.GetConversation(doc.Id); err == nil {
+			convs = append(convs, entity)
+		} else {
+			fmt.Printf("failed to get conversation entity %s: %v", doc.Id, err)
+		}
+	}
+
+	inbox := &pb.Inbox{Conversations: s.newConversationPbsForUser(ctx, convs, userId)}
+	if len(docs) == limit {
+		inbox.NextCursor = newInboxCursor(docs[len(docs)-1])
+	}
+
+	return inbox, nil
+}
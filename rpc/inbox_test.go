@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"google.golang.org/grpc/codes"
+)
+
+func TestGetInbox(t *testing.T) {
+	inboxIds := func(resp *pb.Inbox) []string {
+		ids := []string{}
+		for _, conversation := range resp.GetConversations() {
+			ids = append(ids, conversation.GetId())
+		}
+		return ids
+	}
+	privateCursor := newInboxCursor(models.ConversationDocument{Id: privateId, LastMessageAt: int(now.Add(-time.Minute).UnixMilli())})
+
+	runRpcCases(t, (*Server).GetInbox, []rpcCase[*pb.GetInboxRequest, *pb.Inbox]{
+		{
+			name: "unauthenticated",
+			req:  &pb.GetInboxRequest{},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "most recent activity first",
+			caller: memberId,
+			req:    &pb.GetInboxRequest{},
+			check: func(t *testing.T, f *fixture, resp *pb.Inbox) {
+				assertOrderedIds(t, inboxIds(resp), privateId, groupId)
+				if resp.GetNextCursor() != "" {
+					t.Fatalf("next cursor = %q, want none", resp.GetNextCursor())
+				}
+			},
+		},
+		{
+			name:   "full page returns a cursor",
+			caller: memberId,
+			req:    &pb.GetInboxRequest{Limit: 1},
+			check: func(t *testing.T, f *fixture, resp *pb.Inbox) {
+				assertOrderedIds(t, inboxIds(resp), privateId)
+				if resp.GetNextCursor() != privateCursor {
+					t.Fatalf("next cursor = %q, want %q", resp.GetNextCursor(), privateCursor)
+				}
+			},
+		},
+		{
+			name:   "next page",
+			caller: memberId,
+			req:    &pb.GetInboxRequest{Cursor: privateCursor, Limit: 1},
+			check: func(t *testing.T, f *fixture, resp *pb.Inbox) {
+				assertOrderedIds(t, inboxIds(resp), groupId)
+			},
+		},
+		{
+			name:   "malformed cursor",
+			caller: memberId,
+			req:    &pb.GetInboxRequest{Cursor: "yesterday"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "empty inbox",
+			caller: outsiderId,
+			req:    &pb.GetInboxRequest{},
+			check: func(t *testing.T, f *fixture, resp *pb.Inbox) {
+				assertOrderedIds(t, inboxIds(resp))
+			},
+		},
+	})
+}
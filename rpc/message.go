@@ -2,107 +2,185 @@ package rpc
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/TripConnect/chat-service/consts"
 	"github.com/TripConnect/chat-service/models"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
-	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/store"
 	"github.com/gocql/gocql"
-	"github.com/tripconnect/go-common-utils/advance_search"
-	"github.com/tripconnect/go-common-utils/common"
 	"github.com/tripconnect/go-common-utils/helper"
-	pb "github.com/tripconnect/go-proto-lib/protos"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	maxIdempotencyKeyLength  = 128
+	defaultChatMessagesLimit = 10
 )
 
 func (s *Server) CreateChatMessage(ctx context.Context, req *pb.CreateChatMessageRequest) (*pb.CreateChatMessageAck, error) {
-	fromUserId, fromUserIdErr := gocql.ParseUUID(req.FromUserId)
+	fromUserId, authErr := callerId(ctx)
 	convId, convIdErr := gocql.ParseUUID(req.ConversationId)
 
-	if fromUserIdErr != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid fromUserId")
+	if authErr != nil {
+		return nil, authErr
 	}
 
 	if convIdErr != nil {
 		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
 	}
 
+	if _, err := s.requireMember(convId, fromUserId); err != nil {
+		return nil, err
+	}
+
+	replyToMessageId, threadRootId, err := s.resolveReplyReferences(convId, req.ReplyToMessageId, req.ThreadRootId)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyKey := req.GetIdempotencyKey()
+	if req.IdempotencyKey != nil && (idempotencyKey == "" || len(idempotencyKey) > maxIdempotencyKeyLength) {
+		return nil, status.Error(codes.InvalidArgument, "invalid idempotencyKey")
+	}
+
 	chatMessage := &models.KafkaPendingMessage{
-		ConversationId: convId,
-		MessageId:      gocql.MustRandomUUID(),
-		FromUserId:     fromUserId,
-		Content:        req.GetContent(),
-		SentTime:       time.Now(),
+		ConversationId:   convId,
+		MessageId:        gocql.MustRandomUUID(),
+		FromUserId:       fromUserId,
+		Content:          req.GetContent(),
+		SentTime:         time.Now(),
+		ReplyToMessageId: replyToMessageId,
+		ThreadRootId:     threadRootId,
+		IdempotencyKey:   idempotencyKey,
+	}
+
+	if idempotencyKey != "" {
+		ownerId, err := s.claimIdempotencyKey(convId, fromUserId, idempotencyKey, chatMessage.MessageId)
+		if err != nil {
+			log.Printf("Failed to claim idempotency key of %s: %v", fromUserId, err)
+			return nil, status.Error(codes.Internal, codes.Internal.String())
+		}
+		if ownerId != chatMessage.MessageId {
+			return &pb.CreateChatMessageAck{CorrelationId: ownerId.String(), MessageId: ownerId.String()}, nil
+		}
+	}
+
+	if err := s.Store.SaveMessageStatus(chatMessage.MessageId, convId, fromUserId, models.MessagePending, ""); err != nil {
+		log.Printf("Failed to save status of message %s: %v", chatMessage.MessageId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
 	pendingTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-sys-internal-pending-queue")
-	if err := common.Publish(ctx, pendingTopic, chatMessage); err != nil {
+	if err := s.Events.PublishKeyed(ctx, pendingTopic, convId.String(), chatMessage); err != nil {
 		log.Printf("Create chat message failed %s", err.Error())
+		_ = s.Store.SaveMessageStatus(chatMessage.MessageId, convId, fromUserId, models.MessageFailed, models.FailureEnqueueFailed)
+		if idempotencyKey != "" {
+			// Release the key so that the client retry enqueues the message
+			_ = s.Store.ReleaseIdempotencyKey(convId, fromUserId, idempotencyKey, chatMessage.MessageId)
+		}
 		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
-	// TODO: Refactor using MessageId insteads
 	chatMessagePb := &pb.CreateChatMessageAck{
 		CorrelationId: chatMessage.MessageId.String(),
+		MessageId:     chatMessage.MessageId.String(),
 	}
 
 	return chatMessagePb, nil
 }
 
-func (s *Server) GetChatMessages(ctx context.Context, req *pb.GetChatMessagesRequest) (*pb.ChatMessages, error) {
-	var musts []types.QueryVariant = []types.QueryVariant{
-		esdsl.NewMatchPhraseQuery("conversation_id", req.GetConversationId()),
+// claimIdempotencyKey returns the message owning the key, a failed owner whose key was not released when it was
+// dead lettered gives the key up so that the retry is sent
+func (s *Server) claimIdempotencyKey(convId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) (gocql.UUID, error) {
+	ownerId, err := s.Store.ClaimIdempotencyKey(convId, fromUserId, key, messageId)
+	if err != nil || ownerId == messageId {
+		return ownerId, err
 	}
 
-	if req.GetBefore() != nil {
-		before := types.Float64(req.GetBefore().AsTime().UnixMilli())
-		musts = append(musts, esdsl.NewNumberRangeQuery("sent_time").Lt(before))
+	owner, err := s.Store.GetMessageStatus(ownerId)
+	if err != nil || models.DeliveryStatus(owner.Status) != models.MessageFailed {
+		return ownerId, nil
+	}
+	if err := s.Store.ReleaseIdempotencyKey(convId, fromUserId, key, ownerId); err != nil {
+		return gocql.UUID{}, err
 	}
+	return s.Store.ClaimIdempotencyKey(convId, fromUserId, key, messageId)
+}
 
-	if req.GetAfter() != nil {
-		after := types.Float64(req.GetAfter().AsTime().UnixMilli())
-		musts = append(musts, esdsl.NewNumberRangeQuery("sent_time").Gt(after))
+// GetMessageStatus reports the send state of a message to its sender
+func (s *Server) GetMessageStatus(ctx context.Context, req *pb.GetMessageStatusRequest) (*pb.MessageStatus, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	messageId, messageIdErr := gocql.ParseUUID(req.GetMessageId())
+	if messageIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid messageId")
+	}
+
+	entity, err := s.Store.GetMessageStatus(messageId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	if entity.FromUserId != userId {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	pbStatus := models.NewMessageStatusPb(*entity)
+	return &pbStatus, nil
+}
+
+func (s *Server) GetChatMessages(ctx context.Context, req *pb.GetChatMessagesRequest) (*pb.ChatMessages, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	convId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
 	}
 
-	esQuery := esdsl.NewBoolQuery().
-		Must(musts...)
+	if _, err := s.requireMember(convId, userId); err != nil {
+		return nil, err
+	}
 
-	sort := esdsl.NewSortOptions().AddSortOption("sent_time", esdsl.NewFieldSort(sortorder.Desc))
+	var before, after time.Time
+	if req.GetBefore() != nil {
+		before = req.GetBefore().AsTime()
+	}
+	if req.GetAfter() != nil {
+		after = req.GetAfter().AsTime()
+	}
 
-	searchResult, err := advance_search.NewAdvanceSearch[models.ChatMessageDocument]().
-		Client(common.ElasticsearchClient).
-		Query(esQuery).
-		Index(consts.ChatMessageIndex).
-		PageSize(int(req.GetLimit())).
-		Sort(sort).
-		Search()
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultChatMessagesLimit
+	}
 
+	// Tombstoned messages stay in history as placeholders, messages deleted for the caller only are dropped
+	messages, err := s.Store.ListConversationHistory(convId, userId, before, after, limit)
 	if err != nil {
+		log.Printf("Failed to list messages of %s: %v", convId, err)
 		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
-	docs := searchResult.Data
-
-	pbMessages := make([]*pb.ChatMessage, len(docs))
+	pbMessages := make([]*pb.ChatMessage, len(messages))
 	var wg sync.WaitGroup
-	wg.Add(len(docs))
+	wg.Add(len(messages))
 
-	for i, doc := range docs {
-		go func(i int, docId gocql.UUID) {
+	for i, message := range messages {
+		go func(i int, message models.ChatMessageEntity) {
 			defer wg.Done()
-			if message, err := models.ChatMessageRepository.Get(docId); err == nil {
-				pbMsg := models.NewChatMessagePb(*message.(*models.ChatMessageEntity))
-				pbMessages[i] = &pbMsg
-			} else {
-				fmt.Printf("Failed to get message for id %q: %v\n", docId, err)
-			}
-		}(i, doc.Id)
+			pbMessages[i] = s.newChatMessagePbWithReactions(message, userId)
+		}(i, message)
 	}
 
 	wg.Wait()
@@ -112,48 +190,60 @@ func (s *Server) GetChatMessages(ctx context.Context, req *pb.GetChatMessagesReq
 }
 
 func (s *Server) SearchChatMessages(ctx context.Context, req *pb.SearchChatMessagesRequest) (*pb.ChatMessages, error) {
-	var musts []types.QueryVariant
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
 
-	if req.GetTerm() != "" {
-		musts = append(musts, esdsl.NewWildcardQuery("content", req.GetTerm()))
+	query := store.ChatMessageQuery{
+		Term:           req.GetTerm(),
+		Phrase:         req.GetPhrase(),
+		ViewerId:       userId,
+		ExcludeDeleted: true,
+		PageSize:       int(req.GetLimit()),
 	}
 
 	if req.GetConversationId() != "" {
-		musts = append(musts, esdsl.NewMatchPhraseQuery("conversation_id", req.GetConversationId()))
+		convId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+		if convIdErr != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+		}
+		if _, err := s.requireMember(convId, userId); err != nil {
+			return nil, err
+		}
+		query.ConversationIds = []gocql.UUID{convId}
+	} else {
+		conversationIds, err := s.getUserConversationIds(ctx, userId)
+		if err != nil {
+			log.Printf("Failed to get conversations of %s: %v", userId, err)
+			return nil, status.Error(codes.Internal, codes.Internal.String())
+		}
+		if len(conversationIds) == 0 {
+			return &pb.ChatMessages{}, nil
+		}
+		query.ConversationIds = conversationIds
 	}
 
 	if req.GetBefore() != nil {
-		before := types.Float64(req.GetBefore().AsTime().UnixMilli())
-		musts = append(musts, esdsl.NewNumberRangeQuery("sent_time").Lt(before))
+		query.Before = req.GetBefore().AsTime()
 	}
 
 	if req.GetAfter() != nil {
-		after := types.Float64(req.GetAfter().AsTime().UnixMilli())
-		musts = append(musts, esdsl.NewNumberRangeQuery("sent_time").Gt(after))
+		query.After = req.GetAfter().AsTime()
 	}
 
-	var esQuery types.QueryVariant = esdsl.NewBoolQuery().
-		Must(musts...)
-
-	var sort types.SortCombinationsVariant = esdsl.NewSortOptions().
-		AddSortOption("sent_time", esdsl.NewFieldSort(sortorder.Desc))
-
-	searchResult, err := advance_search.NewAdvanceSearch[models.ChatMessageDocument]().
-		Client(common.ElasticsearchClient).
-		Query(esQuery).
-		Index(consts.ChatMessageIndex).
-		PageSize(int(req.GetLimit())).
-		Sort(sort).
-		Search()
+	hits, err := s.Search.SearchChatMessages(ctx, query)
 
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to search chat messages of %s: %v", userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
 	}
 
 	var pbMessages []*pb.ChatMessage
-	for _, doc := range searchResult.Data {
-		if message, err := models.ChatMessageRepository.Get(doc.Id); err == nil {
-			pbMessage := models.NewChatMessagePb(*message.(*models.ChatMessageEntity))
+	for _, hit := range hits {
+		if message, err := s.Store.GetChatMessage(hit.Id); err == nil {
+			pbMessage := models.NewChatMessagePb(*message)
+			pbMessage.Highlights = hit.Highlights
 			pbMessages = append(pbMessages, &pbMessage)
 		}
 	}
@@ -161,3 +251,284 @@ func (s *Server) SearchChatMessages(ctx context.Context, req *pb.SearchChatMessa
 	response := &pb.ChatMessages{Messages: pbMessages}
 	return response, nil
 }
+
+const replayPageSize = 100
+
+// replayChatMessages sends the messages persisted after the cursor, each conversation in chronological order.
+// They are read from the conversation history, the search index may lag behind and caps how deep it pages.
+func (s *Server) replayChatMessages(userId gocql.UUID, conversationIds []gocql.UUID, after time.Time, stream pb.ChatService_SubscribeConversationServer) (map[gocql.UUID]struct{}, error) {
+	replayed := map[gocql.UUID]struct{}{}
+	for _, conversationId := range conversationIds {
+		cursorTime, cursorId := after, gocql.UUID{}
+		for {
+			messages, err := s.Store.ListConversationHistoryAfter(conversationId, userId, cursorTime, cursorId, replayPageSize)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, message := range messages {
+				pbMessage := models.NewChatMessagePb(message)
+				if err := stream.Send(&pbMessage); err != nil {
+					return nil, err
+				}
+				replayed[message.Id] = struct{}{}
+			}
+
+			if len(messages) < replayPageSize {
+				break
+			}
+			cursorTime, cursorId = messages[len(messages)-1].SentTime, messages[len(messages)-1].Id
+		}
+	}
+	return replayed, nil
+}
+
+func (s *Server) SubscribeConversation(req *pb.SubscribeConversationRequest, stream pb.ChatService_SubscribeConversationServer) error {
+	if len(req.GetConversationIds()) == 0 {
+		return status.Error(codes.InvalidArgument, "missing conversationIds")
+	}
+
+	userId, authErr := callerId(stream.Context())
+	if authErr != nil {
+		return authErr
+	}
+
+	conversationIds := make([]gocql.UUID, len(req.GetConversationIds()))
+	for i, rawId := range req.GetConversationIds() {
+		conversationId, err := gocql.ParseUUID(rawId)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid conversationIds")
+		}
+		if _, err := s.requireMember(conversationId, userId); err != nil {
+			return err
+		}
+		conversationIds[i] = conversationId
+	}
+
+	// Subscribe before replaying so messages persisted meanwhile are buffered instead of lost
+	subscription := s.Hub.Subscribe(userId, conversationIds)
+	defer s.Hub.Unsubscribe(subscription)
+
+	replayed := map[gocql.UUID]struct{}{}
+	if req.GetAfter() != nil {
+		var err error
+		if replayed, err = s.replayChatMessages(userId, conversationIds, req.GetAfter().AsTime(), stream); err != nil {
+			log.Printf("Replay chat messages failed %v", err)
+			return status.Error(codes.Internal, codes.Internal.String())
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-subscription.Overflow():
+			return status.Error(codes.ResourceExhausted, "subscriber too slow, resume from the last received message")
+		case <-subscription.Revoked():
+			return status.Error(codes.PermissionDenied, "not a member of the subscribed conversations anymore")
+		case message := <-subscription.Messages():
+			if _, ok := replayed[message.Id]; ok {
+				continue
+			}
+			// Membership was checked when subscribing, the hub drops the conversations the caller leaves or is removed from
+			if !s.Hub.Follows(subscription, message.ConversationId) {
+				continue
+			}
+			pbMessage := models.NewChatMessagePb(message)
+			if err := stream.Send(&pbMessage); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) EditChatMessage(ctx context.Context, req *pb.EditChatMessageRequest) (*pb.ChatMessage, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	messageId, messageIdErr := gocql.ParseUUID(req.GetMessageId())
+	if messageIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid messageId")
+	}
+
+	if strings.TrimSpace(req.GetContent()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty content")
+	}
+
+	message, err := s.Store.GetChatMessage(messageId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+	entity := *message
+
+	if entity.FromUserId != userId {
+		return nil, status.Error(codes.PermissionDenied, "only the sender can edit the message")
+	}
+
+	if _, err := s.requireMember(entity.ConversationId, userId); err != nil {
+		return nil, err
+	}
+
+	if !entity.DeletedAt.IsZero() {
+		return nil, status.Error(codes.FailedPrecondition, "the message was deleted")
+	}
+
+	if entity.Content == req.GetContent() {
+		pbMessage := models.NewChatMessagePb(entity)
+		return &pbMessage, nil
+	}
+
+	editedAt := time.Now()
+	history := models.ChatMessageHistoryEntity{
+		MessageId:  entity.Id,
+		Content:    entity.Content,
+		ReplacedAt: editedAt,
+	}
+	if err := s.Store.InsertChatMessageHistory(history); err != nil {
+		log.Printf("Failed to save history of message %s: %v", messageId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	entity.Content = req.GetContent()
+	entity.EditedAt = editedAt
+	edited, err := s.Store.EditChatMessage(entity)
+	if err != nil {
+		log.Printf("Failed to edit message %s: %v", messageId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	if !edited {
+		// Deleted for everyone since it was read, the deletion already cleared the history
+		if err := s.Store.DeleteChatMessageHistory(messageId); err != nil {
+			log.Printf("Failed to clear history of deleted message %s: %v", messageId, err)
+		}
+		return nil, status.Error(codes.FailedPrecondition, "the message was deleted")
+	}
+
+	// The guarded edit cannot share a batch with the outbox, the entries are written once it applied.
+	// The sync reads the row when relayed so it carries the edit whichever entry of the message comes last.
+	editedChatMessageTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-edited-message")
+	var w outboxWrite
+	w.entry(models.NewMessageSyncEntry(entity))
+	w.entry(models.NewPublishEntry(entity.ConversationId, editedChatMessageTopic, &models.KafkaEditedMessage{
+		Id:             entity.Id,
+		ConversationId: entity.ConversationId,
+		FromUserId:     entity.FromUserId,
+		Content:        entity.Content,
+		EditedAt:       entity.EditedAt,
+	}))
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to write outbox of edited message %s: %v", messageId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	s.refreshConversationPreview(entity)
+
+	pbMessage := models.NewChatMessagePb(entity)
+	return &pbMessage, nil
+}
+
+const defaultDeleteForEveryoneWindow = time.Hour
+
+func deleteForEveryoneWindow() time.Duration {
+	seconds, err := helper.ReadConfig[int]("chat.message.delete-for-everyone-window-seconds")
+	if err != nil || seconds <= 0 {
+		return defaultDeleteForEveryoneWindow
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func hideChatMessage(w *outboxWrite, userId gocql.UUID, entity models.ChatMessageEntity, deletedAt time.Time) {
+	hidden := models.HiddenChatMessageEntity{
+		UserId:         userId,
+		MessageId:      entity.Id,
+		ConversationId: entity.ConversationId,
+		HiddenAt:       deletedAt,
+	}
+	w.row(models.TableRow{Table: models.HiddenChatMessageRepository.TableInterface, Row: hidden})
+	w.entry(models.NewHideEntry(hidden))
+}
+
+// tombstoneChatMessage clears the content and edit history so the message only remains as a placeholder
+func tombstoneChatMessage(w *outboxWrite, userId gocql.UUID, entity models.ChatMessageEntity, deletedAt time.Time) models.ChatMessageEntity {
+	entity.Content = ""
+	entity.DeletedAt = deletedAt
+	entity.DeletedBy = userId
+	w.row(models.TableRow{Table: models.ChatMessageRepository.TableInterface, Row: entity})
+	for _, row := range models.NewChatMessageRows(entity) {
+		w.row(row)
+	}
+	w.row(models.TableRow{Table: models.ChatMessageHistoryRepository.TableInterface, DeletePartition: true,
+		Row: models.ChatMessageHistoryEntity{MessageId: entity.Id}})
+	w.entry(models.NewMessageSyncEntry(entity))
+	return entity
+}
+
+func (s *Server) DeleteChatMessage(ctx context.Context, req *pb.DeleteChatMessageRequest) (*emptypb.Empty, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	messageId, messageIdErr := gocql.ParseUUID(req.GetMessageId())
+	if messageIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid messageId")
+	}
+
+	message, err := s.Store.GetChatMessage(messageId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+	entity := *message
+
+	if _, err := s.requireMember(entity.ConversationId, userId); err != nil {
+		return nil, err
+	}
+
+	deletedAt := time.Now()
+	mode := models.DeletedForMe
+
+	if req.GetForEveryone() {
+		mode = models.DeletedForEveryone
+		if !entity.DeletedAt.IsZero() {
+			return &emptypb.Empty{}, nil
+		}
+
+		// Senders may take their message back within the window, moderators at any time
+		isSenderInWindow := entity.FromUserId == userId && deletedAt.Sub(entity.SentTime) <= deleteForEveryoneWindow()
+		if !isSenderInWindow {
+			conversation, err := s.Store.GetConversation(entity.ConversationId)
+			if err != nil {
+				return nil, status.Error(codes.NotFound, codes.NotFound.String())
+			}
+			if _, err := s.authorize(*conversation, userId, deleteMessages); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var w outboxWrite
+	if mode == models.DeletedForEveryone {
+		entity = tombstoneChatMessage(&w, userId, entity, deletedAt)
+	} else {
+		hideChatMessage(&w, userId, entity, deletedAt)
+	}
+
+	deletedChatMessageTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-deleted-message")
+	w.entry(models.NewPublishEntry(entity.ConversationId, deletedChatMessageTopic, &models.KafkaDeletedMessage{
+		Id:             entity.Id,
+		ConversationId: entity.ConversationId,
+		DeletedBy:      userId,
+		Mode:           mode,
+		DeletedAt:      deletedAt,
+	}))
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to delete message %s: %v", messageId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	if mode == models.DeletedForEveryone {
+		s.refreshConversationPreview(entity)
+	}
+
+	return &emptypb.Empty{}, nil
+}
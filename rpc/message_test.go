@@ -0,0 +1,708 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func pendingMessage(t *testing.T, f *fixture) *models.KafkaPendingMessage {
+	t.Helper()
+	events := f.events.Published()
+	if len(events) != 1 {
+		t.Fatalf("published %d events, want 1", len(events))
+	}
+	message, ok := events[0].Data.(*models.KafkaPendingMessage)
+	if !ok {
+		t.Fatalf("published %T, want a pending message", events[0].Data)
+	}
+	return message
+}
+
+func TestCreateChatMessage(t *testing.T) {
+	existingId := gocql.MustRandomUUID()
+
+	runRpcCases(t, (*Server).CreateChatMessage, []rpcCase[*pb.CreateChatMessageRequest, *pb.CreateChatMessageAck]{
+		{
+			name:   "member sends a message",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "On my way"},
+			check: func(t *testing.T, f *fixture, resp *pb.CreateChatMessageAck) {
+				message := pendingMessage(t, f)
+				if resp.GetMessageId() != message.MessageId.String() || message.Content != "On my way" || message.FromUserId != memberId {
+					t.Fatalf("ack %v for pending message %v", resp, message)
+				}
+				// The conversation is the key so its messages keep their order
+				if key := f.events.Published()[0].Key; key != groupId.String() {
+					t.Fatalf("key = %q, want %q", key, groupId)
+				}
+				messageStatus, err := f.storage.GetMessageStatus(message.MessageId)
+				if err != nil || messageStatus.Status != int(models.MessagePending) {
+					t.Fatalf("status = %v, %v, want pending", messageStatus, err)
+				}
+			},
+		},
+		{
+			name:   "reply to a thread reply joins the thread",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "See you", ReplyToMessageId: ptr(replyId.String())},
+			check: func(t *testing.T, f *fixture, resp *pb.CreateChatMessageAck) {
+				message := pendingMessage(t, f)
+				if message.ReplyToMessageId != replyId || message.ThreadRootId != messageId {
+					t.Fatalf("reply to %s in thread %s", message.ReplyToMessageId, message.ThreadRootId)
+				}
+			},
+		},
+		{
+			name:   "thread root cannot be a reply",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "See you", ThreadRootId: ptr(replyId.String())},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "reply to another conversation",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "See you", ReplyToMessageId: ptr(privateMessageId.String())},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "reply to an unknown message",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "See you", ReplyToMessageId: ptr(unknownId.String())},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "retry returns the first ack",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_, _ = f.storage.ClaimIdempotencyKey(groupId, memberId, "retry-1", existingId)
+			},
+			req: &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "On my way", IdempotencyKey: ptr("retry-1")},
+			check: func(t *testing.T, f *fixture, resp *pb.CreateChatMessageAck) {
+				if resp.GetMessageId() != existingId.String() {
+					t.Fatalf("message id = %s, want %s", resp.GetMessageId(), existingId)
+				}
+				if events := f.events.Published(); len(events) != 0 {
+					t.Fatalf("published %d events, want none", len(events))
+				}
+			},
+		},
+		{
+			name:   "retry of a failed message sends it again",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_, _ = f.storage.ClaimIdempotencyKey(groupId, memberId, "retry-1", existingId)
+				_ = f.storage.SaveMessageStatus(existingId, groupId, memberId, models.MessageFailed, "")
+			},
+			req: &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "On my way", IdempotencyKey: ptr("retry-1")},
+			check: func(t *testing.T, f *fixture, resp *pb.CreateChatMessageAck) {
+				message := pendingMessage(t, f)
+				if resp.GetMessageId() == existingId.String() || resp.GetMessageId() != message.MessageId.String() {
+					t.Fatalf("ack %v for pending message %v, want a new message", resp, message)
+				}
+				if ownerId, _ := f.storage.ClaimIdempotencyKey(groupId, memberId, "retry-1", gocql.MustRandomUUID()); ownerId != message.MessageId {
+					t.Fatalf("key owned by %s, want %s", ownerId, message.MessageId)
+				}
+			},
+		},
+		{
+			name:   "empty idempotency key",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "On my way", IdempotencyKey: ptr("")},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "outsider cannot send",
+			caller: outsiderId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "Hello"},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid conversation id",
+			caller: memberId,
+			req:    &pb.CreateChatMessageRequest{ConversationId: "group", Content: "Hello"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name: "unauthenticated",
+			req:  &pb.CreateChatMessageRequest{ConversationId: groupId.String(), Content: "Hello"},
+			code: codes.Unauthenticated,
+		},
+	})
+}
+
+func TestGetMessageStatus(t *testing.T) {
+	persisted := func(f *fixture) {
+		_ = f.storage.SaveMessageStatus(messageId, groupId, memberId, models.MessagePersisted, "")
+	}
+
+	runRpcCases(t, (*Server).GetMessageStatus, []rpcCase[*pb.GetMessageStatusRequest, *pb.MessageStatus]{
+		{
+			name:   "sender reads the status",
+			caller: memberId,
+			setup:  persisted,
+			req:    &pb.GetMessageStatusRequest{MessageId: messageId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.MessageStatus) {
+				if resp.GetStatus() != pb.MessageDeliveryStatus_PERSISTED {
+					t.Fatalf("status = %v, want PERSISTED", resp.GetStatus())
+				}
+			},
+		},
+		{
+			name:   "hidden from other users",
+			caller: ownerId,
+			setup:  persisted,
+			req:    &pb.GetMessageStatusRequest{MessageId: messageId.String()},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "unknown message",
+			caller: memberId,
+			req:    &pb.GetMessageStatusRequest{MessageId: unknownId.String()},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "invalid message id",
+			caller: memberId,
+			req:    &pb.GetMessageStatusRequest{MessageId: "message"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestGetChatMessages(t *testing.T) {
+	runRpcCases(t, (*Server).GetChatMessages, []rpcCase[*pb.GetChatMessagesRequest, *pb.ChatMessages]{
+		{
+			name:   "newest first",
+			caller: memberId,
+			req:    &pb.GetChatMessagesRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertOrderedIds(t, messageIds(resp.GetMessages()), replyId, messageId, oldMessageId)
+			},
+		},
+		{
+			name:   "limit",
+			caller: memberId,
+			req:    &pb.GetChatMessagesRequest{ConversationId: groupId.String(), Limit: 1},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertOrderedIds(t, messageIds(resp.GetMessages()), replyId)
+			},
+		},
+		{
+			name:   "before and after",
+			caller: memberId,
+			req: &pb.GetChatMessagesRequest{ConversationId: groupId.String(),
+				Before: timestamppb.New(now.Add(-6 * time.Minute)), After: timestamppb.New(now.Add(-time.Hour))},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertOrderedIds(t, messageIds(resp.GetMessages()), messageId)
+			},
+		},
+		{
+			name:   "messages deleted for the caller are dropped, tombstones stay",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_ = f.storage.InsertHiddenChatMessage(models.HiddenChatMessageEntity{UserId: memberId, MessageId: oldMessageId, ConversationId: groupId, HiddenAt: now})
+				f.tombstone(messageId)
+			},
+			req: &pb.GetChatMessagesRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertOrderedIds(t, messageIds(resp.GetMessages()), replyId, messageId)
+				if tombstone := resp.GetMessages()[1]; tombstone.GetContent() != "" || tombstone.GetDeletedAt() == nil {
+					t.Fatalf("tombstone = %v", tombstone)
+				}
+			},
+		},
+		{
+			name:   "reactions as seen by the caller",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_ = f.storage.InsertMessageReaction(models.MessageReactionEntity{MessageId: replyId, Emoji: "👍", UserId: ownerId, CreatedAt: now})
+				_ = f.storage.InsertMessageReaction(models.MessageReactionEntity{MessageId: replyId, Emoji: "👍", UserId: memberId, CreatedAt: now})
+			},
+			req: &pb.GetChatMessagesRequest{ConversationId: groupId.String(), Limit: 1},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				reactions := resp.GetMessages()[0].GetReactions()
+				if len(reactions) != 1 || reactions[0].GetEmoji() != "👍" || reactions[0].GetCount() != 2 || !reactions[0].GetReactedByMe() {
+					t.Fatalf("reactions = %v", reactions)
+				}
+			},
+		},
+		{
+			name:   "outsider cannot read",
+			caller: outsiderId,
+			req:    &pb.GetChatMessagesRequest{ConversationId: groupId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid conversation id",
+			caller: memberId,
+			req:    &pb.GetChatMessagesRequest{ConversationId: "group"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestSearchChatMessages(t *testing.T) {
+	stationMessageId := mustParseUUID("00000000-0000-0000-0000-000000001006")
+	cafeMessageId := mustParseUUID("00000000-0000-0000-0000-000000001007")
+	olderMessages := func(f *fixture) {
+		f.message(models.ChatMessageEntity{Id: stationMessageId, ConversationId: groupId, FromUserId: adminId,
+			Content: "The station", SentTime: now.Add(-3 * time.Hour)})
+		f.message(models.ChatMessageEntity{Id: cafeMessageId, ConversationId: groupId, FromUserId: adminId,
+			Content: "Café after the museum?", SentTime: now.Add(-4 * time.Hour)})
+	}
+	highlights := func(resp *pb.ChatMessages) []string {
+		fragments := []string{}
+		for _, message := range resp.GetMessages() {
+			fragments = append(fragments, message.GetHighlights()...)
+		}
+		return fragments
+	}
+
+	runRpcCases(t, (*Server).SearchChatMessages, []rpcCase[*pb.SearchChatMessagesRequest, *pb.ChatMessages]{
+		{
+			name:   "search failure",
+			caller: memberId,
+			setup:  func(f *fixture) { f.search.Err = errors.New("index unavailable") },
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "hotel", Limit: 10},
+			code:   codes.Internal,
+		},
+		{
+			name:   "term in one conversation with highlights",
+			caller: memberId,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "HOTEL", Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()), oldMessageId)
+				assertStrings(t, highlights(resp), "Booked the <em>hotel</em>")
+			},
+		},
+		{
+			name:   "highlights escape the content",
+			caller: memberId,
+			setup: func(f *fixture) {
+				f.message(models.ChatMessageEntity{Id: stationMessageId, ConversationId: groupId, FromUserId: adminId,
+					Content: "<img src=x onerror=alert(1)> hotel", SentTime: now.Add(-3 * time.Hour)})
+			},
+			req: &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "hotel", Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertStrings(t, highlights(resp), "Booked the <em>hotel</em>", "&lt;img src=x onerror=alert(1)&gt; <em>hotel</em>")
+			},
+		},
+		{
+			name:   "accents are folded",
+			caller: memberId,
+			setup:  olderMessages,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "cafe", Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertStrings(t, highlights(resp), "<em>Café</em> after the museum?")
+			},
+		},
+		{
+			name:   "most relevant first, then newest",
+			caller: memberId,
+			setup:  olderMessages,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "the station", Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertOrderedIds(t, messageIds(resp.GetMessages()), stationMessageId, replyId)
+			},
+		},
+		{
+			name:   "words anywhere in the content",
+			caller: memberId,
+			setup:  olderMessages,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "museum cafe", Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()), cafeMessageId)
+			},
+		},
+		{
+			name:   "phrase keeps the words in order",
+			caller: memberId,
+			setup:  olderMessages,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "museum cafe", Phrase: true, Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()))
+			},
+		},
+		{
+			name:   "phrase",
+			caller: memberId,
+			setup:  olderMessages,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Term: "after the museum", Phrase: true, Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()), cafeMessageId)
+				assertStrings(t, highlights(resp), "Café <em>after</em> <em>the</em> <em>museum</em>?")
+			},
+		},
+		{
+			name:   "every conversation of the caller",
+			caller: memberId,
+			req:    &pb.SearchChatMessagesRequest{Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()), oldMessageId, messageId, replyId, privateMessageId)
+			},
+		},
+		{
+			name:   "deleted and hidden messages are left out",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_ = f.storage.InsertHiddenChatMessage(models.HiddenChatMessageEntity{UserId: memberId, MessageId: oldMessageId, ConversationId: groupId, HiddenAt: now})
+				f.tombstone(messageId)
+			},
+			req: &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()), replyId)
+			},
+		},
+		{
+			name:   "time range",
+			caller: memberId,
+			req: &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Limit: 10,
+				Before: timestamppb.New(now.Add(-6 * time.Minute))},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()), oldMessageId, messageId)
+			},
+		},
+		{
+			name:   "outsider without conversations",
+			caller: outsiderId,
+			req:    &pb.SearchChatMessagesRequest{Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessages) {
+				assertIds(t, messageIds(resp.GetMessages()))
+			},
+		},
+		{
+			name:   "outsider cannot search a conversation",
+			caller: outsiderId,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr(groupId.String()), Limit: 10},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid conversation id",
+			caller: memberId,
+			req:    &pb.SearchChatMessagesRequest{ConversationId: ptr("group"), Limit: 10},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestEditChatMessage(t *testing.T) {
+	runRpcCases(t, (*Server).EditChatMessage, []rpcCase[*pb.EditChatMessageRequest, *pb.ChatMessage]{
+		{
+			name:   "sender edits and the previous content is kept",
+			caller: memberId,
+			req:    &pb.EditChatMessageRequest{MessageId: messageId.String(), Content: "Where do we meet tomorrow?"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				message := f.getMessage(t, messageId)
+				if resp.GetContent() != "Where do we meet tomorrow?" || message.Content != resp.GetContent() || message.EditedAt.IsZero() {
+					t.Fatalf("edited message = %v", message)
+				}
+				history := f.storage.ChatMessageHistory(messageId)
+				if len(history) != 1 || history[0].Content != "Where do we meet?" {
+					t.Fatalf("history = %v", history)
+				}
+				if events := published[models.KafkaEditedMessage](t, f, editedTopic); len(events) != 1 {
+					t.Fatalf("published %d edited events, want 1", len(events))
+				}
+				assertStrings(t, outboxKinds(f),
+					string(models.OutboxSyncDocument)+":"+consts.ChatMessageIndex,
+					string(models.OutboxPublishRecord)+":"+editedTopic)
+			},
+		},
+		{
+			name:   "editing the last message refreshes the inbox preview",
+			caller: ownerId,
+			req:    &pb.EditChatMessageRequest{MessageId: replyId.String(), Content: "At the north exit"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				conversation, _ := f.storage.GetConversation(groupId)
+				if conversation.LastMessagePreview != "At the north exit" {
+					t.Fatalf("preview = %q", conversation.LastMessagePreview)
+				}
+			},
+		},
+		{
+			name:   "same content is not an edit",
+			caller: memberId,
+			req:    &pb.EditChatMessageRequest{MessageId: messageId.String(), Content: "Where do we meet?"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				if history := f.storage.ChatMessageHistory(messageId); len(history) != 0 {
+					t.Fatalf("history = %v, want none", history)
+				}
+			},
+		},
+		{
+			name:   "only the sender edits",
+			caller: ownerId,
+			req:    &pb.EditChatMessageRequest{MessageId: messageId.String(), Content: "Edited"},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "sender who left cannot edit",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_ = f.storage.DeleteParticipant(models.ParticipantEntity{ConversationId: groupId, UserId: memberId, Status: int(models.Joined)})
+			},
+			req:  &pb.EditChatMessageRequest{MessageId: messageId.String(), Content: "Edited"},
+			code: codes.PermissionDenied,
+		},
+		{
+			name:   "deleted message",
+			caller: memberId,
+			setup:  func(f *fixture) { f.tombstone(messageId) },
+			req:    &pb.EditChatMessageRequest{MessageId: messageId.String(), Content: "Edited"},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "empty content",
+			caller: memberId,
+			req:    &pb.EditChatMessageRequest{MessageId: messageId.String(), Content: "  "},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "unknown message",
+			caller: memberId,
+			req:    &pb.EditChatMessageRequest{MessageId: unknownId.String(), Content: "Edited"},
+			code:   codes.NotFound,
+		},
+	})
+}
+
+func TestDeleteChatMessage(t *testing.T) {
+	assertDeleted := func(t *testing.T, f *fixture, id gocql.UUID, deletedBy gocql.UUID) {
+		t.Helper()
+		message := f.getMessage(t, id)
+		if message.Content != "" || message.DeletedAt.IsZero() || message.DeletedBy != deletedBy {
+			t.Fatalf("message = %v, want a tombstone by %s", message, deletedBy)
+		}
+	}
+
+	runRpcCases(t, (*Server).DeleteChatMessage, []rpcCase[*pb.DeleteChatMessageRequest, *emptypb.Empty]{
+		{
+			name:   "delete for me hides the message from the caller only",
+			caller: ownerId,
+			req:    &pb.DeleteChatMessageRequest{MessageId: messageId.String()},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				if message := f.getMessage(t, messageId); message.Content != "Where do we meet?" {
+					t.Fatalf("content = %q", message.Content)
+				}
+				history, _ := f.storage.ListConversationHistory(groupId, ownerId, time.Time{}, time.Time{}, 10)
+				for _, message := range history {
+					if message.Id == messageId {
+						t.Fatalf("hidden message listed")
+					}
+				}
+				events := published[models.KafkaDeletedMessage](t, f, deletedTopic)
+				if len(events) != 1 || events[0].Mode != models.DeletedForMe {
+					t.Fatalf("deleted events = %v", events)
+				}
+				assertStrings(t, outboxKinds(f),
+					string(models.OutboxHideDocument)+":"+consts.ChatMessageIndex,
+					string(models.OutboxPublishRecord)+":"+deletedTopic)
+			},
+		},
+		{
+			name:   "sender deletes for everyone within the window",
+			caller: memberId,
+			setup: func(f *fixture) {
+				_ = f.storage.InsertChatMessageHistory(models.ChatMessageHistoryEntity{MessageId: messageId, Content: "Where?", ReplacedAt: now})
+			},
+			req: &pb.DeleteChatMessageRequest{MessageId: messageId.String(), ForEveryone: true},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				assertDeleted(t, f, messageId, memberId)
+				if history := f.storage.ChatMessageHistory(messageId); len(history) != 0 {
+					t.Fatalf("history = %v, want none", history)
+				}
+				events := published[models.KafkaDeletedMessage](t, f, deletedTopic)
+				if len(events) != 1 || events[0].Mode != models.DeletedForEveryone {
+					t.Fatalf("deleted events = %v", events)
+				}
+			},
+		},
+		{
+			name:   "sender cannot delete for everyone after the window",
+			caller: memberId,
+			req:    &pb.DeleteChatMessageRequest{MessageId: oldMessageId.String(), ForEveryone: true},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "admin deletes for everyone at any time",
+			caller: adminId,
+			req:    &pb.DeleteChatMessageRequest{MessageId: oldMessageId.String(), ForEveryone: true},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				assertDeleted(t, f, oldMessageId, adminId)
+			},
+		},
+		{
+			name:   "deleting the last message clears the inbox preview",
+			caller: ownerId,
+			req:    &pb.DeleteChatMessageRequest{MessageId: replyId.String(), ForEveryone: true},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				conversation, _ := f.storage.GetConversation(groupId)
+				if conversation.LastMessagePreview != "" {
+					t.Fatalf("preview = %q, want none", conversation.LastMessagePreview)
+				}
+			},
+		},
+		{
+			name:   "already deleted for everyone",
+			caller: memberId,
+			setup:  func(f *fixture) { f.tombstone(messageId) },
+			req:    &pb.DeleteChatMessageRequest{MessageId: messageId.String(), ForEveryone: true},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				if events := f.events.Published(); len(events) != 0 {
+					t.Fatalf("published %d events, want none", len(events))
+				}
+			},
+		},
+		{
+			name:   "outsider cannot delete",
+			caller: outsiderId,
+			req:    &pb.DeleteChatMessageRequest{MessageId: messageId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "unknown message",
+			caller: memberId,
+			req:    &pb.DeleteChatMessageRequest{MessageId: unknownId.String()},
+			code:   codes.NotFound,
+		},
+	})
+}
+
+// subscribeStream collects what the handler sends, it stands in for the grpc stream
+type subscribeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.ChatMessage
+}
+
+func (s *subscribeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *subscribeStream) Send(message *pb.ChatMessage) error {
+	s.sent <- message
+	return nil
+}
+
+func (s *subscribeStream) next(t *testing.T) *pb.ChatMessage {
+	t.Helper()
+	select {
+	case message := <-s.sent:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestSubscribeConversation(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller gocql.UUID
+		req    *pb.SubscribeConversationRequest
+		code   codes.Code
+	}{
+		{
+			name:   "missing conversations",
+			caller: memberId,
+			req:    &pb.SubscribeConversationRequest{},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name: "unauthenticated",
+			req:  &pb.SubscribeConversationRequest{ConversationIds: []string{groupId.String()}},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "invalid conversation id",
+			caller: memberId,
+			req:    &pb.SubscribeConversationRequest{ConversationIds: []string{"group"}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "outsider cannot subscribe",
+			caller: outsiderId,
+			req:    &pb.SubscribeConversationRequest{ConversationIds: []string{groupId.String()}},
+			code:   codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			stream := &subscribeStream{ctx: callerContext(tc.caller), sent: make(chan *pb.ChatMessage, 10)}
+			if code := status.Code(f.server.SubscribeConversation(tc.req, stream)); code != tc.code {
+				t.Fatalf("code = %v, want %v", code, tc.code)
+			}
+		})
+	}
+
+	t.Run("replays from the cursor then streams live messages", func(t *testing.T) {
+		f := newFixture(t)
+		ctx, cancel := context.WithCancel(callerContext(memberId))
+		stream := &subscribeStream{ctx: ctx, sent: make(chan *pb.ChatMessage, 10)}
+		req := &pb.SubscribeConversationRequest{
+			ConversationIds: []string{groupId.String(), privateId.String()},
+			After:           timestamppb.New(now.Add(-6 * time.Minute)),
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- f.server.SubscribeConversation(req, stream) }()
+
+		assertOrderedIds(t, []string{stream.next(t).GetId(), stream.next(t).GetId()}, replyId, privateMessageId)
+
+		// The subscription was made before the replay, a message already replayed is not sent twice
+		reply := f.getMessage(t, replyId)
+		f.server.Hub.Publish(reply)
+		live := models.ChatMessageEntity{Id: gocql.MustRandomUUID(), ConversationId: groupId, FromUserId: ownerId, Content: "Running late", SentTime: time.Now()}
+		f.server.Hub.Publish(live)
+		assertOrderedIds(t, []string{stream.next(t).GetId()}, live.Id)
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("err = %v, want nil after the client left", err)
+		}
+	})
+
+	t.Run("conversations the caller was removed from stop streaming", func(t *testing.T) {
+		f := newFixture(t)
+		stream := &subscribeStream{ctx: callerContext(memberId), sent: make(chan *pb.ChatMessage, 10)}
+		req := &pb.SubscribeConversationRequest{ConversationIds: []string{groupId.String(), privateId.String()}}
+
+		done := make(chan error, 1)
+		go func() { done <- f.server.SubscribeConversation(req, stream) }()
+		// Wait for the subscription, the first live message is only sent once it exists
+		first := models.ChatMessageEntity{Id: gocql.MustRandomUUID(), ConversationId: groupId, FromUserId: ownerId, SentTime: time.Now()}
+		for delivered := 0; delivered == 0; delivered = f.server.Hub.Publish(first) {
+			time.Sleep(time.Millisecond)
+		}
+		assertOrderedIds(t, []string{stream.next(t).GetId()}, first.Id)
+
+		if _, err := f.server.RemoveParticipant(callerContext(ownerId), &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: memberId.String()}); err != nil {
+			t.Fatal(err)
+		}
+		removed := models.ChatMessageEntity{Id: gocql.MustRandomUUID(), ConversationId: groupId, FromUserId: ownerId, SentTime: time.Now()}
+		f.server.Hub.Publish(removed)
+		private := models.ChatMessageEntity{Id: gocql.MustRandomUUID(), ConversationId: privateId, FromUserId: ownerId, SentTime: time.Now()}
+		f.server.Hub.Publish(private)
+		assertOrderedIds(t, []string{stream.next(t).GetId()}, private.Id)
+
+		// Removed on another instance, the membership event reaches the hub
+		f.server.Hub.RemoveMember(privateId, memberId)
+		if code := status.Code(<-done); code != codes.PermissionDenied {
+			t.Fatalf("code = %v, want %v once every conversation was left", code, codes.PermissionDenied)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"github.com/TripConnect/chat-service/models"
+)
+
+// outboxWrite collects the rows of a change and the outbox entries projecting it, they are saved in one batch so
+// Elasticsearch and Kafka never miss a change stored in Cassandra
+type outboxWrite struct {
+	rows    []models.TableRow
+	entries []models.OutboxEntity
+	err     error
+}
+
+func (w *outboxWrite) row(row models.TableRow) {
+	w.rows = append(w.rows, row)
+}
+
+// entry takes the result of an entry builder, the first error fails the save
+func (w *outboxWrite) entry(entry models.OutboxEntity, err error) {
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.entries = append(w.entries, entry)
+}
+
+func (s *Server) save(w *outboxWrite) error {
+	if w.err != nil {
+		return w.err
+	}
+	return s.Store.SaveWithOutbox(w.rows, w.entries...)
+}
@@ -0,0 +1,513 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"github.com/tripconnect/go-common-utils/helper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *Server) getGroupConversation(conversationId gocql.UUID) (*models.ConversationEntity, error) {
+	entity, err := s.Store.GetConversation(conversationId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	if entity.Type != int(pb.ConversationType_GROUP) {
+		return nil, status.Error(codes.FailedPrecondition, "membership can only be changed in group conversations")
+	}
+
+	return entity, nil
+}
+
+// saveParticipant writes the participant row with the sync of its document
+func saveParticipant(w *outboxWrite, participant models.ParticipantEntity) {
+	w.row(models.TableRow{Table: models.ParticipantRepository.TableInterface, Row: participant})
+	w.entry(models.NewParticipantSyncEntry(participant.ConversationId, participant.UserId))
+}
+
+// deleteParticipant removes the participant and its read cursor with the sync of its document
+func deleteParticipant(w *outboxWrite, participant models.ParticipantEntity) {
+	w.row(models.TableRow{Table: models.ParticipantRepository.TableInterface, Row: participant, Delete: true})
+	w.row(models.TableRow{Table: models.ReadCursorRepository.TableInterface, Delete: true,
+		Row: models.ReadCursorEntity{ConversationId: participant.ConversationId, UserId: participant.UserId}})
+	w.entry(models.NewParticipantSyncEntry(participant.ConversationId, participant.UserId))
+}
+
+// changeParticipantStatus moves the participant to another Cassandra row since status is part of the primary key,
+// the Elasticsearch document keeps its id and is overwritten
+func changeParticipantStatus(w *outboxWrite, participant models.ParticipantEntity, participantStatus models.ParticipantStatus) models.ParticipantEntity {
+	w.row(models.TableRow{Table: models.ParticipantRepository.TableInterface, Row: participant, Delete: true})
+	participant.Status = int(participantStatus)
+	saveParticipant(w, participant)
+	return participant
+}
+
+// resetUnreadCount is left out of the batch since counters cannot be batched with other rows, a member who joins
+// again starts without the messages counted before they left
+func (s *Server) resetUnreadCount(participant models.ParticipantEntity) {
+	if err := s.Store.SetUnreadCount(participant.ConversationId, participant.UserId, 0); err != nil {
+		log.Printf("Failed to reset unread count of %s in %s: %v", participant.UserId, participant.ConversationId, err)
+	}
+}
+
+func publishMembershipEvent(w *outboxWrite, conversationId gocql.UUID, actorId gocql.UUID, action models.MembershipAction, userIds []gocql.UUID) {
+	membershipTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-membership-changed")
+	w.entry(models.NewPublishEntry(conversationId, membershipTopic, &models.KafkaMembershipEvent{
+		ConversationId: conversationId,
+		ActorId:        actorId,
+		UserIds:        userIds,
+		Action:         action,
+		CreatedAt:      time.Now(),
+	}))
+}
+
+func (s *Server) getConversationPb(ctx context.Context, conversation models.ConversationEntity) *pb.Conversation {
+	pbJoinedMembers, err := s.getConversationMembers(ctx, conversation.Id, models.Joined, 0, 50)
+	if err != nil {
+		log.Printf("cannot get conversation memebers %s %v", conversation.Id, err)
+		pbJoinedMembers = []models.ParticipantEntity{}
+	}
+
+	pbConversation := models.NewConversationPb(conversation, pbJoinedMembers)
+	return &pbConversation
+}
+
+func (s *Server) AddParticipants(ctx context.Context, req *pb.AddParticipantsRequest) (*pb.Conversation, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	var memberIds []gocql.UUID
+	for _, rawMemberId := range req.GetMemberIds() {
+		memberId, err := gocql.ParseUUID(rawMemberId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid memberIds")
+		}
+		memberIds = append(memberIds, memberId)
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorize(*conversation, userId, manageMembers); err != nil {
+		return nil, err
+	}
+
+	var w outboxWrite
+	var addedIds []gocql.UUID
+	for _, memberId := range memberIds {
+		existing, err := s.Store.FindParticipant(conversationId, memberId)
+		if err == nil && existing.Status == int(models.Joined) {
+			continue
+		}
+
+		if err == nil {
+			// Adding a user with a pending join request approves it
+			changeParticipantStatus(&w, *existing, models.Joined)
+		} else {
+			saveParticipant(&w, models.ParticipantEntity{
+				ConversationId: conversationId,
+				UserId:         memberId,
+				NickName:       "",
+				Status:         int(models.Joined),
+				CreatedAt:      time.Now(),
+			})
+		}
+		addedIds = append(addedIds, memberId)
+	}
+
+	if len(addedIds) > 0 {
+		publishMembershipEvent(&w, conversationId, userId, models.MembershipAdded, addedIds)
+		if err := s.save(&w); err != nil {
+			log.Printf("Failed to add participants %v to %s: %v", addedIds, conversationId, err)
+			return nil, status.Error(codes.Internal, codes.Internal.String())
+		}
+	}
+
+	return s.getConversationPb(ctx, *conversation), nil
+}
+
+func (s *Server) RemoveParticipant(ctx context.Context, req *pb.RemoveParticipantRequest) (*pb.Conversation, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	memberId, memberIdErr := gocql.ParseUUID(req.GetMemberId())
+	if memberIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid memberId")
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := s.authorize(*conversation, userId, manageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := s.Store.FindParticipant(conversationId, memberId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	if !outranks(*conversation, *actor, *participant) {
+		return nil, status.Error(codes.PermissionDenied, "cannot remove a participant with an equal or higher role")
+	}
+
+	var w outboxWrite
+	deleteParticipant(&w, *participant)
+	publishMembershipEvent(&w, conversationId, userId, models.MembershipRemoved, []gocql.UUID{memberId})
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to remove participant %s from %s: %v", memberId, conversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	s.resetUnreadCount(*participant)
+	// The other instances stop streaming to the member on the membership event
+	s.Hub.RemoveMember(conversationId, memberId)
+
+	return s.getConversationPb(ctx, *conversation), nil
+}
+
+func (s *Server) LeaveConversation(ctx context.Context, req *pb.LeaveConversationRequest) (*emptypb.Empty, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := s.Store.FindParticipant(conversationId, userId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	if conversation.RoleOf(*participant) == models.OwnerRole {
+		return nil, status.Error(codes.FailedPrecondition, "the owner must transfer ownership before leaving")
+	}
+
+	var w outboxWrite
+	deleteParticipant(&w, *participant)
+	publishMembershipEvent(&w, conversationId, userId, models.MembershipLeft, []gocql.UUID{userId})
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to leave conversation %s for %s: %v", conversationId, userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	s.resetUnreadCount(*participant)
+	s.Hub.RemoveMember(conversationId, userId)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) RequestJoinConversation(ctx context.Context, req *pb.RequestJoinConversationRequest) (*pb.Participant, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	if _, err := s.getGroupConversation(conversationId); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.Store.FindParticipant(conversationId, userId); err == nil {
+		if existing.Status == int(models.Joined) {
+			return nil, status.Error(codes.AlreadyExists, "already a member of the conversation")
+		}
+		pbParticipant := models.NewParticipantPb(*existing)
+		return &pbParticipant, nil
+	}
+
+	participant := models.ParticipantEntity{
+		ConversationId: conversationId,
+		UserId:         userId,
+		NickName:       "",
+		Status:         int(models.Requested),
+		CreatedAt:      time.Now(),
+	}
+	var w outboxWrite
+	saveParticipant(&w, participant)
+	publishMembershipEvent(&w, conversationId, userId, models.MembershipRequested, []gocql.UUID{userId})
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to request joining %s for %s: %v", conversationId, userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	pbParticipant := models.NewParticipantPb(participant)
+	return &pbParticipant, nil
+}
+
+func (s *Server) ResolveJoinRequest(ctx context.Context, req *pb.ResolveJoinRequestRequest) (*pb.Participant, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	memberId, memberIdErr := gocql.ParseUUID(req.GetMemberId())
+	if memberIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid memberId")
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorize(*conversation, userId, resolveJoinRequests); err != nil {
+		return nil, err
+	}
+
+	requested, err := s.Store.GetParticipant(conversationId, memberId, models.Requested)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+	participant := *requested
+
+	var w outboxWrite
+	if !req.GetApprove() {
+		deleteParticipant(&w, participant)
+		publishMembershipEvent(&w, conversationId, userId, models.MembershipRejected, []gocql.UUID{memberId})
+		if err := s.save(&w); err != nil {
+			log.Printf("Failed to reject join request %s of %s: %v", conversationId, memberId, err)
+			return nil, status.Error(codes.Internal, codes.Internal.String())
+		}
+		s.resetUnreadCount(participant)
+
+		pbParticipant := models.NewParticipantPb(participant)
+		return &pbParticipant, nil
+	}
+
+	joined := changeParticipantStatus(&w, participant, models.Joined)
+	publishMembershipEvent(&w, conversationId, userId, models.MembershipAdded, []gocql.UUID{memberId})
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to approve join request %s of %s: %v", conversationId, memberId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	pbParticipant := models.NewParticipantPb(joined)
+	return &pbParticipant, nil
+}
+
+func (s *Server) GetConversationMembers(ctx context.Context, req *pb.GetConversationMembersRequest) (*pb.Participants, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	conversation, err := s.Store.GetConversation(conversationId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	participantStatus := models.ParticipantStatus(req.GetStatus())
+	if participantStatus == models.Requested {
+		if _, err := s.authorize(*conversation, userId, resolveJoinRequests); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.requireMember(conversationId, userId); err != nil {
+		return nil, err
+	}
+
+	members, err := s.getConversationMembers(ctx, conversationId, participantStatus, int(req.GetPageNumber()), int(req.GetPageSize()))
+	if err != nil {
+		log.Printf("cannot get conversation memebers %s %v", conversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	var pbParticipants []*pb.Participant
+	for _, member := range members {
+		pbParticipant := models.NewParticipantPb(member)
+		pbParticipants = append(pbParticipants, &pbParticipant)
+	}
+
+	return &pb.Participants{Participants: pbParticipants}, nil
+}
+
+func (s *Server) UpdateConversation(ctx context.Context, req *pb.UpdateConversationRequest) (*pb.Conversation, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+	if strings.TrimSpace(req.GetName()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty name")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorize(*conversation, userId, renameConversation); err != nil {
+		return nil, err
+	}
+
+	// Only the name is written, the activity columns may be moving meanwhile
+	conversation.Name = req.GetName()
+	var w outboxWrite
+	w.row(models.TableRow{Table: models.ConversationRepository.TableInterface, Row: *conversation, Columns: []string{"name"}})
+	w.entry(models.NewConversationSyncEntry(conversationId))
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to rename conversation %s: %v", conversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return s.getConversationPb(ctx, *conversation), nil
+}
+
+func (s *Server) UpdateParticipantRole(ctx context.Context, req *pb.UpdateParticipantRoleRequest) (*pb.Participant, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	memberId, memberIdErr := gocql.ParseUUID(req.GetMemberId())
+	if memberIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid memberId")
+	}
+
+	role := models.ParticipantRole(req.GetRole())
+	if role != models.MemberRole && role != models.AdminRole {
+		return nil, status.Error(codes.InvalidArgument, "ownership is changed with TransferOwnership")
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := s.authorize(*conversation, userId, manageRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.Store.GetParticipant(conversationId, memberId, models.Joined)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+	participant := *member
+
+	if !outranks(*conversation, *actor, participant) {
+		return nil, status.Error(codes.PermissionDenied, "cannot change the role of an equal or higher role")
+	}
+
+	participant.Role = int(role)
+	var w outboxWrite
+	saveParticipant(&w, participant)
+	publishMembershipEvent(&w, conversationId, userId, models.MembershipRoleChanged, []gocql.UUID{memberId})
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to change role of %s in %s: %v", memberId, conversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	pbParticipant := models.NewParticipantPb(participant)
+	return &pbParticipant, nil
+}
+
+func (s *Server) TransferOwnership(ctx context.Context, req *pb.TransferOwnershipRequest) (*pb.Conversation, error) {
+	conversationId, convIdErr := gocql.ParseUUID(req.GetConversationId())
+	if convIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid conversationId")
+	}
+
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	newOwnerId, newOwnerIdErr := gocql.ParseUUID(req.GetNewOwnerId())
+	if newOwnerIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid newOwnerId")
+	}
+
+	conversation, err := s.getGroupConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.authorize(*conversation, userId, transferOwnership)
+	if err != nil {
+		return nil, err
+	}
+
+	if newOwnerId == userId {
+		return s.getConversationPb(ctx, *conversation), nil
+	}
+
+	member, err := s.Store.GetParticipant(conversationId, newOwnerId, models.Joined)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, "the new owner must be a member")
+	}
+	newOwner := *member
+
+	// The previous owner stays as an admin
+	conversation.OwnerId = newOwnerId
+	newOwner.Role = int(models.OwnerRole)
+	owner.Role = int(models.AdminRole)
+	var w outboxWrite
+	w.row(models.TableRow{Table: models.ConversationRepository.TableInterface, Row: *conversation, Columns: []string{"owner_id"}})
+	saveParticipant(&w, newOwner)
+	saveParticipant(&w, *owner)
+	publishMembershipEvent(&w, conversationId, userId, models.MembershipRoleChanged, []gocql.UUID{newOwnerId, userId})
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to transfer ownership of %s: %v", conversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return s.getConversationPb(ctx, *conversation), nil
+}
@@ -0,0 +1,475 @@
+package rpc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// assertMembershipEvents compares the actions of the published membership events
+func assertMembershipEvents(t *testing.T, f *fixture, want ...models.MembershipAction) {
+	t.Helper()
+	actions := []models.MembershipAction{}
+	for _, event := range published[models.KafkaMembershipEvent](t, f, membershipTopic) {
+		actions = append(actions, event.Action)
+	}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("membership events = %v, want %v", actions, want)
+	}
+}
+
+func assertParticipantStatus(t *testing.T, f *fixture, conversationId gocql.UUID, userId gocql.UUID, want models.ParticipantStatus) {
+	t.Helper()
+	participant, err := f.storage.FindParticipant(conversationId, userId)
+	if err != nil || participant.Status != int(want) {
+		t.Fatalf("participant %s = %v, %v, want status %d", userId, participant, err, want)
+	}
+}
+
+func assertNotParticipant(t *testing.T, f *fixture, conversationId gocql.UUID, userId gocql.UUID) {
+	t.Helper()
+	if participant, err := f.storage.FindParticipant(conversationId, userId); err != gocql.ErrNotFound {
+		t.Fatalf("participant %s = %v, %v, want none", userId, participant, err)
+	}
+}
+
+func TestAddParticipants(t *testing.T) {
+	runRpcCases(t, (*Server).AddParticipants, []rpcCase[*pb.AddParticipantsRequest, *pb.Conversation]{
+		{
+			name:   "invalid conversation id",
+			caller: adminId,
+			req:    &pb.AddParticipantsRequest{ConversationId: "group", MemberIds: []string{outsiderId.String()}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name: "unauthenticated",
+			req:  &pb.AddParticipantsRequest{ConversationId: groupId.String(), MemberIds: []string{outsiderId.String()}},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "invalid member id",
+			caller: adminId,
+			req:    &pb.AddParticipantsRequest{ConversationId: groupId.String(), MemberIds: []string{"outsider"}},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "unknown conversation",
+			caller: adminId,
+			req:    &pb.AddParticipantsRequest{ConversationId: unknownId.String(), MemberIds: []string{outsiderId.String()}},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "private conversation",
+			caller: ownerId,
+			req:    &pb.AddParticipantsRequest{ConversationId: privateId.String(), MemberIds: []string{outsiderId.String()}},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "member cannot add",
+			caller: memberId,
+			req:    &pb.AddParticipantsRequest{ConversationId: groupId.String(), MemberIds: []string{outsiderId.String()}},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "admin adds a new member",
+			caller: adminId,
+			req:    &pb.AddParticipantsRequest{ConversationId: groupId.String(), MemberIds: []string{outsiderId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				assertIds(t, resp.GetMemberIds(), ownerId, adminId, memberId, outsiderId)
+				assertParticipantStatus(t, f, groupId, outsiderId, models.Joined)
+				assertMembershipEvents(t, f, models.MembershipAdded)
+			},
+		},
+		{
+			name:   "adding a requester approves the request",
+			caller: adminId,
+			req:    &pb.AddParticipantsRequest{ConversationId: groupId.String(), MemberIds: []string{requesterId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				assertParticipantStatus(t, f, groupId, requesterId, models.Joined)
+				if _, err := f.storage.GetParticipant(groupId, requesterId, models.Requested); err != gocql.ErrNotFound {
+					t.Fatalf("request row kept: %v", err)
+				}
+			},
+		},
+		{
+			name:   "existing members are skipped",
+			caller: ownerId,
+			req:    &pb.AddParticipantsRequest{ConversationId: groupId.String(), MemberIds: []string{memberId.String()}},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				assertMembershipEvents(t, f)
+			},
+		},
+	})
+}
+
+func TestRemoveParticipant(t *testing.T) {
+	runRpcCases(t, (*Server).RemoveParticipant, []rpcCase[*pb.RemoveParticipantRequest, *pb.Conversation]{
+		{
+			name:   "admin removes a member with their read cursor",
+			caller: adminId,
+			setup:  func(f *fixture) { f.readCursor(memberId, messageId) },
+			req:    &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: memberId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				assertIds(t, resp.GetMemberIds(), ownerId, adminId)
+				assertNotParticipant(t, f, groupId, memberId)
+				if _, err := f.storage.GetReadCursor(groupId, memberId); err != gocql.ErrNotFound {
+					t.Fatalf("read cursor kept: %v", err)
+				}
+				assertMembershipEvents(t, f, models.MembershipRemoved)
+			},
+		},
+		{
+			name:   "admin cannot remove the owner",
+			caller: adminId,
+			req:    &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: ownerId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "admin cannot remove an equal role",
+			caller: adminId,
+			req:    &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: adminId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "member cannot remove",
+			caller: memberId,
+			req:    &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: requesterId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "unknown participant",
+			caller: ownerId,
+			req:    &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: outsiderId.String()},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "invalid member id",
+			caller: ownerId,
+			req:    &pb.RemoveParticipantRequest{ConversationId: groupId.String(), MemberId: "member"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestLeaveConversation(t *testing.T) {
+	runRpcCases(t, (*Server).LeaveConversation, []rpcCase[*pb.LeaveConversationRequest, *emptypb.Empty]{
+		{
+			name:   "member leaves",
+			caller: memberId,
+			req:    &pb.LeaveConversationRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				assertNotParticipant(t, f, groupId, memberId)
+				assertMembershipEvents(t, f, models.MembershipLeft)
+			},
+		},
+		{
+			name:   "requester withdraws the request",
+			caller: requesterId,
+			req:    &pb.LeaveConversationRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *emptypb.Empty) {
+				assertNotParticipant(t, f, groupId, requesterId)
+			},
+		},
+		{
+			name:   "owner must transfer ownership first",
+			caller: ownerId,
+			req:    &pb.LeaveConversationRequest{ConversationId: groupId.String()},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "not a participant",
+			caller: outsiderId,
+			req:    &pb.LeaveConversationRequest{ConversationId: groupId.String()},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "private conversation",
+			caller: memberId,
+			req:    &pb.LeaveConversationRequest{ConversationId: privateId.String()},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "invalid conversation id",
+			caller: memberId,
+			req:    &pb.LeaveConversationRequest{ConversationId: "group"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestRequestJoinConversation(t *testing.T) {
+	runRpcCases(t, (*Server).RequestJoinConversation, []rpcCase[*pb.RequestJoinConversationRequest, *pb.Participant]{
+		{
+			name:   "outsider requests to join",
+			caller: outsiderId,
+			req:    &pb.RequestJoinConversationRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Participant) {
+				if resp.GetStatus() != pb.ParticipantStatus_REQUESTED {
+					t.Fatalf("status = %v, want REQUESTED", resp.GetStatus())
+				}
+				assertParticipantStatus(t, f, groupId, outsiderId, models.Requested)
+				assertMembershipEvents(t, f, models.MembershipRequested)
+			},
+		},
+		{
+			name:   "pending request is returned again",
+			caller: requesterId,
+			req:    &pb.RequestJoinConversationRequest{ConversationId: groupId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Participant) {
+				if resp.GetStatus() != pb.ParticipantStatus_REQUESTED {
+					t.Fatalf("status = %v, want REQUESTED", resp.GetStatus())
+				}
+				assertMembershipEvents(t, f)
+			},
+		},
+		{
+			name:   "already a member",
+			caller: memberId,
+			req:    &pb.RequestJoinConversationRequest{ConversationId: groupId.String()},
+			code:   codes.AlreadyExists,
+		},
+		{
+			name:   "private conversation",
+			caller: outsiderId,
+			req:    &pb.RequestJoinConversationRequest{ConversationId: privateId.String()},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name: "unauthenticated",
+			req:  &pb.RequestJoinConversationRequest{ConversationId: groupId.String()},
+			code: codes.Unauthenticated,
+		},
+	})
+}
+
+func TestResolveJoinRequest(t *testing.T) {
+	runRpcCases(t, (*Server).ResolveJoinRequest, []rpcCase[*pb.ResolveJoinRequestRequest, *pb.Participant]{
+		{
+			name:   "admin approves",
+			caller: adminId,
+			req:    &pb.ResolveJoinRequestRequest{ConversationId: groupId.String(), MemberId: requesterId.String(), Approve: true},
+			check: func(t *testing.T, f *fixture, resp *pb.Participant) {
+				if resp.GetStatus() != pb.ParticipantStatus_JOINED {
+					t.Fatalf("status = %v, want JOINED", resp.GetStatus())
+				}
+				assertParticipantStatus(t, f, groupId, requesterId, models.Joined)
+				assertMembershipEvents(t, f, models.MembershipAdded)
+			},
+		},
+		{
+			name:   "admin rejects",
+			caller: adminId,
+			req:    &pb.ResolveJoinRequestRequest{ConversationId: groupId.String(), MemberId: requesterId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Participant) {
+				assertNotParticipant(t, f, groupId, requesterId)
+				assertMembershipEvents(t, f, models.MembershipRejected)
+			},
+		},
+		{
+			name:   "member cannot resolve",
+			caller: memberId,
+			req:    &pb.ResolveJoinRequestRequest{ConversationId: groupId.String(), MemberId: requesterId.String(), Approve: true},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "no pending request",
+			caller: adminId,
+			req:    &pb.ResolveJoinRequestRequest{ConversationId: groupId.String(), MemberId: outsiderId.String(), Approve: true},
+			code:   codes.NotFound,
+		},
+		{
+			name:   "invalid member id",
+			caller: adminId,
+			req:    &pb.ResolveJoinRequestRequest{ConversationId: groupId.String(), MemberId: "requester"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestGetConversationMembers(t *testing.T) {
+	participantIds := func(resp *pb.Participants) []string {
+		ids := []string{}
+		for _, participant := range resp.GetParticipants() {
+			ids = append(ids, participant.GetUserId())
+		}
+		return ids
+	}
+
+	runRpcCases(t, (*Server).GetConversationMembers, []rpcCase[*pb.GetConversationMembersRequest, *pb.Participants]{
+		{
+			name:   "member lists joined members",
+			caller: memberId,
+			req:    &pb.GetConversationMembersRequest{ConversationId: groupId.String(), Status: pb.ParticipantStatus_JOINED, PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Participants) {
+				assertIds(t, participantIds(resp), ownerId, adminId, memberId)
+			},
+		},
+		{
+			name:   "admin lists join requests",
+			caller: adminId,
+			req:    &pb.GetConversationMembersRequest{ConversationId: groupId.String(), Status: pb.ParticipantStatus_REQUESTED, PageSize: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Participants) {
+				assertIds(t, participantIds(resp), requesterId)
+			},
+		},
+		{
+			name:   "member cannot list join requests",
+			caller: memberId,
+			req:    &pb.GetConversationMembersRequest{ConversationId: groupId.String(), Status: pb.ParticipantStatus_REQUESTED, PageSize: 10},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "outsider cannot list members",
+			caller: outsiderId,
+			req:    &pb.GetConversationMembersRequest{ConversationId: groupId.String(), Status: pb.ParticipantStatus_JOINED, PageSize: 10},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "unknown conversation",
+			caller: memberId,
+			req:    &pb.GetConversationMembersRequest{ConversationId: unknownId.String(), Status: pb.ParticipantStatus_JOINED, PageSize: 10},
+			code:   codes.NotFound,
+		},
+	})
+}
+
+func TestUpdateConversation(t *testing.T) {
+	runRpcCases(t, (*Server).UpdateConversation, []rpcCase[*pb.UpdateConversationRequest, *pb.Conversation]{
+		{
+			name:   "admin renames",
+			caller: adminId,
+			req:    &pb.UpdateConversationRequest{ConversationId: groupId.String(), Name: "Ski trip"},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				conversation, _ := f.storage.GetConversation(groupId)
+				if resp.GetName() != "Ski trip" || conversation.Name != "Ski trip" {
+					t.Fatalf("name = %q, stored %q", resp.GetName(), conversation.Name)
+				}
+				if conversation.LastMessageId != replyId || conversation.LastMessagePreview != "At the station" {
+					t.Fatalf("activity = %v, want it kept", conversation)
+				}
+				assertStrings(t, outboxKinds(f), string(models.OutboxSyncDocument)+":"+consts.ConversationIndex)
+			},
+		},
+		{
+			name:   "member cannot rename",
+			caller: memberId,
+			req:    &pb.UpdateConversationRequest{ConversationId: groupId.String(), Name: "Ski trip"},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "private conversation",
+			caller: ownerId,
+			req:    &pb.UpdateConversationRequest{ConversationId: privateId.String(), Name: "Ski trip"},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "invalid conversation id",
+			caller: ownerId,
+			req:    &pb.UpdateConversationRequest{ConversationId: "group", Name: "Ski trip"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "blank name",
+			caller: adminId,
+			req:    &pb.UpdateConversationRequest{ConversationId: groupId.String(), Name: "  "},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestUpdateParticipantRole(t *testing.T) {
+	runRpcCases(t, (*Server).UpdateParticipantRole, []rpcCase[*pb.UpdateParticipantRoleRequest, *pb.Participant]{
+		{
+			name:   "owner promotes a member",
+			caller: ownerId,
+			req:    &pb.UpdateParticipantRoleRequest{ConversationId: groupId.String(), MemberId: memberId.String(), Role: pb.ParticipantRole_ADMIN},
+			check: func(t *testing.T, f *fixture, resp *pb.Participant) {
+				participant, _ := f.storage.GetParticipant(groupId, memberId, models.Joined)
+				if resp.GetRole() != pb.ParticipantRole_ADMIN || participant.Role != int(models.AdminRole) {
+					t.Fatalf("role = %v, stored %d", resp.GetRole(), participant.Role)
+				}
+				assertMembershipEvents(t, f, models.MembershipRoleChanged)
+			},
+		},
+		{
+			name:   "ownership is not a role change",
+			caller: ownerId,
+			req:    &pb.UpdateParticipantRoleRequest{ConversationId: groupId.String(), MemberId: memberId.String(), Role: pb.ParticipantRole_OWNER},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "admin cannot manage roles",
+			caller: adminId,
+			req:    &pb.UpdateParticipantRoleRequest{ConversationId: groupId.String(), MemberId: memberId.String(), Role: pb.ParticipantRole_ADMIN},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "owner cannot demote themselves",
+			caller: ownerId,
+			req:    &pb.UpdateParticipantRoleRequest{ConversationId: groupId.String(), MemberId: ownerId.String(), Role: pb.ParticipantRole_MEMBER},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "not a member",
+			caller: ownerId,
+			req:    &pb.UpdateParticipantRoleRequest{ConversationId: groupId.String(), MemberId: outsiderId.String(), Role: pb.ParticipantRole_ADMIN},
+			code:   codes.NotFound,
+		},
+	})
+}
+
+func TestTransferOwnership(t *testing.T) {
+	runRpcCases(t, (*Server).TransferOwnership, []rpcCase[*pb.TransferOwnershipRequest, *pb.Conversation]{
+		{
+			name:   "previous owner becomes an admin",
+			caller: ownerId,
+			req:    &pb.TransferOwnershipRequest{ConversationId: groupId.String(), NewOwnerId: memberId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				if resp.GetOwnerId() != memberId.String() {
+					t.Fatalf("owner = %s, want %s", resp.GetOwnerId(), memberId)
+				}
+				newOwner, _ := f.storage.GetParticipant(groupId, memberId, models.Joined)
+				previousOwner, _ := f.storage.GetParticipant(groupId, ownerId, models.Joined)
+				if newOwner.Role != int(models.OwnerRole) || previousOwner.Role != int(models.AdminRole) {
+					t.Fatalf("roles = %d, %d", newOwner.Role, previousOwner.Role)
+				}
+				assertMembershipEvents(t, f, models.MembershipRoleChanged)
+			},
+		},
+		{
+			name:   "transfer to themselves changes nothing",
+			caller: ownerId,
+			req:    &pb.TransferOwnershipRequest{ConversationId: groupId.String(), NewOwnerId: ownerId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Conversation) {
+				if resp.GetOwnerId() != ownerId.String() {
+					t.Fatalf("owner = %s, want %s", resp.GetOwnerId(), ownerId)
+				}
+				assertMembershipEvents(t, f)
+			},
+		},
+		{
+			name:   "new owner must be a member",
+			caller: ownerId,
+			req:    &pb.TransferOwnershipRequest{ConversationId: groupId.String(), NewOwnerId: requesterId.String()},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "only the owner transfers",
+			caller: adminId,
+			req:    &pb.TransferOwnershipRequest{ConversationId: groupId.String(), NewOwnerId: adminId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid new owner id",
+			caller: ownerId,
+			req:    &pb.TransferOwnershipRequest{ConversationId: groupId.String(), NewOwnerId: "member"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
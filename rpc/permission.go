@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"log"
+
+	"github.com/TripConnect/chat-service/auth"
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type permission int
+
+const (
+	renameConversation permission = iota
+	manageMembers
+	deleteMessages
+	resolveJoinRequests
+	manageRoles
+	transferOwnership
+)
+
+var rolePermissions = map[models.ParticipantRole]map[permission]bool{
+	models.MemberRole: {},
+	models.AdminRole: {
+		renameConversation:  true,
+		manageMembers:       true,
+		deleteMessages:      true,
+		resolveJoinRequests: true,
+	},
+	models.OwnerRole: {
+		renameConversation:  true,
+		manageMembers:       true,
+		deleteMessages:      true,
+		resolveJoinRequests: true,
+		manageRoles:         true,
+		transferOwnership:   true,
+	},
+}
+
+// callerId returns the user authenticated by the auth interceptors, client supplied user ids are ignored
+func callerId(ctx context.Context) (gocql.UUID, error) {
+	userId, ok := auth.UserIdFromContext(ctx)
+	if !ok {
+		return gocql.UUID{}, status.Error(codes.Unauthenticated, codes.Unauthenticated.String())
+	}
+	return userId, nil
+}
+
+// requireMember ensures the user is a joined participant of the conversation, a failed lookup is not a denial
+func (s *Server) requireMember(conversationId gocql.UUID, userId gocql.UUID) (*models.ParticipantEntity, error) {
+	joined, err := s.Store.GetParticipant(conversationId, userId, models.Joined)
+	if err == gocql.ErrNotFound {
+		return nil, status.Error(codes.PermissionDenied, codes.PermissionDenied.String())
+	}
+	if err != nil {
+		log.Printf("Failed to get participant %s of conversation %s: %v", userId, conversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	return joined, nil
+}
+
+// authorize ensures the user is a joined participant whose role grants the permission
+func (s *Server) authorize(conversation models.ConversationEntity, userId gocql.UUID, perm permission) (*models.ParticipantEntity, error) {
+	participant, err := s.requireMember(conversation.Id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !rolePermissions[conversation.RoleOf(*participant)][perm] {
+		return nil, status.Error(codes.PermissionDenied, codes.PermissionDenied.String())
+	}
+
+	return participant, nil
+}
+
+// outranks reports whether the actor may act on the target, nobody may act on an equal or higher role
+func outranks(conversation models.ConversationEntity, actor models.ParticipantEntity, target models.ParticipantEntity) bool {
+	return conversation.RoleOf(actor) > conversation.RoleOf(target)
+}
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"github.com/tripconnect/go-common-utils/helper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxEmojiLength = 32
+
+// newChatMessagePbWithReactions builds the message with its reaction summary as seen by the viewer
+func (s *Server) newChatMessagePbWithReactions(entity models.ChatMessageEntity, viewerId gocql.UUID) *pb.ChatMessage {
+	pbMessage := models.NewChatMessagePb(entity)
+	if reactions, err := s.Store.ListMessageReactions(entity.Id); err == nil {
+		pbMessage.Reactions = models.NewReactionSummariesPb(reactions, viewerId)
+	} else {
+		log.Printf("Failed to get reactions for message %s: %v", entity.Id, err)
+	}
+	return &pbMessage
+}
+
+func validateEmoji(emoji string) error {
+	if emoji == "" || len(emoji) > maxEmojiLength || strings.IndexFunc(emoji, unicode.IsSpace) >= 0 {
+		return status.Error(codes.InvalidArgument, "invalid emoji")
+	}
+	return nil
+}
+
+// getMessageForMember loads a message of a conversation the user has joined
+func (s *Server) getMessageForMember(userId gocql.UUID, rawMessageId string) (*models.ChatMessageEntity, error) {
+	messageId, messageIdErr := gocql.ParseUUID(rawMessageId)
+	if messageIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid messageId")
+	}
+
+	entity, err := s.Store.GetChatMessage(messageId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	if _, err := s.requireMember(entity.ConversationId, userId); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+func publishReactionEvent(w *outboxWrite, entity models.ChatMessageEntity, userId gocql.UUID, emoji string, action models.ReactionAction) {
+	reactionTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-message-reaction")
+	w.entry(models.NewPublishEntry(entity.ConversationId, reactionTopic, &models.KafkaMessageReaction{
+		MessageId:      entity.Id,
+		ConversationId: entity.ConversationId,
+		UserId:         userId,
+		Emoji:          emoji,
+		Action:         action,
+		CreatedAt:      time.Now(),
+	}))
+}
+
+func (s *Server) AddReaction(ctx context.Context, req *pb.AddReactionRequest) (*pb.ChatMessage, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	if err := validateEmoji(req.GetEmoji()); err != nil {
+		return nil, err
+	}
+
+	entity, err := s.getMessageForMember(userId, req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+
+	if !entity.DeletedAt.IsZero() {
+		return nil, status.Error(codes.FailedPrecondition, "the message was deleted")
+	}
+
+	if _, err := s.Store.GetMessageReaction(entity.Id, req.GetEmoji(), userId); err == nil {
+		return s.newChatMessagePbWithReactions(*entity, userId), nil
+	}
+
+	reaction := models.MessageReactionEntity{
+		MessageId: entity.Id,
+		Emoji:     req.GetEmoji(),
+		UserId:    userId,
+		CreatedAt: time.Now(),
+	}
+	var w outboxWrite
+	w.row(models.TableRow{Table: models.MessageReactionRepository.TableInterface, Row: reaction})
+	publishReactionEvent(&w, *entity, userId, reaction.Emoji, models.ReactionAdded)
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to add reaction to %s: %v", entity.Id, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return s.newChatMessagePbWithReactions(*entity, userId), nil
+}
+
+func (s *Server) RemoveReaction(ctx context.Context, req *pb.RemoveReactionRequest) (*pb.ChatMessage, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	if err := validateEmoji(req.GetEmoji()); err != nil {
+		return nil, err
+	}
+
+	entity, err := s.getMessageForMember(userId, req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.Store.GetMessageReaction(entity.Id, req.GetEmoji(), userId)
+	if err != nil {
+		return s.newChatMessagePbWithReactions(*entity, userId), nil
+	}
+
+	var w outboxWrite
+	w.row(models.TableRow{Table: models.MessageReactionRepository.TableInterface, Row: *existing, Delete: true})
+	publishReactionEvent(&w, *entity, userId, req.GetEmoji(), models.ReactionRemoved)
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to remove reaction from %s: %v", entity.Id, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return s.newChatMessagePbWithReactions(*entity, userId), nil
+}
@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"google.golang.org/grpc/codes"
+)
+
+func assertReactionEvents(t *testing.T, f *fixture, want ...models.ReactionAction) {
+	t.Helper()
+	actions := []models.ReactionAction{}
+	for _, event := range published[models.KafkaMessageReaction](t, f, reactionTopic) {
+		actions = append(actions, event.Action)
+	}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("reaction events = %v, want %v", actions, want)
+	}
+}
+
+func TestAddReaction(t *testing.T) {
+	reacted := func(f *fixture) {
+		_ = f.storage.InsertMessageReaction(models.MessageReactionEntity{MessageId: messageId, Emoji: "👍", UserId: memberId, CreatedAt: now})
+	}
+
+	runRpcCases(t, (*Server).AddReaction, []rpcCase[*pb.AddReactionRequest, *pb.ChatMessage]{
+		{
+			name:   "member reacts",
+			caller: memberId,
+			req:    &pb.AddReactionRequest{MessageId: messageId.String(), Emoji: "👍"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				reactions := resp.GetReactions()
+				if len(reactions) != 1 || reactions[0].GetCount() != 1 || !reactions[0].GetReactedByMe() {
+					t.Fatalf("reactions = %v", reactions)
+				}
+				assertReactionEvents(t, f, models.ReactionAdded)
+			},
+		},
+		{
+			name:   "reacting twice counts once",
+			caller: memberId,
+			setup:  reacted,
+			req:    &pb.AddReactionRequest{MessageId: messageId.String(), Emoji: "👍"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				if count := resp.GetReactions()[0].GetCount(); count != 1 {
+					t.Fatalf("count = %d, want 1", count)
+				}
+				assertReactionEvents(t, f)
+			},
+		},
+		{
+			name:   "emoji with spaces",
+			caller: memberId,
+			req:    &pb.AddReactionRequest{MessageId: messageId.String(), Emoji: "thumbs up"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "deleted message",
+			caller: memberId,
+			setup:  func(f *fixture) { f.tombstone(messageId) },
+			req:    &pb.AddReactionRequest{MessageId: messageId.String(), Emoji: "👍"},
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "outsider cannot react",
+			caller: outsiderId,
+			req:    &pb.AddReactionRequest{MessageId: messageId.String(), Emoji: "👍"},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid message id",
+			caller: memberId,
+			req:    &pb.AddReactionRequest{MessageId: "message", Emoji: "👍"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestRemoveReaction(t *testing.T) {
+	reacted := func(f *fixture) {
+		_ = f.storage.InsertMessageReaction(models.MessageReactionEntity{MessageId: messageId, Emoji: "👍", UserId: memberId, CreatedAt: now})
+	}
+
+	runRpcCases(t, (*Server).RemoveReaction, []rpcCase[*pb.RemoveReactionRequest, *pb.ChatMessage]{
+		{
+			name:   "member removes their reaction",
+			caller: memberId,
+			setup:  reacted,
+			req:    &pb.RemoveReactionRequest{MessageId: messageId.String(), Emoji: "👍"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				if reactions := resp.GetReactions(); len(reactions) != 0 {
+					t.Fatalf("reactions = %v, want none", reactions)
+				}
+				assertReactionEvents(t, f, models.ReactionRemoved)
+			},
+		},
+		{
+			name:   "reactions of others are kept",
+			caller: ownerId,
+			setup:  reacted,
+			req:    &pb.RemoveReactionRequest{MessageId: messageId.String(), Emoji: "👍"},
+			check: func(t *testing.T, f *fixture, resp *pb.ChatMessage) {
+				reactions := resp.GetReactions()
+				if len(reactions) != 1 || reactions[0].GetReactedByMe() {
+					t.Fatalf("reactions = %v", reactions)
+				}
+				assertReactionEvents(t, f)
+			},
+		},
+		{
+			name:   "empty emoji",
+			caller: memberId,
+			req:    &pb.RemoveReactionRequest{MessageId: messageId.String()},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "unknown message",
+			caller: memberId,
+			req:    &pb.RemoveReactionRequest{MessageId: unknownId.String(), Emoji: "👍"},
+			code:   codes.NotFound,
+		},
+	})
+}
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/gocql/gocql"
+	"github.com/tripconnect/go-common-utils/helper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func publishReadCursor(w *outboxWrite, cursor models.ReadCursorEntity) {
+	readCursorTopic, _ := helper.ReadConfig[string]("kafka.topic.chatting-fct-read-cursor")
+	w.entry(models.NewPublishEntry(cursor.ConversationId, readCursorTopic, &models.KafkaReadCursor{
+		ConversationId:    cursor.ConversationId,
+		UserId:            cursor.UserId,
+		LastReadMessageId: cursor.LastReadMessageId,
+		ReadAt:            cursor.ReadAt,
+	}))
+}
+
+func (s *Server) MarkRead(ctx context.Context, req *pb.MarkReadRequest) (*pb.ReadReceipt, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	message, err := s.getMessageForMember(userId, req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+
+	// The cursor only moves forward, marking an older message read keeps the current cursor
+	if current, err := s.Store.GetReadCursor(message.ConversationId, userId); err == nil {
+		if !current.LastReadSentTime.Before(message.SentTime) {
+			receipt := models.NewReadReceiptPb(*current)
+			return &receipt, nil
+		}
+	} else if err != gocql.ErrNotFound {
+		log.Printf("Failed to get read cursor of %s: %v", userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	cursor := models.ReadCursorEntity{
+		ConversationId:    message.ConversationId,
+		UserId:            userId,
+		LastReadMessageId: message.Id,
+		LastReadSentTime:  message.SentTime,
+		ReadAt:            time.Now(),
+	}
+	var w outboxWrite
+	w.row(models.TableRow{Table: models.ReadCursorRepository.TableInterface, Row: cursor})
+	publishReadCursor(&w, cursor)
+	if err := s.save(&w); err != nil {
+		log.Printf("Failed to save read cursor of %s: %v", userId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	if err := store.RecountUnreadMessages(s.Store, cursor.ConversationId, userId, cursor.LastReadSentTime, cursor.LastReadMessageId); err != nil {
+		log.Printf("Failed to recount unread messages of %s: %v", userId, err)
+	}
+
+	receipt := models.NewReadReceiptPb(cursor)
+	return &receipt, nil
+}
+
+// GetMessageReaders lists the participants whose read cursor reached the message, the sender is left out
+func (s *Server) GetMessageReaders(ctx context.Context, req *pb.GetMessageReadersRequest) (*pb.ReadReceipts, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	message, err := s.getMessageForMember(userId, req.GetMessageId())
+	if err != nil {
+		return nil, err
+	}
+
+	cursors, err := s.Store.ListReadCursors(message.ConversationId)
+	if err != nil {
+		log.Printf("Failed to get read cursors of %s: %v", message.ConversationId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	receipts := []*pb.ReadReceipt{}
+	for _, cursor := range cursors {
+		if cursor.UserId == message.FromUserId || cursor.LastReadSentTime.Before(message.SentTime) {
+			continue
+		}
+		receipt := models.NewReadReceiptPb(*cursor)
+		receipts = append(receipts, &receipt)
+	}
+
+	return &pb.ReadReceipts{Receipts: receipts}, nil
+}
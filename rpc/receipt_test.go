@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMarkRead(t *testing.T) {
+	runRpcCases(t, (*Server).MarkRead, []rpcCase[*pb.MarkReadRequest, *pb.ReadReceipt]{
+		{
+			name:   "cursor moves to the message",
+			caller: memberId,
+			req:    &pb.MarkReadRequest{MessageId: replyId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ReadReceipt) {
+				cursor, err := f.storage.GetReadCursor(groupId, memberId)
+				if err != nil || cursor.LastReadMessageId != replyId || resp.GetLastReadMessageId() != replyId.String() {
+					t.Fatalf("cursor = %v, %v", cursor, err)
+				}
+				if events := published[models.KafkaReadCursor](t, f, readCursorTopic); len(events) != 1 {
+					t.Fatalf("published %d read cursors, want 1", len(events))
+				}
+			},
+		},
+		{
+			name:   "unread count restarts after the message",
+			caller: adminId,
+			req:    &pb.MarkReadRequest{MessageId: messageId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ReadReceipt) {
+				if unread, err := f.storage.GetUnreadCount(groupId, adminId); err != nil || unread != 1 {
+					t.Fatalf("unread = %d, %v, want 1", unread, err)
+				}
+			},
+		},
+		{
+			name:   "cursor never moves back",
+			caller: memberId,
+			setup:  func(f *fixture) { f.readCursor(memberId, replyId) },
+			req:    &pb.MarkReadRequest{MessageId: oldMessageId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ReadReceipt) {
+				if resp.GetLastReadMessageId() != replyId.String() {
+					t.Fatalf("last read = %s, want %s", resp.GetLastReadMessageId(), replyId)
+				}
+				if events := f.events.Published(); len(events) != 0 {
+					t.Fatalf("published %d events, want none", len(events))
+				}
+			},
+		},
+		{
+			name:   "outsider cannot mark read",
+			caller: outsiderId,
+			req:    &pb.MarkReadRequest{MessageId: replyId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "invalid message id",
+			caller: memberId,
+			req:    &pb.MarkReadRequest{MessageId: "message"},
+			code:   codes.InvalidArgument,
+		},
+	})
+}
+
+func TestGetMessageReaders(t *testing.T) {
+	cursors := func(f *fixture) {
+		f.readCursor(ownerId, replyId)
+		f.readCursor(adminId, oldMessageId)
+		f.readCursor(memberId, replyId)
+	}
+	readerIds := func(resp *pb.ReadReceipts) []string {
+		ids := []string{}
+		for _, receipt := range resp.GetReceipts() {
+			ids = append(ids, receipt.GetUserId())
+		}
+		return ids
+	}
+
+	runRpcCases(t, (*Server).GetMessageReaders, []rpcCase[*pb.GetMessageReadersRequest, *pb.ReadReceipts]{
+		{
+			name:   "readers past the message except the sender",
+			caller: memberId,
+			setup:  cursors,
+			req:    &pb.GetMessageReadersRequest{MessageId: messageId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ReadReceipts) {
+				assertIds(t, readerIds(resp), ownerId)
+			},
+		},
+		{
+			name:   "no readers yet",
+			caller: memberId,
+			req:    &pb.GetMessageReadersRequest{MessageId: messageId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.ReadReceipts) {
+				assertIds(t, readerIds(resp))
+			},
+		},
+		{
+			name:   "outsider cannot list readers",
+			caller: outsiderId,
+			req:    &pb.GetMessageReadersRequest{MessageId: messageId.String()},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "unknown message",
+			caller: memberId,
+			req:    &pb.GetMessageReadersRequest{MessageId: unknownId.String()},
+			code:   codes.NotFound,
+		},
+	})
+}
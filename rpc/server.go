@@ -1,9 +1,16 @@
 package rpc
 
 import (
-	"github.com/tripconnect/go-proto-lib/protos"
+	"github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/realtime"
+	"github.com/TripConnect/chat-service/store"
 )
 
+// Server reaches Cassandra, Elasticsearch and Kafka only through its dependencies
 type Server struct {
 	protos.UnimplementedChatServiceServer
+	Store  store.Storage
+	Search store.Search
+	Events store.Events
+	Hub    *realtime.Hub
 }
@@ -0,0 +1,303 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TripConnect/chat-service/auth"
+	_ "github.com/TripConnect/chat-service/internal/testenv"
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/realtime"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/TripConnect/chat-service/store/memory"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The seeded world: a group owned by ownerId with an admin, a member and a pending join request,
+// and a private conversation between the owner and the member. The outsider belongs to neither.
+var (
+	ownerId     = mustParseUUID("00000000-0000-0000-0000-00000000000a")
+	adminId     = mustParseUUID("00000000-0000-0000-0000-00000000000b")
+	memberId    = mustParseUUID("00000000-0000-0000-0000-00000000000c")
+	requesterId = mustParseUUID("00000000-0000-0000-0000-00000000000d")
+	outsiderId  = mustParseUUID("00000000-0000-0000-0000-00000000000e")
+
+	groupId   = mustParseUUID("00000000-0000-0000-0000-000000000100")
+	privateId = models.NewPrivateConversationId([]string{ownerId.String(), memberId.String()})
+
+	// oldMessageId is past the delete for everyone window, replyId answers messageId in its thread
+	oldMessageId     = mustParseUUID("00000000-0000-0000-0000-000000001001")
+	messageId        = mustParseUUID("00000000-0000-0000-0000-000000001002")
+	replyId          = mustParseUUID("00000000-0000-0000-0000-000000001003")
+	privateMessageId = mustParseUUID("00000000-0000-0000-0000-000000001004")
+	unknownId        = mustParseUUID("00000000-0000-0000-0000-00000000ffff")
+
+	now = time.Now().Truncate(time.Millisecond)
+)
+
+func mustParseUUID(raw string) gocql.UUID {
+	id, err := gocql.ParseUUID(raw)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+type fixture struct {
+	server  *Server
+	storage *memory.Storage
+	search  *memory.Search
+	events  *memory.Events
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	setConfig(t, "kafka.topic.chatting-fct-membership-changed", membershipTopic)
+	setConfig(t, "kafka.topic.chatting-fct-edited-message", editedTopic)
+	setConfig(t, "kafka.topic.chatting-fct-deleted-message", deletedTopic)
+	setConfig(t, "kafka.topic.chatting-fct-message-reaction", reactionTopic)
+	setConfig(t, "kafka.topic.chatting-fct-read-cursor", readCursorTopic)
+
+	storage := memory.NewStorage()
+	f := &fixture{
+		server: &Server{
+			Store:  storage,
+			Search: memory.NewSearch(storage),
+			Events: memory.NewEvents(),
+			Hub:    realtime.NewHub(),
+		},
+		storage: storage,
+	}
+	f.search = f.server.Search.(*memory.Search)
+	f.events = f.server.Events.(*memory.Events)
+
+	f.conversation(models.ConversationEntity{
+		Id:                 groupId,
+		OwnerId:            ownerId,
+		Name:               "Trip planning",
+		Type:               int(pb.ConversationType_GROUP),
+		CreatedAt:          now.Add(-24 * time.Hour),
+		LastMessageId:      replyId,
+		LastMessageAt:      now.Add(-5 * time.Minute),
+		LastMessagePreview: "At the station",
+	})
+	f.participant(groupId, ownerId, models.Joined, models.OwnerRole)
+	f.participant(groupId, adminId, models.Joined, models.AdminRole)
+	f.participant(groupId, memberId, models.Joined, models.MemberRole)
+	f.participant(groupId, requesterId, models.Requested, models.MemberRole)
+
+	f.conversation(models.ConversationEntity{
+		Id:                 privateId,
+		Type:               int(pb.ConversationType_PRIVATE),
+		CreatedAt:          now.Add(-24 * time.Hour),
+		LastMessageId:      privateMessageId,
+		LastMessageAt:      now.Add(-time.Minute),
+		LastMessagePreview: "hi",
+	})
+	f.participant(privateId, ownerId, models.Joined, models.MemberRole)
+	f.participant(privateId, memberId, models.Joined, models.MemberRole)
+
+	f.message(models.ChatMessageEntity{Id: oldMessageId, ConversationId: groupId, FromUserId: memberId,
+		Content: "Booked the hotel", SentTime: now.Add(-2 * time.Hour)})
+	f.message(models.ChatMessageEntity{Id: messageId, ConversationId: groupId, FromUserId: memberId,
+		Content: "Where do we meet?", SentTime: now.Add(-10 * time.Minute)})
+	f.message(models.ChatMessageEntity{Id: replyId, ConversationId: groupId, FromUserId: ownerId,
+		Content: "At the station", SentTime: now.Add(-5 * time.Minute), ReplyToMessageId: messageId, ThreadRootId: messageId})
+	f.message(models.ChatMessageEntity{Id: privateMessageId, ConversationId: privateId, FromUserId: ownerId,
+		Content: "hi", SentTime: now.Add(-time.Minute)})
+
+	return f
+}
+
+func (f *fixture) conversation(conversation models.ConversationEntity) {
+	_ = f.storage.UpdateConversation(conversation)
+}
+
+func (f *fixture) participant(conversationId gocql.UUID, userId gocql.UUID, participantStatus models.ParticipantStatus, role models.ParticipantRole) {
+	_ = f.storage.InsertParticipant(models.ParticipantEntity{
+		ConversationId: conversationId,
+		UserId:         userId,
+		Status:         int(participantStatus),
+		Role:           int(role),
+		CreatedAt:      now.Add(-time.Hour),
+	})
+}
+
+// message stores the message and counts it as unread for the other joined members like the consumer does
+func (f *fixture) message(message models.ChatMessageEntity) {
+	message.CreatedAt = message.SentTime
+	_, _ = f.storage.InsertChatMessageIfNotExists(message)
+
+	members, _ := f.search.SearchParticipants(context.Background(), store.ParticipantQuery{
+		ConversationId: message.ConversationId,
+		Status:         models.Joined,
+		PageSize:       100,
+	})
+	userIds := []gocql.UUID{}
+	for _, member := range members {
+		if member.UserId != message.FromUserId {
+			userIds = append(userIds, member.UserId)
+		}
+	}
+	_ = f.storage.IncrementUnreadCounts(message.ConversationId, userIds)
+}
+
+// tombstone marks the message deleted for everyone
+func (f *fixture) tombstone(id gocql.UUID) {
+	message, _ := f.storage.GetChatMessage(id)
+	message.Content = ""
+	message.DeletedAt = now
+	message.DeletedBy = message.FromUserId
+	_ = f.storage.UpdateChatMessage(*message)
+}
+
+func (f *fixture) readCursor(userId gocql.UUID, id gocql.UUID) {
+	message, _ := f.storage.GetChatMessage(id)
+	_ = f.storage.InsertReadCursor(models.ReadCursorEntity{
+		ConversationId:    message.ConversationId,
+		UserId:            userId,
+		LastReadMessageId: message.Id,
+		LastReadSentTime:  message.SentTime,
+		ReadAt:            now,
+	})
+	_ = store.RecountUnreadMessages(f.storage, message.ConversationId, userId, message.SentTime, message.Id)
+}
+
+func (f *fixture) getMessage(t *testing.T, id gocql.UUID) models.ChatMessageEntity {
+	t.Helper()
+	message, err := f.storage.GetChatMessage(id)
+	if err != nil {
+		t.Fatalf("message %s: %v", id, err)
+	}
+	return *message
+}
+
+// callerContext authenticates the caller like the auth interceptors do, the zero id stays anonymous
+func callerContext(userId gocql.UUID) context.Context {
+	if userId == (gocql.UUID{}) {
+		return context.Background()
+	}
+	return auth.NewContext(context.Background(), userId)
+}
+
+// Topics of the events written to the outbox, set as config by newFixture
+const (
+	membershipTopic = "membership"
+	editedTopic     = "edited"
+	deletedTopic    = "deleted"
+	reactionTopic   = "reaction"
+	readCursorTopic = "read-cursor"
+)
+
+func setConfig(t *testing.T, path string, value string) {
+	t.Helper()
+	t.Setenv(strings.ToUpper(strings.ReplaceAll("data."+path, ".", "_")), value)
+}
+
+// published decodes the records written to the outbox for the topic in the order they were written
+func published[T any](t *testing.T, f *fixture, topic string) []T {
+	t.Helper()
+	events := []T{}
+	for _, entry := range f.storage.Outbox() {
+		if entry.Kind != string(models.OutboxPublishRecord) || entry.Target != topic {
+			continue
+		}
+		var event T
+		if err := json.Unmarshal([]byte(entry.Payload), &event); err != nil {
+			t.Fatalf("outbox entry %s: %v", entry.EntryId, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// outboxKinds lists the kind and target of every outbox entry in the order they were written
+func outboxKinds(f *fixture) []string {
+	kinds := []string{}
+	for _, entry := range f.storage.Outbox() {
+		kinds = append(kinds, entry.Kind+":"+entry.Target)
+	}
+	return kinds
+}
+
+type rpcCase[Req any, Resp any] struct {
+	name   string
+	caller gocql.UUID
+	setup  func(f *fixture)
+	req    Req
+	code   codes.Code
+	check  func(t *testing.T, f *fixture, resp Resp)
+}
+
+// runRpcCases calls the handler of every case against a freshly seeded server
+func runRpcCases[Req any, Resp any](t *testing.T, handler func(*Server, context.Context, Req) (Resp, error), cases []rpcCase[Req, Resp]) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tc.setup != nil {
+				tc.setup(f)
+			}
+
+			resp, err := handler(f.server, callerContext(tc.caller), tc.req)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("code = %v, want %v (%v)", code, tc.code, err)
+			}
+			if err == nil && tc.check != nil {
+				tc.check(t, f, resp)
+			}
+		})
+	}
+}
+
+func assertIds(t *testing.T, got []string, want ...gocql.UUID) {
+	t.Helper()
+	wantIds := []string{}
+	for _, id := range want {
+		wantIds = append(wantIds, id.String())
+	}
+	got = slices.Clone(got)
+	slices.Sort(got)
+	slices.Sort(wantIds)
+	if !slices.Equal(got, wantIds) {
+		t.Fatalf("ids = %v, want %v", got, wantIds)
+	}
+}
+
+func messageIds(messages []*pb.ChatMessage) []string {
+	ids := []string{}
+	for _, message := range messages {
+		ids = append(ids, message.GetId())
+	}
+	return ids
+}
+
+// assertOrderedIds compares ids in order, unlike assertIds
+func assertOrderedIds(t *testing.T, got []string, want ...gocql.UUID) {
+	t.Helper()
+	wantIds := []string{}
+	for _, id := range want {
+		wantIds = append(wantIds, id.String())
+	}
+	if !slices.Equal(got, wantIds) {
+		t.Fatalf("ids = %v, want %v", got, wantIds)
+	}
+}
+
+func assertStrings(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// getReferencedMessage loads a message a new message refers to, it must live in the same conversation
+func (s *Server) getReferencedMessage(conversationId gocql.UUID, rawMessageId string, field string) (*models.ChatMessageEntity, error) {
+	messageId, messageIdErr := gocql.ParseUUID(rawMessageId)
+	if messageIdErr != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s", field)
+	}
+
+	entity, err := s.Store.GetChatMessage(messageId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s not found", field)
+	}
+
+	if entity.ConversationId != conversationId {
+		return nil, status.Errorf(codes.InvalidArgument, "%s belongs to another conversation", field)
+	}
+
+	return entity, nil
+}
+
+// resolveReplyReferences validates the optional reply and thread references of a new message.
+// Threads are one level deep, a reply to a thread reply joins the thread of the quoted message.
+func (s *Server) resolveReplyReferences(conversationId gocql.UUID, rawReplyToMessageId *string, rawThreadRootId *string) (gocql.UUID, gocql.UUID, error) {
+	var replyToMessageId, threadRootId gocql.UUID
+
+	if rawThreadRootId != nil {
+		root, err := s.getReferencedMessage(conversationId, *rawThreadRootId, "threadRootId")
+		if err != nil {
+			return replyToMessageId, threadRootId, err
+		}
+		if root.ThreadRootId != (gocql.UUID{}) {
+			return replyToMessageId, threadRootId, status.Error(codes.InvalidArgument, "threadRootId is a thread reply")
+		}
+		threadRootId = root.Id
+	}
+
+	if rawReplyToMessageId != nil {
+		quoted, err := s.getReferencedMessage(conversationId, *rawReplyToMessageId, "replyToMessageId")
+		if err != nil {
+			return replyToMessageId, threadRootId, err
+		}
+		replyToMessageId = quoted.Id
+
+		// Replying inside a thread keeps the reply in that thread
+		if rawThreadRootId != nil && quoted.ThreadRootId != (gocql.UUID{}) && quoted.ThreadRootId != threadRootId {
+			return replyToMessageId, threadRootId, status.Error(codes.InvalidArgument, "replyToMessageId belongs to another thread")
+		}
+		if rawThreadRootId == nil && quoted.ThreadRootId != (gocql.UUID{}) {
+			threadRootId = quoted.ThreadRootId
+		}
+	}
+
+	return replyToMessageId, threadRootId, nil
+}
+
+// countThreadReplies counts the replies of a thread that were not deleted for everyone
+func (s *Server) countThreadReplies(ctx context.Context, rootId gocql.UUID) (int32, error) {
+	count, err := s.Search.CountChatMessages(ctx, store.ChatMessageQuery{ThreadRootId: rootId, ExcludeDeleted: true})
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(count), nil
+}
+
+func (s *Server) GetThread(ctx context.Context, req *pb.GetThreadRequest) (*pb.Thread, error) {
+	userId, authErr := callerId(ctx)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	rootId, rootIdErr := gocql.ParseUUID(req.GetRootMessageId())
+	if rootIdErr != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid rootMessageId")
+	}
+
+	root, err := s.Store.GetChatMessage(rootId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	}
+
+	if _, err := s.requireMember(root.ConversationId, userId); err != nil {
+		return nil, err
+	}
+
+	if root.ThreadRootId != (gocql.UUID{}) {
+		return nil, status.Error(codes.InvalidArgument, "rootMessageId is a thread reply")
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultChatMessagesLimit
+	}
+
+	query := store.ChatMessageQuery{
+		ThreadRootId: rootId,
+		ViewerId:     userId,
+		// Replies read top-down, the client pages forward with the sent time of the last reply
+		Ascending: true,
+		PageSize:  limit,
+	}
+
+	if req.GetAfter() != nil {
+		query.After = req.GetAfter().AsTime()
+	}
+
+	docs, err := s.Search.SearchChatMessages(ctx, query)
+
+	if err != nil {
+		log.Printf("Failed to search thread %s: %v", rootId, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	replies := make([]*pb.ChatMessage, len(docs))
+	var wg sync.WaitGroup
+	wg.Add(len(docs))
+
+	for i, doc := range docs {
+		go func(i int, docId gocql.UUID) {
+			defer wg.Done()
+			if message, err := s.Store.GetChatMessage(docId); err == nil {
+				replies[i] = s.newChatMessagePbWithReactions(*message, userId)
+			} else {
+				log.Printf("Failed to get message for id %s: %v", docId, err)
+			}
+		}(i, doc.Id)
+	}
+
+	wg.Wait()
+
+	pbRoot := s.newChatMessagePbWithReactions(*root, userId)
+	if replyCount, err := s.countThreadReplies(ctx, rootId); err == nil {
+		pbRoot.ReplyCount = replyCount
+	} else {
+		log.Printf("Failed to count replies of %s: %v", rootId, err)
+	}
+
+	result := &pb.Thread{Root: pbRoot, Replies: replies}
+	return result, nil
+}
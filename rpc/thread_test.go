@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	pb "github.com/TripConnect/chat-service/protos"
+	"github.com/gocql/gocql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGetThread(t *testing.T) {
+	secondReplyId := mustParseUUID("00000000-0000-0000-0000-000000001005")
+	secondReply := func(f *fixture) {
+		f.message(models.ChatMessageEntity{Id: secondReplyId, ConversationId: groupId, FromUserId: adminId,
+			Content: "Platform 2", SentTime: now.Add(-4 * time.Minute), ThreadRootId: messageId})
+	}
+
+	runRpcCases(t, (*Server).GetThread, []rpcCase[*pb.GetThreadRequest, *pb.Thread]{
+		{
+			name:   "root with its replies in order",
+			caller: memberId,
+			setup:  secondReply,
+			req:    &pb.GetThreadRequest{RootMessageId: messageId.String(), Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Thread) {
+				if resp.GetRoot().GetId() != messageId.String() || resp.GetRoot().GetReplyCount() != 2 {
+					t.Fatalf("root = %v", resp.GetRoot())
+				}
+				assertOrderedIds(t, messageIds(resp.GetReplies()), replyId, secondReplyId)
+			},
+		},
+		{
+			name:   "default limit",
+			caller: memberId,
+			setup: func(f *fixture) {
+				for i := range defaultChatMessagesLimit {
+					f.message(models.ChatMessageEntity{Id: mustParseUUID(fmt.Sprintf("00000000-0000-0000-0000-0000000020%02d", i)), ConversationId: groupId,
+						FromUserId: adminId, Content: "More", SentTime: now.Add(-time.Minute), ThreadRootId: messageId})
+				}
+			},
+			req: &pb.GetThreadRequest{RootMessageId: messageId.String()},
+			check: func(t *testing.T, f *fixture, resp *pb.Thread) {
+				if replies := resp.GetReplies(); len(replies) != defaultChatMessagesLimit || replies[0].GetId() != replyId.String() {
+					t.Fatalf("replies = %v, want the first %d", messageIds(replies), defaultChatMessagesLimit)
+				}
+			},
+		},
+		{
+			name:   "replies after the cursor",
+			caller: memberId,
+			setup:  secondReply,
+			req:    &pb.GetThreadRequest{RootMessageId: messageId.String(), Limit: 10, After: timestamppb.New(now.Add(-5 * time.Minute))},
+			check: func(t *testing.T, f *fixture, resp *pb.Thread) {
+				assertOrderedIds(t, messageIds(resp.GetReplies()), secondReplyId)
+			},
+		},
+		{
+			name:   "deleted replies are not counted",
+			caller: memberId,
+			setup:  func(f *fixture) { f.tombstone(replyId) },
+			req:    &pb.GetThreadRequest{RootMessageId: messageId.String(), Limit: 10},
+			check: func(t *testing.T, f *fixture, resp *pb.Thread) {
+				if count := resp.GetRoot().GetReplyCount(); count != 0 {
+					t.Fatalf("reply count = %d, want 0", count)
+				}
+			},
+		},
+		{
+			name:   "root is a reply",
+			caller: memberId,
+			req:    &pb.GetThreadRequest{RootMessageId: replyId.String(), Limit: 10},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "outsider cannot read",
+			caller: outsiderId,
+			req:    &pb.GetThreadRequest{RootMessageId: messageId.String(), Limit: 10},
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "unknown root",
+			caller: memberId,
+			req:    &pb.GetThreadRequest{RootMessageId: unknownId.String(), Limit: 10},
+			code:   codes.NotFound,
+		},
+		{
+			name: "unauthenticated",
+			req:  &pb.GetThreadRequest{RootMessageId: gocql.UUID{}.String(), Limit: 10},
+			code: codes.Unauthenticated,
+		},
+	})
+}
@@ -0,0 +1,158 @@
+package cassandra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+)
+
+// Storage runs on the repositories of the models package
+type Storage struct{}
+
+func (Storage) GetConversation(conversationId gocql.UUID) (*models.ConversationEntity, error) {
+	conversation, err := models.ConversationRepository.Get(conversationId)
+	if err != nil {
+		return nil, err
+	}
+	return conversation.(*models.ConversationEntity), nil
+}
+
+func (Storage) TouchConversation(message models.ChatMessageEntity) (bool, error) {
+	return models.TouchConversation(message)
+}
+
+func (Storage) UpdateConversationPreview(message models.ChatMessageEntity) (bool, error) {
+	return models.UpdateConversationPreview(message)
+}
+
+func (Storage) InsertConversationIfNotExists(conversation models.ConversationEntity) (bool, error) {
+	return models.InsertConversationIfNotExists(conversation)
+}
+
+func (Storage) GetParticipant(conversationId gocql.UUID, userId gocql.UUID, status models.ParticipantStatus) (*models.ParticipantEntity, error) {
+	participant, err := models.ParticipantRepository.Get(conversationId, userId, int(status))
+	if err != nil {
+		return nil, err
+	}
+	return participant.(*models.ParticipantEntity), nil
+}
+
+func (Storage) FindParticipant(conversationId gocql.UUID, userId gocql.UUID) (*models.ParticipantEntity, error) {
+	return models.FindParticipant(conversationId, userId)
+}
+
+func (Storage) GetReadCursor(conversationId gocql.UUID, userId gocql.UUID) (*models.ReadCursorEntity, error) {
+	cursor, err := models.ReadCursorRepository.Get(conversationId, userId)
+	if err != nil {
+		return nil, err
+	}
+	return cursor.(*models.ReadCursorEntity), nil
+}
+
+func (Storage) ListReadCursors(conversationId gocql.UUID) ([]*models.ReadCursorEntity, error) {
+	cursors, err := models.ReadCursorRepository.List(conversationId)
+	if err != nil {
+		return nil, err
+	}
+	return cursors.([]*models.ReadCursorEntity), nil
+}
+
+func (Storage) GetChatMessage(messageId gocql.UUID) (*models.ChatMessageEntity, error) {
+	message, err := models.ChatMessageRepository.Get(messageId)
+	if err != nil {
+		return nil, err
+	}
+	return message.(*models.ChatMessageEntity), nil
+}
+
+func (Storage) InsertChatMessageIfNotExists(entity models.ChatMessageEntity) (bool, error) {
+	return models.InsertChatMessageIfNotExists(entity)
+}
+
+func (Storage) EditChatMessage(entity models.ChatMessageEntity) (bool, error) {
+	return models.EditChatMessage(entity)
+}
+
+func (Storage) IncrementUnreadCounts(conversationId gocql.UUID, userIds []gocql.UUID) error {
+	return models.IncrementUnreadCounts(conversationId, userIds)
+}
+
+func (Storage) GetUnreadCount(conversationId gocql.UUID, userId gocql.UUID) (int64, error) {
+	return models.GetUnreadCount(conversationId, userId)
+}
+
+func (Storage) SetUnreadCount(conversationId gocql.UUID, userId gocql.UUID, count int64) error {
+	return models.SetUnreadCount(conversationId, userId, count)
+}
+
+func (Storage) NextConversationSequence(conversationId gocql.UUID, messageId gocql.UUID) (int64, error) {
+	return models.NextConversationSequence(conversationId, messageId)
+}
+
+func (Storage) GetMessageSequence(messageId gocql.UUID) (int64, error) {
+	return models.GetMessageSequence(messageId)
+}
+
+func (Storage) ListConversationHistory(conversationId gocql.UUID, viewerId gocql.UUID, before time.Time, after time.Time, limit int) ([]models.ChatMessageEntity, error) {
+	return models.ListConversationHistory(conversationId, viewerId, before, after, limit)
+}
+
+func (Storage) ListConversationHistoryAfter(conversationId gocql.UUID, viewerId gocql.UUID, after time.Time, afterId gocql.UUID, limit int) ([]models.ChatMessageEntity, error) {
+	return models.ListConversationHistoryAfter(conversationId, viewerId, after, afterId, limit)
+}
+
+func (Storage) InsertChatMessageHistory(history models.ChatMessageHistoryEntity) error {
+	return models.ChatMessageHistoryRepository.Insert(history)
+}
+
+func (Storage) DeleteChatMessageHistory(messageId gocql.UUID) error {
+	table := models.ChatMessageHistoryRepository.TableInterface
+	statement := fmt.Sprintf(`DELETE FROM %q.%q WHERE message_id = ?`, table.Keyspace().Name(), table.Name())
+	return table.Query(statement, messageId).Exec()
+}
+
+func (Storage) GetMessageReaction(messageId gocql.UUID, emoji string, userId gocql.UUID) (*models.MessageReactionEntity, error) {
+	reaction, err := models.MessageReactionRepository.Get(messageId, emoji, userId)
+	if err != nil {
+		return nil, err
+	}
+	return reaction.(*models.MessageReactionEntity), nil
+}
+
+func (Storage) ListMessageReactions(messageId gocql.UUID) ([]*models.MessageReactionEntity, error) {
+	reactions, err := models.MessageReactionRepository.List(messageId)
+	if err != nil {
+		return nil, err
+	}
+	return reactions.([]*models.MessageReactionEntity), nil
+}
+
+func (Storage) GetMessageStatus(messageId gocql.UUID) (*models.MessageStatusEntity, error) {
+	messageStatus, err := models.MessageStatusRepository.Get(messageId)
+	if err != nil {
+		return nil, err
+	}
+	return messageStatus.(*models.MessageStatusEntity), nil
+}
+
+func (Storage) SaveMessageStatus(messageId gocql.UUID, conversationId gocql.UUID, fromUserId gocql.UUID, status models.DeliveryStatus, failureReason models.FailureReason) error {
+	return models.SaveMessageStatus(messageId, conversationId, fromUserId, status, failureReason)
+}
+
+func (Storage) ClaimIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) (gocql.UUID, error) {
+	return models.ClaimIdempotencyKey(conversationId, fromUserId, key, messageId)
+}
+
+func (Storage) ReleaseIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) error {
+	return models.ReleaseIdempotencyKey(conversationId, fromUserId, key, messageId)
+}
+
+func (Storage) SaveWithOutbox(rows []models.TableRow, entries ...models.OutboxEntity) error {
+	return models.SaveWithOutbox(rows, entries...)
+}
+
+func (Storage) MarkOutboxRelayed(entry models.OutboxEntity) error {
+	return models.MarkOutboxEntryRelayed(entry)
+}
@@ -0,0 +1,323 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/TripConnect/chat-service/consts"
+	"github.com/TripConnect/chat-service/models"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/esdsl"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/highlighterencoder"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/operator"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/refresh"
+	"github.com/elastic/go-elasticsearch/v9/typedapi/types/enums/sortorder"
+	"github.com/gocql/gocql"
+	"github.com/tripconnect/go-common-utils/advance_search"
+	"github.com/tripconnect/go-common-utils/common"
+)
+
+const addMemberIdsScript = `
+if (ctx._source.member_ids == null) { ctx._source.member_ids = [] }
+for (id in params.member_ids) { if (!ctx._source.member_ids.contains(id)) { ctx._source.member_ids.add(id) } }`
+
+const removeMemberIdsScript = `
+if (ctx._source.member_ids != null) { ctx._source.member_ids.removeIf(id -> params.member_ids.contains(id)) }`
+
+const hideChatMessageScript = `
+if (ctx._source.hidden_for == null) { ctx._source.hidden_for = [] }
+if (!ctx._source.hidden_for.contains(params.user_id)) { ctx._source.hidden_for.add(params.user_id) }`
+
+// Highlights of a message are at most this many fragments of about this many characters
+const (
+	highlightFragments    = 3
+	highlightFragmentSize = 100
+)
+
+// Search runs on the shared Elasticsearch client
+type Search struct{}
+
+func (Search) SearchParticipants(ctx context.Context, query store.ParticipantQuery) ([]models.ParticipantDocument, error) {
+	musts := []types.QueryVariant{
+		esdsl.NewMatchPhraseQuery("status", strconv.Itoa(int(query.Status))),
+	}
+	if query.ConversationId != (gocql.UUID{}) {
+		musts = append(musts, esdsl.NewMatchPhraseQuery("conversation_id", query.ConversationId.String()))
+	}
+	if query.UserId != (gocql.UUID{}) {
+		musts = append(musts, esdsl.NewMatchPhraseQuery("user_id", query.UserId.String()))
+	}
+
+	searchResult, err := advance_search.NewAdvanceSearch[models.ParticipantDocument]().
+		Client(common.ElasticsearchClient).
+		Query(esdsl.NewBoolQuery().Must(musts...)).
+		Index(consts.ParticipantIndex).
+		Page(query.PageNumber, query.PageSize).
+		Sort(esdsl.NewSortOptions().AddSortOption("created_at", esdsl.NewFieldSort(sortorder.Desc))).
+		Search()
+	if err != nil {
+		return nil, err
+	}
+	return searchResult.Data, nil
+}
+
+func (Search) IndexParticipant(ctx context.Context, participant models.ParticipantEntity) error {
+	participantDoc := models.NewParticipantDoc(participant, nil)
+	_, err := common.ElasticsearchClient.
+		Index(consts.ParticipantIndex).
+		Id(models.NewParticipantDocId(participant.ConversationId, participant.UserId)).
+		Request(&participantDoc).
+		Refresh(refresh.Waitfor).
+		Do(ctx)
+	return err
+}
+
+func (Search) DeleteParticipant(ctx context.Context, conversationId gocql.UUID, userId gocql.UUID) error {
+	// Delete by query so documents indexed before they had a deterministic id are removed too
+	_, err := common.ElasticsearchClient.
+		DeleteByQuery(consts.ParticipantIndex).
+		Query(esdsl.NewBoolQuery().
+			Must(
+				esdsl.NewMatchPhraseQuery("conversation_id", conversationId.String()),
+				esdsl.NewMatchPhraseQuery("user_id", userId.String()),
+			)).
+		Refresh(true).
+		Do(ctx)
+	return err
+}
+
+func (Search) SearchConversations(ctx context.Context, query store.ConversationQuery) ([]models.ConversationDocument, error) {
+	musts := []types.QueryVariant{
+		esdsl.NewMatchPhraseQuery("member_ids", query.MemberId.String()),
+	}
+	if query.Type != nil {
+		musts = append(musts, esdsl.NewMatchPhraseQuery("type", strconv.Itoa(*query.Type)))
+	}
+	if query.Name != "" {
+		musts = append(musts, esdsl.NewMatchQuery("name", query.Name).Operator(operator.And))
+	}
+	if query.After != nil {
+		// Ties on the activity time are broken by the conversation id, matching the sort below
+		lastActivity := types.Float64(query.After.LastMessageAt)
+		musts = append(musts, esdsl.NewBoolQuery().
+			Should(
+				esdsl.NewNumberRangeQuery("last_message_at").Lt(lastActivity),
+				esdsl.NewBoolQuery().Must(
+					esdsl.NewNumberRangeQuery("last_message_at").Gte(lastActivity).Lte(lastActivity),
+					esdsl.NewTermRangeQuery("id").Lt(query.After.Id.String()),
+				),
+			))
+	}
+
+	sorts := []types.SortCombinationsVariant{}
+	if query.Name != "" {
+		sorts = append(sorts, newScoreSort())
+	}
+	sorts = append(sorts,
+		esdsl.NewSortOptions().AddSortOption("last_message_at", esdsl.NewFieldSort(sortorder.Desc)),
+		esdsl.NewSortOptions().AddSortOption("id", esdsl.NewFieldSort(sortorder.Desc)))
+
+	result, err := common.ElasticsearchClient.
+		Search().
+		Index(consts.ConversationIndex).
+		Query(esdsl.NewBoolQuery().Must(musts...)).
+		Sort(sorts...).
+		From(query.PageNumber * query.PageSize).
+		Size(query.PageSize).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []models.ConversationDocument{}
+	for _, hit := range result.Hits.Hits {
+		var doc models.ConversationDocument
+		if err := json.Unmarshal(hit.Source_, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// newScoreSort puts the most relevant documents first, a sort on fields alone leaves the score uncomputed
+func newScoreSort() types.SortCombinationsVariant {
+	return esdsl.NewSortOptions().Score_(esdsl.NewScoreSort().Order(sortorder.Desc))
+}
+
+// UpdateConversation writes the fields of the conversation document kept in the row, the member ids are left as they are
+func (Search) UpdateConversation(ctx context.Context, conversation models.ConversationEntity) error {
+	conversationDoc := models.NewConversationDoc(conversation, nil)
+	_, err := common.ElasticsearchClient.
+		Update(consts.ConversationIndex, conversation.Id.String()).
+		Doc(map[string]any{
+			"id":              conversationDoc.Id,
+			"name":            conversationDoc.Name,
+			"type":            conversationDoc.Type,
+			"created_at":      conversationDoc.CreatedAt,
+			"last_message_at": conversationDoc.LastMessageAt,
+		}).
+		DocAsUpsert(true).
+		Refresh(refresh.Waitfor).
+		Do(ctx)
+	return err
+}
+
+func (Search) AddConversationMembers(ctx context.Context, conversationId gocql.UUID, memberIds []gocql.UUID) error {
+	return updateConversationMemberIds(ctx, conversationId, addMemberIdsScript, memberIds)
+}
+
+func (Search) RemoveConversationMembers(ctx context.Context, conversationId gocql.UUID, memberIds []gocql.UUID) error {
+	return updateConversationMemberIds(ctx, conversationId, removeMemberIdsScript, memberIds)
+}
+
+func updateConversationMemberIds(ctx context.Context, conversationId gocql.UUID, script string, memberIds []gocql.UUID) error {
+	params, err := json.Marshal(memberIds)
+	if err != nil {
+		return err
+	}
+
+	// The members may be synced before the conversation, its other fields are upserted by its own sync
+	_, err = common.ElasticsearchClient.
+		Update(consts.ConversationIndex, conversationId.String()).
+		Script(esdsl.NewScript().
+			Source(esdsl.NewScriptSource().String(script)).
+			AddParam("member_ids", params)).
+		ScriptedUpsert(true).
+		Upsert(map[string]any{"id": conversationId}).
+		Refresh(refresh.Waitfor).
+		Do(ctx)
+	return err
+}
+
+func newConversationIdsQuery(conversationIds []gocql.UUID) types.QueryVariant {
+	conversationIdValues := make([]types.FieldValueVariant, len(conversationIds))
+	for i, conversationId := range conversationIds {
+		conversationIdValues[i] = esdsl.NewFieldValue().String(conversationId.String())
+	}
+
+	return esdsl.NewTermsQuery().
+		AddTermsQuery("conversation_id", esdsl.NewTermsQueryField().FieldValues(conversationIdValues...))
+}
+
+func newChatMessageQuery(query store.ChatMessageQuery) types.QueryVariant {
+	musts := []types.QueryVariant{}
+	if query.Term != "" && query.Phrase {
+		musts = append(musts, esdsl.NewMatchPhraseQuery("content", query.Term))
+	} else if query.Term != "" {
+		musts = append(musts, esdsl.NewMatchQuery("content", query.Term).Operator(operator.And))
+	}
+	if len(query.ConversationIds) > 0 {
+		musts = append(musts, newConversationIdsQuery(query.ConversationIds))
+	}
+	if query.ThreadRootId != (gocql.UUID{}) {
+		musts = append(musts, esdsl.NewMatchPhraseQuery("thread_root_id", query.ThreadRootId.String()))
+	}
+	if !query.Before.IsZero() {
+		musts = append(musts, esdsl.NewNumberRangeQuery("sent_time").Lt(types.Float64(query.Before.UnixMilli())))
+	}
+	if !query.After.IsZero() {
+		musts = append(musts, esdsl.NewNumberRangeQuery("sent_time").Gt(types.Float64(query.After.UnixMilli())))
+	}
+
+	mustNots := []types.QueryVariant{}
+	if query.ViewerId != (gocql.UUID{}) {
+		mustNots = append(mustNots, esdsl.NewMatchPhraseQuery("hidden_for", query.ViewerId.String()))
+	}
+	if query.ExcludeDeleted {
+		mustNots = append(mustNots, esdsl.NewExistsQuery().Field("deleted_at"))
+	}
+
+	return esdsl.NewBoolQuery().Must(musts...).MustNot(mustNots...)
+}
+
+func (Search) SearchChatMessages(ctx context.Context, query store.ChatMessageQuery) ([]store.ChatMessageHit, error) {
+	order := sortorder.Desc
+	if query.Ascending {
+		order = sortorder.Asc
+	}
+
+	sorts := []types.SortCombinationsVariant{}
+	if query.Term != "" {
+		sorts = append(sorts, newScoreSort())
+	}
+	sorts = append(sorts, esdsl.NewSortOptions().AddSortOption("sent_time", esdsl.NewFieldSort(order)))
+
+	request := common.ElasticsearchClient.
+		Search().
+		Index(consts.ChatMessageIndex).
+		Query(newChatMessageQuery(query)).
+		Sort(sorts...).
+		From(query.PageNumber * query.PageSize).
+		Size(query.PageSize)
+	if query.Term != "" {
+		request = request.Highlight(esdsl.NewHighlight().
+			Fields([]map[string]types.HighlightField{{"content": *types.NewHighlightField()}}).
+			// Escapes the content so only the tags are markup
+			Encoder(highlighterencoder.Html).
+			PreTags("<em>").
+			PostTags("</em>").
+			FragmentSize(highlightFragmentSize).
+			NumberOfFragments(highlightFragments))
+	}
+
+	result, err := request.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []store.ChatMessageHit{}
+	for _, hit := range result.Hits.Hits {
+		messageHit := store.ChatMessageHit{Highlights: hit.Highlight["content"]}
+		if err := json.Unmarshal(hit.Source_, &messageHit.ChatMessageDocument); err != nil {
+			return nil, err
+		}
+		if hit.Score_ != nil {
+			messageHit.Score = float64(*hit.Score_)
+		}
+		hits = append(hits, messageHit)
+	}
+	return hits, nil
+}
+
+func (Search) CountChatMessages(ctx context.Context, query store.ChatMessageQuery) (int64, error) {
+	result, err := common.ElasticsearchClient.
+		Count().
+		Index(consts.ChatMessageIndex).
+		Query(newChatMessageQuery(query)).
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// UpdateChatMessage writes every field of the message document but the users it is hidden for
+func (Search) UpdateChatMessage(ctx context.Context, entity models.ChatMessageEntity) error {
+	_, err := common.ElasticsearchClient.
+		Update(consts.ChatMessageIndex, entity.Id.String()).
+		Doc(models.NewChatMessageDoc(entity)).
+		DocAsUpsert(true).
+		Do(ctx)
+	return err
+}
+
+func (Search) HideChatMessage(ctx context.Context, messageId gocql.UUID, userId gocql.UUID) error {
+	params, err := json.Marshal(userId.String())
+	if err != nil {
+		return err
+	}
+
+	// The message may be hidden before its document is synced, the sync upserts its other fields
+	_, err = common.ElasticsearchClient.
+		Update(consts.ChatMessageIndex, messageId.String()).
+		Script(esdsl.NewScript().
+			Source(esdsl.NewScriptSource().String(hideChatMessageScript)).
+			AddParam("user_id", params)).
+		ScriptedUpsert(true).
+		Upsert(map[string]any{"id": messageId}).
+		Do(ctx)
+	return err
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Event is a record published through Events, Data is the value as it was passed
+type Event struct {
+	Topic string
+	Key   string
+	Data  interface{}
+}
+
+// Events records what is published instead of sending it
+type Events struct {
+	mu      sync.Mutex
+	events  []Event
+	records []kafka.Message
+	err     error
+}
+
+func NewEvents() *Events {
+	return &Events{}
+}
+
+// SetErr fails every publish with err until it is reset with nil
+func (e *Events) SetErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+}
+
+// Published returns the events in the order they were published
+func (e *Events) Published() []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.events)
+}
+
+// Records returns the raw records in the order they were published
+func (e *Events) Records() []kafka.Message {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.records)
+}
+
+func (e *Events) Publish(ctx context.Context, topic string, data interface{}) error {
+	return e.PublishKeyed(ctx, topic, "", data)
+}
+
+func (e *Events) PublishKeyed(ctx context.Context, topic string, key string, data interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	e.events = append(e.events, Event{Topic: topic, Key: key, Data: data})
+	return nil
+}
+
+func (e *Events) PublishRecord(ctx context.Context, record kafka.Message) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	e.records = append(e.records, record)
+	return nil
+}
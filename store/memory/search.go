@@ -0,0 +1,312 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/TripConnect/chat-service/store"
+	"github.com/gocql/gocql"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Search answers queries from the rows of the storage, so documents are always in sync with their rows
+// and the writes only have to succeed
+type Search struct {
+	storage *Storage
+	// Err fails every query when set
+	Err error
+}
+
+func NewSearch(storage *Storage) *Search {
+	return &Search{storage: storage}
+}
+
+func (s *Search) SearchParticipants(ctx context.Context, query store.ParticipantQuery) ([]models.ParticipantDocument, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	s.storage.mu.RLock()
+	defer s.storage.mu.RUnlock()
+
+	participants := []models.ParticipantEntity{}
+	for _, participant := range s.storage.participants {
+		if participant.Status != int(query.Status) ||
+			query.ConversationId != (gocql.UUID{}) && participant.ConversationId != query.ConversationId ||
+			query.UserId != (gocql.UUID{}) && participant.UserId != query.UserId {
+			continue
+		}
+		participants = append(participants, participant)
+	}
+	slices.SortFunc(participants, func(a, b models.ParticipantEntity) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	docs := []models.ParticipantDocument{}
+	for _, participant := range page(participants, query.PageNumber, query.PageSize) {
+		docs = append(docs, models.NewParticipantDoc(participant, nil))
+	}
+	return docs, nil
+}
+
+func (s *Search) IndexParticipant(ctx context.Context, participant models.ParticipantEntity) error {
+	return nil
+}
+
+func (s *Search) DeleteParticipant(ctx context.Context, conversationId gocql.UUID, userId gocql.UUID) error {
+	return nil
+}
+
+func (s *Search) SearchConversations(ctx context.Context, query store.ConversationQuery) ([]models.ConversationDocument, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	s.storage.mu.RLock()
+	defer s.storage.mu.RUnlock()
+
+	docs := []models.ConversationDocument{}
+	scores := map[gocql.UUID]float64{}
+	for _, conversation := range s.storage.conversations {
+		memberIds := s.joinedMemberIds(conversation.Id)
+		doc := models.NewConversationDoc(conversation, memberIds)
+		if !slices.Contains(memberIds, query.MemberId.String()) ||
+			query.Type != nil && doc.Type != *query.Type ||
+			query.After != nil && compareActivity(doc, int(query.After.LastMessageAt), query.After.Id) >= 0 {
+			continue
+		}
+		if query.Name != "" {
+			match, ok := matchText(query.Name, doc.Name, false)
+			if !ok {
+				continue
+			}
+			scores[doc.Id] = match.score
+		}
+		docs = append(docs, doc)
+	}
+	slices.SortFunc(docs, func(a, b models.ConversationDocument) int {
+		if order := cmp.Compare(scores[b.Id], scores[a.Id]); order != 0 {
+			return order
+		}
+		return compareActivity(b, a.LastMessageAt, a.Id)
+	})
+
+	return page(docs, query.PageNumber, query.PageSize), nil
+}
+
+func (s *Search) joinedMemberIds(conversationId gocql.UUID) []string {
+	memberIds := []string{}
+	for _, participant := range s.storage.participants {
+		if participant.ConversationId == conversationId && participant.Status == int(models.Joined) {
+			memberIds = append(memberIds, participant.UserId.String())
+		}
+	}
+	slices.Sort(memberIds)
+	return memberIds
+}
+
+func (s *Search) UpdateConversation(ctx context.Context, conversation models.ConversationEntity) error {
+	return nil
+}
+
+func (s *Search) AddConversationMembers(ctx context.Context, conversationId gocql.UUID, memberIds []gocql.UUID) error {
+	return nil
+}
+
+func (s *Search) RemoveConversationMembers(ctx context.Context, conversationId gocql.UUID, memberIds []gocql.UUID) error {
+	return nil
+}
+
+func (s *Search) SearchChatMessages(ctx context.Context, query store.ChatMessageQuery) ([]store.ChatMessageHit, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	messages := s.matchChatMessages(query)
+	slices.SortFunc(messages, func(a, b matchedMessage) int {
+		if order := cmp.Compare(b.match.score, a.match.score); order != 0 {
+			return order
+		}
+		if query.Ascending {
+			return compareSentTime(a.message.SentTime, a.message.Id, b.message.SentTime, b.message.Id)
+		}
+		return compareSentTime(b.message.SentTime, b.message.Id, a.message.SentTime, a.message.Id)
+	})
+
+	hits := []store.ChatMessageHit{}
+	for _, matched := range page(messages, query.PageNumber, query.PageSize) {
+		hit := store.ChatMessageHit{ChatMessageDocument: models.NewChatMessageDoc(matched.message), Score: matched.match.score}
+		if query.Term != "" {
+			hit.Highlights = []string{matched.match.highlight}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+func (s *Search) CountChatMessages(ctx context.Context, query store.ChatMessageQuery) (int64, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return int64(len(s.matchChatMessages(query))), nil
+}
+
+type matchedMessage struct {
+	message models.ChatMessageEntity
+	match   textMatch
+}
+
+func (s *Search) matchChatMessages(query store.ChatMessageQuery) []matchedMessage {
+	s.storage.mu.RLock()
+	defer s.storage.mu.RUnlock()
+
+	messages := []matchedMessage{}
+	for _, message := range s.storage.messages {
+		if len(query.ConversationIds) > 0 && !slices.Contains(query.ConversationIds, message.ConversationId) ||
+			query.ThreadRootId != (gocql.UUID{}) && message.ThreadRootId != query.ThreadRootId ||
+			!query.Before.IsZero() && message.SentTime.UnixMilli() >= query.Before.UnixMilli() ||
+			!query.After.IsZero() && message.SentTime.UnixMilli() <= query.After.UnixMilli() ||
+			query.ExcludeDeleted && !message.DeletedAt.IsZero() {
+			continue
+		}
+		if _, hidden := s.storage.hidden[userMessageKey{query.ViewerId, message.Id}]; hidden && query.ViewerId != (gocql.UUID{}) {
+			continue
+		}
+		matched := matchedMessage{message: message}
+		if query.Term != "" {
+			match, ok := matchText(query.Term, message.Content, query.Phrase)
+			if !ok {
+				continue
+			}
+			matched.match = match
+		}
+		messages = append(messages, matched)
+	}
+	return messages
+}
+
+func (s *Search) UpdateChatMessage(ctx context.Context, entity models.ChatMessageEntity) error {
+	return nil
+}
+
+func (s *Search) HideChatMessage(ctx context.Context, messageId gocql.UUID, userId gocql.UUID) error {
+	return nil
+}
+
+// compareActivity orders the conversation against an inbox cursor, by last activity then id
+func compareActivity(doc models.ConversationDocument, lastMessageAt int, id gocql.UUID) int {
+	if doc.LastMessageAt != lastMessageAt {
+		if doc.LastMessageAt < lastMessageAt {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(doc.Id.String(), id.String())
+}
+
+// textMatch is how well a text matched the words of a term, the highlight is the HTML escaped text with the
+// matched words wrapped in <em> tags
+type textMatch struct {
+	score     float64
+	highlight string
+}
+
+// matchText requires every word of the term in the text or, for a phrase, all of them next to each other in order.
+// The score is the share of the words of the text that matched, so short texts made of the term rank first.
+func matchText(term string, text string, phrase bool) (textMatch, bool) {
+	termWords := analyze(term)
+	words := analyze(text)
+	if len(termWords) == 0 {
+		return textMatch{}, false
+	}
+
+	matched := make([]bool, len(words))
+	if phrase {
+		found := false
+		for i := 0; i+len(termWords) <= len(words); i++ {
+			if slices.EqualFunc(words[i:i+len(termWords)], termWords, func(a, b word) bool { return a.term == b.term }) {
+				for j := range termWords {
+					matched[i+j] = true
+				}
+				found = true
+			}
+		}
+		if !found {
+			return textMatch{}, false
+		}
+	} else {
+		for _, termWord := range termWords {
+			found := false
+			for i, textWord := range words {
+				if textWord.term == termWord.term {
+					matched[i] = true
+					found = true
+				}
+			}
+			if !found {
+				return textMatch{}, false
+			}
+		}
+	}
+
+	var highlight strings.Builder
+	count, end := 0, 0
+	for i, textWord := range words {
+		if !matched[i] {
+			continue
+		}
+		count++
+		highlight.WriteString(html.EscapeString(text[end:textWord.start]))
+		highlight.WriteString("<em>" + html.EscapeString(text[textWord.start:textWord.end]) + "</em>")
+		end = textWord.end
+	}
+	highlight.WriteString(html.EscapeString(text[end:]))
+	return textMatch{score: float64(count) / float64(len(words)), highlight: highlight.String()}, true
+}
+
+// word is a word of a text at its byte offsets, the term is the word as the search compares it
+type word struct {
+	term  string
+	start int
+	end   int
+}
+
+// analyze splits the text into lowercase words without accents, close to the chat_text analyzer of the indices
+func analyze(text string) []word {
+	words := []word{}
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || start >= 0 && unicode.Is(unicode.Mn, r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord && start >= 0 {
+			words = append(words, newWord(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, newWord(text, start, len(text)))
+	}
+	return words
+}
+
+func newWord(text string, start int, end int) word {
+	// Transformers keep state, so the chain is not shared between searches
+	foldAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(foldAccents, text[start:end])
+	if err != nil {
+		folded = text[start:end]
+	}
+	return word{term: strings.ToLower(folded), start: start, end: end}
+}
+
+func page[T any](items []T, pageNumber int, pageSize int) []T {
+	from := min(pageNumber*pageSize, len(items))
+	to := min(from+pageSize, len(items))
+	return items[from:to]
+}
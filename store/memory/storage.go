@@ -0,0 +1,539 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	r "github.com/kristoiv/gocqltable/reflect"
+)
+
+type participantKey struct {
+	conversationId gocql.UUID
+	userId         gocql.UUID
+	status         int
+}
+
+type userMessageKey struct {
+	userId    gocql.UUID
+	messageId gocql.UUID
+}
+
+type reactionKey struct {
+	messageId gocql.UUID
+	emoji     string
+	userId    gocql.UUID
+}
+
+type idempotencyKey struct {
+	conversationId gocql.UUID
+	fromUserId     gocql.UUID
+	key            string
+}
+
+type sequence struct {
+	last          int64
+	lastMessageId gocql.UUID
+}
+
+// Storage keeps the rows in maps, it stands in for Cassandra in tests.
+// Rows are copied in and out so callers never share them with the store.
+type Storage struct {
+	mu              sync.RWMutex
+	conversations   map[gocql.UUID]models.ConversationEntity
+	participants    map[participantKey]models.ParticipantEntity
+	readCursors     map[userMessageKey]models.ReadCursorEntity
+	unreadCounts    map[userMessageKey]int64
+	messages        map[gocql.UUID]models.ChatMessageEntity
+	histories       map[gocql.UUID][]models.ChatMessageHistoryEntity
+	hidden          map[userMessageKey]models.HiddenChatMessageEntity
+	reactions       map[reactionKey]models.MessageReactionEntity
+	statuses        map[gocql.UUID]models.MessageStatusEntity
+	idempotencyKeys map[idempotencyKey]gocql.UUID
+	sequences       map[gocql.UUID]sequence
+	reserved        map[gocql.UUID]int64
+	outbox          []models.OutboxEntity
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		conversations:   map[gocql.UUID]models.ConversationEntity{},
+		participants:    map[participantKey]models.ParticipantEntity{},
+		readCursors:     map[userMessageKey]models.ReadCursorEntity{},
+		unreadCounts:    map[userMessageKey]int64{},
+		messages:        map[gocql.UUID]models.ChatMessageEntity{},
+		histories:       map[gocql.UUID][]models.ChatMessageHistoryEntity{},
+		hidden:          map[userMessageKey]models.HiddenChatMessageEntity{},
+		reactions:       map[reactionKey]models.MessageReactionEntity{},
+		statuses:        map[gocql.UUID]models.MessageStatusEntity{},
+		idempotencyKeys: map[idempotencyKey]gocql.UUID{},
+		sequences:       map[gocql.UUID]sequence{},
+		reserved:        map[gocql.UUID]int64{},
+	}
+}
+
+// Outbox returns the entries saved so far in the order they were written
+func (s *Storage) Outbox() []models.OutboxEntity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.outbox)
+}
+
+// ChatMessageHistory returns the previous contents of the message, oldest first
+func (s *Storage) ChatMessageHistory(messageId gocql.UUID) []models.ChatMessageHistoryEntity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.histories[messageId])
+}
+
+func (s *Storage) GetConversation(conversationId gocql.UUID) (*models.ConversationEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conversation, ok := s.conversations[conversationId]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return &conversation, nil
+}
+
+func (s *Storage) TouchConversation(message models.ChatMessageEntity) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conversation, ok := s.conversations[message.ConversationId]
+	if !ok {
+		return false, gocql.ErrNotFound
+	}
+	if conversation.LastMessageAt.After(message.SentTime) {
+		return false, nil
+	}
+	conversation.LastMessageId = message.Id
+	conversation.LastMessageAt = message.SentTime
+	conversation.LastMessagePreview = models.NewMessagePreview(message.Content)
+	s.conversations[conversation.Id] = conversation
+	return true, nil
+}
+
+func (s *Storage) UpdateConversationPreview(message models.ChatMessageEntity) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conversation, ok := s.conversations[message.ConversationId]
+	if !ok || conversation.LastMessageId != message.Id {
+		return false, nil
+	}
+	conversation.LastMessagePreview = models.NewMessagePreview(message.Content)
+	s.conversations[conversation.Id] = conversation
+	return true, nil
+}
+
+func (s *Storage) InsertConversationIfNotExists(conversation models.ConversationEntity) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conversations[conversation.Id]; ok {
+		return false, nil
+	}
+	s.conversations[conversation.Id] = conversation
+	return true, nil
+}
+
+func (s *Storage) UpdateConversation(conversation models.ConversationEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[conversation.Id] = conversation
+	return nil
+}
+
+func (s *Storage) GetParticipant(conversationId gocql.UUID, userId gocql.UUID, status models.ParticipantStatus) (*models.ParticipantEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	participant, ok := s.participants[participantKey{conversationId, userId, int(status)}]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return &participant, nil
+}
+
+func (s *Storage) FindParticipant(conversationId gocql.UUID, userId gocql.UUID) (*models.ParticipantEntity, error) {
+	for _, status := range []models.ParticipantStatus{models.Joined, models.Requested} {
+		if participant, err := s.GetParticipant(conversationId, userId, status); err == nil {
+			return participant, nil
+		}
+	}
+	return nil, gocql.ErrNotFound
+}
+
+func (s *Storage) InsertParticipant(participant models.ParticipantEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.participants[participantKey{participant.ConversationId, participant.UserId, participant.Status}] = participant
+	return nil
+}
+
+func (s *Storage) DeleteParticipant(participant models.ParticipantEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.participants, participantKey{participant.ConversationId, participant.UserId, participant.Status})
+	return nil
+}
+
+func (s *Storage) GetReadCursor(conversationId gocql.UUID, userId gocql.UUID) (*models.ReadCursorEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cursor, ok := s.readCursors[userMessageKey{userId, conversationId}]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return &cursor, nil
+}
+
+func (s *Storage) ListReadCursors(conversationId gocql.UUID) ([]*models.ReadCursorEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cursors := []*models.ReadCursorEntity{}
+	for _, cursor := range s.readCursors {
+		if cursor.ConversationId == conversationId {
+			cursors = append(cursors, &cursor)
+		}
+	}
+	slices.SortFunc(cursors, func(a, b *models.ReadCursorEntity) int {
+		return strings.Compare(a.UserId.String(), b.UserId.String())
+	})
+	return cursors, nil
+}
+
+func (s *Storage) InsertReadCursor(cursor models.ReadCursorEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readCursors[userMessageKey{cursor.UserId, cursor.ConversationId}] = cursor
+	return nil
+}
+
+func (s *Storage) DeleteReadCursor(conversationId gocql.UUID, userId gocql.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.readCursors, userMessageKey{userId, conversationId})
+	return nil
+}
+
+func (s *Storage) IncrementUnreadCounts(conversationId gocql.UUID, userIds []gocql.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, userId := range userIds {
+		s.unreadCounts[userMessageKey{userId, conversationId}]++
+	}
+	return nil
+}
+
+func (s *Storage) GetUnreadCount(conversationId gocql.UUID, userId gocql.UUID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	unread, ok := s.unreadCounts[userMessageKey{userId, conversationId}]
+	if !ok {
+		return 0, gocql.ErrNotFound
+	}
+	return unread, nil
+}
+
+func (s *Storage) SetUnreadCount(conversationId gocql.UUID, userId gocql.UUID, count int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unreadCounts[userMessageKey{userId, conversationId}] = count
+	return nil
+}
+
+func (s *Storage) GetChatMessage(messageId gocql.UUID) (*models.ChatMessageEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	message, ok := s.messages[messageId]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return &message, nil
+}
+
+func (s *Storage) InsertChatMessageIfNotExists(entity models.ChatMessageEntity) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.messages[entity.Id]; ok {
+		return false, nil
+	}
+	s.messages[entity.Id] = entity
+	return true, nil
+}
+
+func (s *Storage) UpdateChatMessage(entity models.ChatMessageEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[entity.Id] = entity
+	return nil
+}
+
+func (s *Storage) EditChatMessage(entity models.ChatMessageEntity) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.messages[entity.Id]
+	if !ok || !current.DeletedAt.IsZero() {
+		return false, nil
+	}
+	current.Content = entity.Content
+	current.EditedAt = entity.EditedAt
+	s.messages[entity.Id] = current
+	return true, nil
+}
+
+func (s *Storage) NextConversationSequence(conversationId gocql.UUID, messageId gocql.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reserved, ok := s.reserved[messageId]; ok {
+		return reserved, nil
+	}
+	next := sequence{last: s.sequences[conversationId].last + 1, lastMessageId: messageId}
+	s.sequences[conversationId] = next
+	s.reserved[messageId] = next.last
+	return next.last, nil
+}
+
+func (s *Storage) GetMessageSequence(messageId gocql.UUID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reserved, ok := s.reserved[messageId]
+	if !ok {
+		return 0, gocql.ErrNotFound
+	}
+	return reserved, nil
+}
+
+// ListConversationHistory compares sent times in milliseconds, the precision Cassandra keeps
+func (s *Storage) ListConversationHistory(conversationId gocql.UUID, viewerId gocql.UUID, before time.Time, after time.Time, limit int) ([]models.ChatMessageEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	messages := []models.ChatMessageEntity{}
+	for _, message := range s.messages {
+		if message.ConversationId != conversationId {
+			continue
+		}
+		if !before.IsZero() && message.SentTime.UnixMilli() >= before.UnixMilli() {
+			continue
+		}
+		if !after.IsZero() && message.SentTime.UnixMilli() <= after.UnixMilli() {
+			continue
+		}
+		if _, ok := s.hidden[userMessageKey{viewerId, message.Id}]; ok {
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	slices.SortFunc(messages, func(a, b models.ChatMessageEntity) int {
+		return compareSentTime(b.SentTime, b.Id, a.SentTime, a.Id)
+	})
+	return messages[:min(limit, len(messages))], nil
+}
+
+func (s *Storage) ListConversationHistoryAfter(conversationId gocql.UUID, viewerId gocql.UUID, after time.Time, afterId gocql.UUID, limit int) ([]models.ChatMessageEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	messages := []models.ChatMessageEntity{}
+	for _, message := range s.messages {
+		if message.ConversationId != conversationId {
+			continue
+		}
+		if afterId == (gocql.UUID{}) && message.SentTime.UnixMilli() <= after.UnixMilli() ||
+			afterId != (gocql.UUID{}) && compareSentTime(message.SentTime, message.Id, after, afterId) <= 0 {
+			continue
+		}
+		if _, ok := s.hidden[userMessageKey{viewerId, message.Id}]; ok {
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	slices.SortFunc(messages, func(a, b models.ChatMessageEntity) int {
+		return compareSentTime(a.SentTime, a.Id, b.SentTime, b.Id)
+	})
+	return messages[:min(limit, len(messages))], nil
+}
+
+func (s *Storage) InsertChatMessageHistory(history models.ChatMessageHistoryEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.histories[history.MessageId] = append(s.histories[history.MessageId], history)
+	return nil
+}
+
+func (s *Storage) DeleteChatMessageHistory(messageId gocql.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.histories, messageId)
+	return nil
+}
+
+func (s *Storage) InsertHiddenChatMessage(hidden models.HiddenChatMessageEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hidden[userMessageKey{hidden.UserId, hidden.MessageId}] = hidden
+	return nil
+}
+
+func (s *Storage) GetMessageReaction(messageId gocql.UUID, emoji string, userId gocql.UUID) (*models.MessageReactionEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reaction, ok := s.reactions[reactionKey{messageId, emoji, userId}]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return &reaction, nil
+}
+
+// ListMessageReactions orders the reactions by emoji then user, like their clustering columns
+func (s *Storage) ListMessageReactions(messageId gocql.UUID) ([]*models.MessageReactionEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reactions := []*models.MessageReactionEntity{}
+	for _, reaction := range s.reactions {
+		if reaction.MessageId == messageId {
+			reactions = append(reactions, &reaction)
+		}
+	}
+	slices.SortFunc(reactions, func(a, b *models.MessageReactionEntity) int {
+		if byEmoji := strings.Compare(a.Emoji, b.Emoji); byEmoji != 0 {
+			return byEmoji
+		}
+		return strings.Compare(a.UserId.String(), b.UserId.String())
+	})
+	return reactions, nil
+}
+
+func (s *Storage) InsertMessageReaction(reaction models.MessageReactionEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reactions[reactionKey{reaction.MessageId, reaction.Emoji, reaction.UserId}] = reaction
+	return nil
+}
+
+func (s *Storage) DeleteMessageReaction(reaction models.MessageReactionEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reactions, reactionKey{reaction.MessageId, reaction.Emoji, reaction.UserId})
+	return nil
+}
+
+func (s *Storage) GetMessageStatus(messageId gocql.UUID) (*models.MessageStatusEntity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	messageStatus, ok := s.statuses[messageId]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return &messageStatus, nil
+}
+
+func (s *Storage) SaveMessageStatus(messageId gocql.UUID, conversationId gocql.UUID, fromUserId gocql.UUID, status models.DeliveryStatus, failureReason models.FailureReason) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[messageId] = models.MessageStatusEntity{
+		MessageId:      messageId,
+		ConversationId: conversationId,
+		FromUserId:     fromUserId,
+		Status:         int(status),
+		FailureReason:  string(failureReason),
+		UpdatedAt:      time.Now(),
+	}
+	return nil
+}
+
+func (s *Storage) ClaimIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) (gocql.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ownerId, ok := s.idempotencyKeys[idempotencyKey{conversationId, fromUserId, key}]; ok {
+		return ownerId, nil
+	}
+	s.idempotencyKeys[idempotencyKey{conversationId, fromUserId, key}] = messageId
+	return messageId, nil
+}
+
+func (s *Storage) ReleaseIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idempotencyKeys[idempotencyKey{conversationId, fromUserId, key}] == messageId {
+		delete(s.idempotencyKeys, idempotencyKey{conversationId, fromUserId, key})
+	}
+	return nil
+}
+
+// SaveWithOutbox stores the rows by their type, the history rows are left out since the history is read from the messages
+func (s *Storage) SaveWithOutbox(rows []models.TableRow, entries ...models.OutboxEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, row := range rows {
+		switch entity := row.Row.(type) {
+		case models.ConversationEntity:
+			writeRow(s.conversations, entity.Id, row, entity)
+		case models.ParticipantEntity:
+			writeRow(s.participants, participantKey{entity.ConversationId, entity.UserId, entity.Status}, row, entity)
+		case models.ReadCursorEntity:
+			writeRow(s.readCursors, userMessageKey{entity.UserId, entity.ConversationId}, row, entity)
+		case models.ChatMessageEntity:
+			writeRow(s.messages, entity.Id, row, entity)
+		case models.HiddenChatMessageEntity:
+			writeRow(s.hidden, userMessageKey{entity.UserId, entity.MessageId}, row, entity)
+		case models.MessageReactionEntity:
+			writeRow(s.reactions, reactionKey{entity.MessageId, entity.Emoji, entity.UserId}, row, entity)
+		case models.ChatMessageHistoryEntity:
+			if !row.DeletePartition {
+				return fmt.Errorf("unsupported history write %v", row)
+			}
+			delete(s.histories, entity.MessageId)
+		case models.MessageByConversationEntity, models.MessageBucketEntity:
+		default:
+			return fmt.Errorf("unsupported row %T", row.Row)
+		}
+	}
+	s.outbox = append(s.outbox, entries...)
+	return nil
+}
+
+func (s *Storage) MarkOutboxRelayed(entry models.OutboxEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.outbox {
+		if s.outbox[i].EntryId == entry.EntryId {
+			s.outbox[i].Relayed = true
+		}
+	}
+	return nil
+}
+
+// writeRow applies the row like its statement would, an update of missing row creates it with its keys and columns
+func writeRow[K comparable, V any](rows map[K]V, key K, row models.TableRow, entity V) {
+	switch {
+	case row.Delete:
+		delete(rows, key)
+	case len(row.Columns) > 0:
+		current := rows[key]
+		fields, _ := r.StructToMap(entity)
+		columns := map[string]interface{}{}
+		for _, column := range slices.Concat(row.Table.RowKeys(), row.Table.RangeKeys(), row.Columns) {
+			columns[column] = fields[column]
+		}
+		_ = r.MapToStruct(columns, &current)
+		rows[key] = current
+	default:
+		rows[key] = entity
+	}
+}
+
+// compareSentTime orders messages by sent time in milliseconds, ties are broken by id
+func compareSentTime(aTime time.Time, aId gocql.UUID, bTime time.Time, bId gocql.UUID) int {
+	if aTime.UnixMilli() != bTime.UnixMilli() {
+		if aTime.UnixMilli() < bTime.UnixMilli() {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(aId.String(), bId.String())
+}
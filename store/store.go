@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/TripConnect/chat-service/models"
+	"github.com/gocql/gocql"
+	"github.com/segmentio/kafka-go"
+)
+
+// Storage is the Cassandra side of the service, lookups of missing rows fail with gocql.ErrNotFound.
+// Rows with an Elasticsearch document or a Kafka event are only written through SaveWithOutbox.
+type Storage interface {
+	GetConversation(conversationId gocql.UUID) (*models.ConversationEntity, error)
+	// TouchConversation records the message as the last activity unless a later message is recorded, it reports whether it did.
+	// It fails with gocql.ErrNotFound when the conversation does not exist.
+	TouchConversation(message models.ChatMessageEntity) (bool, error)
+	// UpdateConversationPreview replaces the preview if the message is still the last one, it reports whether it did
+	UpdateConversationPreview(message models.ChatMessageEntity) (bool, error)
+
+	// InsertConversationIfNotExists reports whether the conversation was created by this call
+	InsertConversationIfNotExists(conversation models.ConversationEntity) (bool, error)
+
+	GetParticipant(conversationId gocql.UUID, userId gocql.UUID, status models.ParticipantStatus) (*models.ParticipantEntity, error)
+	// FindParticipant looks the participant up under every status
+	FindParticipant(conversationId gocql.UUID, userId gocql.UUID) (*models.ParticipantEntity, error)
+
+	GetReadCursor(conversationId gocql.UUID, userId gocql.UUID) (*models.ReadCursorEntity, error)
+	ListReadCursors(conversationId gocql.UUID) ([]*models.ReadCursorEntity, error)
+
+	// IncrementUnreadCounts adds one unread message for each of the users
+	IncrementUnreadCounts(conversationId gocql.UUID, userIds []gocql.UUID) error
+	GetUnreadCount(conversationId gocql.UUID, userId gocql.UUID) (int64, error)
+	SetUnreadCount(conversationId gocql.UUID, userId gocql.UUID, count int64) error
+
+	GetChatMessage(messageId gocql.UUID) (*models.ChatMessageEntity, error)
+	// InsertChatMessageIfNotExists reports whether the message was created by this call
+	InsertChatMessageIfNotExists(entity models.ChatMessageEntity) (bool, error)
+	// EditChatMessage writes the content and edit time unless the message was deleted meanwhile, it reports whether it did
+	EditChatMessage(entity models.ChatMessageEntity) (bool, error)
+	// NextConversationSequence returns the number given to the message before if any, numbers skipped by failed messages are not reused
+	NextConversationSequence(conversationId gocql.UUID, messageId gocql.UUID) (int64, error)
+	GetMessageSequence(messageId gocql.UUID) (int64, error)
+	ListConversationHistory(conversationId gocql.UUID, viewerId gocql.UUID, before time.Time, after time.Time, limit int) ([]models.ChatMessageEntity, error)
+	// ListConversationHistoryAfter pages oldest first from the sent time and id of the last message received
+	ListConversationHistoryAfter(conversationId gocql.UUID, viewerId gocql.UUID, after time.Time, afterId gocql.UUID, limit int) ([]models.ChatMessageEntity, error)
+	InsertChatMessageHistory(history models.ChatMessageHistoryEntity) error
+	DeleteChatMessageHistory(messageId gocql.UUID) error
+
+	GetMessageReaction(messageId gocql.UUID, emoji string, userId gocql.UUID) (*models.MessageReactionEntity, error)
+	ListMessageReactions(messageId gocql.UUID) ([]*models.MessageReactionEntity, error)
+
+	GetMessageStatus(messageId gocql.UUID) (*models.MessageStatusEntity, error)
+	SaveMessageStatus(messageId gocql.UUID, conversationId gocql.UUID, fromUserId gocql.UUID, status models.DeliveryStatus, failureReason models.FailureReason) error
+
+	// ClaimIdempotencyKey returns the id of the message owning the key
+	ClaimIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) (gocql.UUID, error)
+	// ReleaseIdempotencyKey frees the key only while the message still owns it
+	ReleaseIdempotencyKey(conversationId gocql.UUID, fromUserId gocql.UUID, key string, messageId gocql.UUID) error
+
+	// SaveWithOutbox writes the rows and the outbox entries atomically
+	SaveWithOutbox(rows []models.TableRow, entries ...models.OutboxEntity) error
+	// MarkOutboxRelayed tells the relay the entry was already projected by its writer
+	MarkOutboxRelayed(entry models.OutboxEntity) error
+}
+
+// ParticipantQuery matches the participants of a conversation or the conversations of a user, zero ids match any.
+// Participants are ordered newest first.
+type ParticipantQuery struct {
+	ConversationId gocql.UUID
+	UserId         gocql.UUID
+	Status         models.ParticipantStatus
+	PageNumber     int
+	PageSize       int
+}
+
+// ActivityCursor is the last conversation of an inbox page, the next page starts after it
+type ActivityCursor struct {
+	LastMessageAt int64
+	Id            gocql.UUID
+}
+
+// ConversationQuery matches the conversations of a member, ordered by last activity then id, newest first.
+// With a name the most relevant conversations come first, an After cursor only pages the activity order.
+type ConversationQuery struct {
+	MemberId gocql.UUID
+	Type     *int
+	// Name is full text, every word has to be in the name, empty matches any
+	Name       string
+	After      *ActivityCursor
+	PageNumber int
+	PageSize   int
+}
+
+// ChatMessageQuery matches chat messages, zero fields do not filter.
+// Messages are ordered by sent time, newest first unless Ascending is set. With a term the most relevant messages
+// come first and ties are broken by the newest.
+type ChatMessageQuery struct {
+	// Term is full text, every word has to be in the content
+	Term string
+	// Phrase requires the words of the term next to each other and in order
+	Phrase          bool
+	ConversationIds []gocql.UUID
+	ThreadRootId    gocql.UUID
+	Before          time.Time
+	After           time.Time
+	// Messages the viewer deleted for themselves are left out
+	ViewerId       gocql.UUID
+	ExcludeDeleted bool
+	Ascending      bool
+	PageNumber     int
+	PageSize       int
+}
+
+// ChatMessageHit is a message matched by a search, the highlights are fragments of the content with the words of
+// the term wrapped in <em> tags, the content is HTML escaped so the tags are the only markup
+type ChatMessageHit struct {
+	models.ChatMessageDocument
+	Score      float64
+	Highlights []string
+}
+
+// Search is the Elasticsearch side of the service. Its writes are made by the outbox relay, they upsert the fields
+// they write so they can be applied before the document is indexed.
+type Search interface {
+	SearchParticipants(ctx context.Context, query ParticipantQuery) ([]models.ParticipantDocument, error)
+	IndexParticipant(ctx context.Context, participant models.ParticipantEntity) error
+	DeleteParticipant(ctx context.Context, conversationId gocql.UUID, userId gocql.UUID) error
+
+	SearchConversations(ctx context.Context, query ConversationQuery) ([]models.ConversationDocument, error)
+	// UpdateConversation writes the fields kept in the row, the member ids are only changed with their own methods
+	UpdateConversation(ctx context.Context, conversation models.ConversationEntity) error
+	AddConversationMembers(ctx context.Context, conversationId gocql.UUID, memberIds []gocql.UUID) error
+	RemoveConversationMembers(ctx context.Context, conversationId gocql.UUID, memberIds []gocql.UUID) error
+
+	SearchChatMessages(ctx context.Context, query ChatMessageQuery) ([]ChatMessageHit, error)
+	CountChatMessages(ctx context.Context, query ChatMessageQuery) (int64, error)
+	// UpdateChatMessage writes every field of the message document but the users it is hidden for
+	UpdateChatMessage(ctx context.Context, entity models.ChatMessageEntity) error
+	HideChatMessage(ctx context.Context, messageId gocql.UUID, userId gocql.UUID) error
+}
+
+// Events publishes the records of the service, data is encoded as JSON and should be passed as pointer
+type Events interface {
+	Publish(ctx context.Context, topic string, data interface{}) error
+	// PublishKeyed sends records with the same key to the same partition, which keeps their order
+	PublishKeyed(ctx context.Context, topic string, key string, data interface{}) error
+	// PublishRecord sends an already encoded record as is, headers included
+	PublishRecord(ctx context.Context, record kafka.Message) error
+}
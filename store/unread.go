@@ -0,0 +1,38 @@
+package store
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	unreadRecountPageSize = 200
+	// MaxUnreadRecount bounds the history read by a recount, clients show larger counts as this number or more
+	MaxUnreadRecount = 999
+)
+
+// RecountUnreadMessages sets the unread counter of the user to the messages of others sent after the given message,
+// the counter only adds messages so deletions and hidden messages are taken out here
+func RecountUnreadMessages(storage Storage, conversationId gocql.UUID, userId gocql.UUID, after time.Time, afterId gocql.UUID) error {
+	var unread int64
+	for unread < MaxUnreadRecount {
+		page, err := storage.ListConversationHistoryAfter(conversationId, userId, after, afterId, unreadRecountPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range page {
+			if message.FromUserId != userId && message.DeletedAt.IsZero() {
+				unread++
+			}
+		}
+
+		if len(page) < unreadRecountPageSize {
+			break
+		}
+		after, afterId = page[len(page)-1].SentTime, page[len(page)-1].Id
+	}
+
+	return storage.SetUnreadCount(conversationId, userId, min(unread, MaxUnreadRecount))
+}